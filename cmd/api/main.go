@@ -4,22 +4,29 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/nats-io/nats.go"
 
 	"essg/internal/adapter/storage"
 	"essg/internal/config"
+	"essg/internal/domain/identity"
+	"essg/internal/domain/messaging"
+	"essg/internal/domain/space"
 	"essg/internal/domain/trend"
 	"essg/internal/server"
 	geoService "essg/internal/service/geo"
+	identityService "essg/internal/service/identity"
 	"essg/internal/service/listening"
+	messagingService "essg/internal/service/messaging"
 	spaceService "essg/internal/service/space"
 )
 
@@ -54,6 +61,20 @@ func main() {
 	// Initialize storage adapters
 	trendStore := storage.NewTrendStore(db)
 	spaceStore := storage.NewSpaceStore(db)
+	relationStore := storage.NewRelationStore(db)
+	archiveStore := storage.NewArchiveStore(db)
+	admissionStore := storage.NewAdmissionStore(db)
+	membershipStore := storage.NewMembershipStore(db)
+	identityStore := storage.NewIdentityStore(db)
+	pollStore := storage.NewPollStore(db)
+	timelineStore := storage.NewTimelineStore(db)
+	tokenStore := storage.NewTokenStore(db)
+	rateLimitStore := storage.NewRateLimitStore(db)
+	messageStore := storage.NewMessageStore(db)
+	reputationStore := storage.NewReputationStore(db)
+	presenceStore := storage.NewPresenceStore(db)
+	eventStore := storage.NewEventStore(db)
+	analyticsStore := storage.NewAnalyticsStore(db)
 
 	// Initialize services
 	trendAnalyzer := listening.NewAnalyzer()
@@ -65,6 +86,17 @@ func main() {
 	// Create geocoder service
 	geocoder := geoService.NewGeocoderService()
 
+	// Load the offline population dataset that densities come from
+	var population *geoService.PopulationGrid
+	if cfg.Geo.PopulationDataset != "" {
+		population, err = geoService.LoadPopulationGrid(cfg.Geo.PopulationDataset)
+		if err != nil {
+			log.Fatalf("Failed to load population dataset: %v", err)
+		}
+	} else {
+		log.Printf("No population dataset configured; geo engagement will not be weighted by density")
+	}
+
 	// Initialize geospatial service
 	geoSpatialService := geoService.NewGeoSpatialService(
 		db,
@@ -76,6 +108,7 @@ func main() {
 			MaxRadius:                   cfg.Geo.MaxRadius,
 			ClusterThreshold:            cfg.Geo.ClusterThreshold,
 			PopulationDensityThresholds: cfg.Geo.PopulationDensityThresholds,
+			Population:                  population,
 		},
 	)
 
@@ -95,55 +128,324 @@ func main() {
 		},
 	)
 
-	// Initialize engagement analyzer
+	// Initialize presence, shared with other replicas through the database
+	presenceService := spaceService.NewPresenceService(
+		presenceStore,
+		natsConn,
+		spaceService.PresenceConfig{
+			IdleAfter:         cfg.Space.PresenceIdleAfter,
+			HeartbeatInterval: cfg.Space.PresenceHeartbeat,
+			StaleAfter:        3 * cfg.Space.PresenceHeartbeat,
+			TypingTimeout:     cfg.Space.TypingTimeout,
+			TypingDebounce:    cfg.Space.TypingDebounce,
+			SweepInterval:     time.Second,
+			StoreTimeout:      5 * time.Second,
+		},
+	)
+	presenceService.Start()
+
+	// Load the lexicon that scores message sentiment and toxicity
+	lexicon, err := spaceService.LoadDefaultLexicon()
+	if err != nil {
+		log.Fatalf("Failed to load lexicon: %v", err)
+	}
+
+	// Personas and location grids are keyed by their own secret when one is set
+	personaSecret := cfg.Identity.PersonaSecret
+	if personaSecret == "" {
+		personaSecret = cfg.Identity.TokenSecret
+	}
+
+	// Initialize location privacy, keyed like personas so grids can't be linked across spaces
+	privacyService := identityService.NewPrivacyService(identityService.PrivacySettings{
+		Secret: personaSecret,
+		Precision: map[identity.LocationSharingLevel]float64{
+			identity.LocationSharingPrecise:      10,
+			identity.LocationSharingNeighborhood: 1000,
+			identity.LocationSharingApproximate:  5000,
+		},
+		DefaultLocationSharing: identity.LocationSharingLevel(cfg.Identity.DefaultLocationSharing),
+		DefaultAnonymity:       cfg.Identity.DefaultAnonymity,
+		MessageRetention:       cfg.Messaging.MessageRetention,
+		TokenLifetime:          cfg.Identity.TokenExpiry,
+		GuestTokenLifetime:     cfg.Identity.GuestTokenExpiry,
+	})
+
+	// Initialize engagement analyzer, counting live presence as active users and
+	// only the locations members consented to share
 	engagementAnalyzer := spaceService.NewEngagementAnalyzer(
 		db,
 		natsConn,
 		geoSpatialService,
+		presenceService,
+		privacyService,
 		spaceService.EngagementAnalyzerConfig{
-			MonitoringInterval: cfg.Space.MonitoringInterval,
+			Models: []space.EngagementModel{
+				spaceService.EWMAEngagementModel{
+					HalfLife:       cfg.Space.EWMAHalfLife,
+					ReactionWeight: cfg.Space.EWMAReactionWeight,
+				},
+			},
+			Signals: spaceService.SignalConfig{
+				Lexicon:             lexicon,
+				ToxicityThreshold:   cfg.Space.ToxicityThreshold,
+				ToxicityMinMessages: cfg.Space.ToxicityMinMessages,
+			},
+			LocalRatioSample: cfg.Space.LocalRatioSample,
 		},
 	)
 
 	// Initialize space manager
 	spaceManager := spaceService.NewSpaceManager(
 		spaceStore,
+		admissionStore,
+		analyticsStore,
 		engagementAnalyzer,
 		natsConn,
 		spaceService.SpaceManagerConfig{
 			EventsTopic:         cfg.Space.EventsTopic,
 			DefaultGracePeriod:  cfg.Space.DefaultGracePeriod,
 			MonitoringInterval:  cfg.Space.MonitoringInterval,
+			CountdownInterval:   cfg.Space.CountdownInterval,
 			MaxConcurrentSpaces: cfg.Space.MaxConcurrentSpaces,
+			Admission: spaceService.AdmissionConfig{
+				Policy:              spaceService.AdmissionPolicy(cfg.Space.AdmissionPolicy),
+				MaxQueuedTrends:     cfg.Space.MaxQueuedTrends,
+				QueuedTrendTTL:      cfg.Space.QueuedTrendTTL,
+				EvictionGracePeriod: cfg.Space.EvictionGracePeriod,
+				EvictionMaxScore:    cfg.Space.EvictionMaxScore,
+			},
+			Analytics: spaceService.AnalyticsConfig{
+				DefaultRange:  24 * time.Hour,
+				DefaultBucket: cfg.Space.AnalyticsBucket,
+				MaxBuckets:    cfg.Space.AnalyticsMaxBuckets,
+			},
+		},
+	)
+
+	// Load space templates, reloading them when their definitions change
+	templateLoader := spaceService.NewTemplateLoader(
+		spaceService.TemplateRegistrars{spaceManager, engagementAnalyzer},
+		spaceService.TemplateLoaderConfig{
+			Dir:              cfg.Space.TemplatesDir,
+			EngagementModels: engagementAnalyzer.ModelNames(),
+			ReloadInterval:   cfg.Space.TemplatesReload,
 		},
 	)
+	if err := templateLoader.Load(); err != nil {
+		log.Fatalf("Failed to load space templates: %v", err)
+	}
+	templateLoader.Start()
 
-	// Register space templates
-	registerSpaceTemplates(spaceManager)
+	// Initialize related space discovery
+	relatedSpaceService := spaceService.NewRelatedSpaceService(
+		relationStore,
+		spaceStore,
+		geoSpatialService,
+		spaceService.RelatedSpaceConfig{
+			RefreshInterval: cfg.Space.RelatedRefresh,
+			MaxRelated:      cfg.Space.MaxRelatedSpaces,
+			MinScore:        cfg.Space.RelatedMinScore,
+			ProximityKm:     cfg.Space.RelatedProximityKm,
+			MaxCandidates:   cfg.Space.MaxConcurrentSpaces,
+		},
+	)
+	spaceManager.RegisterLifecycleHandler(relatedSpaceService.HandleLifecycleChange)
+	relatedSpaceService.Start()
+
+	// Initialize space archiving, kept no longer than messages are retained
+	spaceArchiver := spaceService.NewSpaceArchiver(
+		archiveStore,
+		spaceService.ArchiverConfig{
+			Retention:       cfg.Messaging.MessageRetention,
+			TopMessages:     cfg.Space.ArchiveTopMessages,
+			CurveBucket:     cfg.Space.ArchiveCurveBucket,
+			CleanupInterval: time.Hour,
+		},
+	)
+	if cfg.Space.ArchiveEnabled {
+		spaceManager.RegisterLifecycleHandler(spaceArchiver.HandleLifecycleChange)
+	}
+
+	// Remove expired archives, including any left from when archiving was enabled
+	spaceArchiver.Start()
+
+	// Initialize space membership, with personas keyed by the persona secret
+	membershipService := spaceService.NewMembershipService(
+		membershipStore,
+		identityService.NewPersonaService(personaSecret),
+		natsConn,
+		spaceService.MembershipConfig{
+			ActiveWindow:           cfg.Space.ActiveWindow,
+			DefaultAnonymity:       cfg.Identity.DefaultAnonymity,
+			DefaultLocationSharing: identity.LocationSharingLevel(cfg.Identity.DefaultLocationSharing),
+		},
+	)
+
+	// Initialize authentication tokens
+	tokenService := identityService.NewTokenService(
+		tokenStore,
+		natsConn,
+		identityService.TokenConfig{
+			Secret:          cfg.Identity.TokenSecret,
+			DefaultTTL:      cfg.Identity.TokenExpiry,
+			StoreTimeout:    5 * time.Second,
+			CleanupInterval: time.Hour,
+		},
+	)
+	tokenService.Start()
+
+	// Initialize users and their ephemeral identities
+	identityManager := identityService.NewIdentityService(
+		identityStore,
+		membershipService,
+		tokenService,
+		identityService.IdentityConfig{
+			DefaultAnonymity:       cfg.Identity.DefaultAnonymity,
+			DefaultLocationSharing: identity.LocationSharingLevel(cfg.Identity.DefaultLocationSharing),
+			GuestTTL:               cfg.Identity.GuestTokenExpiry,
+			GuestLimit:             cfg.Identity.GuestLimit,
+			GuestLimitWindow:       cfg.Identity.GuestLimitWindow,
+			GuestCleanupInterval:   time.Hour,
+			StoreTimeout:           30 * time.Second,
+		},
+	)
+	identityManager.Start()
+
+	// Initialize reputation, credited from message and reaction events
+	reputationService := identityService.NewReputationService(
+		reputationStore,
+		natsConn,
+		identityService.ReputationConfig{
+			HelpfulnessCredit: 1,
+			ReplyCredit:       1,
+			NewGiverWeight:    0.1,
+			StrikePenalty:     5,
+			StrikeLimit:       3,
+			EstablishedScore:  3,
+			TrustedScore:      10,
+			CarryOver:         cfg.Identity.TrustCarryOver,
+			QueueGroup:        "reputation",
+			StoreTimeout:      5 * time.Second,
+		},
+	)
+	if err := reputationService.Start(); err != nil {
+		log.Fatalf("Failed to start reputation service: %v", err)
+	}
+
+	// Initialize the event log, recording replayable space events for resuming streams
+	eventLog := spaceService.NewEventLogService(
+		eventStore,
+		natsConn,
+		spaceService.EventLogConfig{
+			Topics:       space.LoggedEventTopics,
+			QueueGroup:   "event-log",
+			StoreTimeout: 5 * time.Second,
+		},
+	)
+	if err := eventLog.Start(); err != nil {
+		log.Fatalf("Failed to start event log: %v", err)
+	}
+
+	// Initialize rate limits, with guests and restricted identities held to the strictest tier
+	rateLimiter := messagingService.NewRateLimitService(
+		rateLimitStore,
+		identityManager,
+		reputationService,
+		messagingService.RateLimitConfig{
+			Window: cfg.Messaging.RateLimitWindow,
+			Limits: map[messaging.RateTier]int{
+				messaging.TierGuest:   cfg.Messaging.GuestRateLimit,
+				messaging.TierMember:  cfg.Messaging.RateLimit,
+				messaging.TierTrusted: cfg.Messaging.TrustedRateLimit,
+			},
+			StoreTimeout: 5 * time.Second,
+		},
+	)
+
+	// Initialize message history, stored before messages and reactions are delivered
+	historyService := messagingService.NewHistoryService(
+		messageStore,
+		messagingService.HistoryConfig{
+			MaxRecentMessages: 50,
+		},
+	)
+
+	// Initialize polls, closed when their deadline passes or their space dissolves
+	pollService := spaceService.NewPollService(
+		pollStore,
+		spaceStore,
+		natsConn,
+		spaceService.PollConfig{
+			MinOptions:        2,
+			MaxOptions:        cfg.Space.PollMaxOptions,
+			MaxQuestionLength: 300,
+			MaxOptionLength:   100,
+			SweepInterval:     cfg.Space.PollSweepInterval,
+		},
+	)
+	spaceManager.RegisterLifecycleHandler(pollService.HandleLifecycleChange)
+	pollService.Start()
+
+	// Initialize curated timelines, suggesting updates from trend sources
+	timelineService := spaceService.NewTimelineService(
+		timelineStore,
+		spaceStore,
+		reputationService,
+		natsConn,
+		spaceService.TimelineConfig{
+			MaxContentLength: 1000,
+			MaxSuggestions:   cfg.Space.TimelineSuggestions,
+		},
+	)
 
 	// Register trend handler to create spaces automatically
 	trendDetector.RegisterTrendHandler(func(t trend.Trend) error {
 		if t.Score >= cfg.Trend.TrendThreshold {
 			_, err := spaceManager.CreateSpace(context.Background(), t)
+			if errors.Is(err, space.ErrAtCapacity) {
+				// Admission control has queued or rejected the trend and recorded why
+				return nil
+			}
 			return err
 		}
 		return nil
 	})
 
+	// Suggest timeline updates as the detector sees new trend sources
+	trendDetector.RegisterTrendHandler(timelineService.HandleTrend)
+
 	// Start the trend detector
 	if err := trendDetector.Start(ctx); err != nil {
 		log.Fatalf("Failed to start trend detector: %v", err)
 	}
 
 	// Initialize HTTP server
-	httpServer := server.NewServer(
-		cfg.Server,
-		db,
-		natsConn,
-		trendDetector,
-		spaceManager,
-		geoSpatialService,
-	)
+	httpServer := server.NewServer(cfg.Server, server.Deps{
+		DB:                db,
+		NatsConn:          natsConn,
+		TrendDetector:     trendDetector,
+		SpaceManager:      spaceManager,
+		RelationFinder:    relatedSpaceService,
+		Archiver:          spaceArchiver,
+		AdmissionReporter: spaceManager,
+		Analytics:         spaceManager,
+		Membership:        membershipService,
+		Presence:          presenceService,
+		Events:            eventLog,
+		TemplateSelector:  spaceManager,
+		Polls:             pollService,
+		Timeline:          timelineService,
+		Tokens:            tokenService,
+		Identities:        identityManager,
+		Guests:            identityManager,
+		Limiter:           rateLimiter,
+		History:           historyService,
+		Reputation:        reputationService,
+		Privacy:           privacyService,
+		PrivacyConfig:     privacyService,
+		GeoService:        geoSpatialService,
+	})
 
 	// Start HTTP server
 	go func() {
@@ -179,6 +481,51 @@ func main() {
 		log.Printf("Space manager shutdown error: %v", err)
 	}
 
+	// Stop presence tracking, announcing departures of identities connected here
+	if err := presenceService.Stop(shutdownCtx); err != nil {
+		log.Printf("Presence service shutdown error: %v", err)
+	}
+
+	// Stop poll deadline sweep
+	if err := pollService.Stop(shutdownCtx); err != nil {
+		log.Printf("Poll service shutdown error: %v", err)
+	}
+
+	// Stop following reputation events
+	if err := reputationService.Stop(shutdownCtx); err != nil {
+		log.Printf("Reputation service shutdown error: %v", err)
+	}
+
+	// Stop recording space events
+	if err := eventLog.Stop(shutdownCtx); err != nil {
+		log.Printf("Event log shutdown error: %v", err)
+	}
+
+	// Stop expired guest cleanup
+	if err := identityManager.Stop(shutdownCtx); err != nil {
+		log.Printf("Identity service shutdown error: %v", err)
+	}
+
+	// Stop token cleanup
+	if err := tokenService.Stop(shutdownCtx); err != nil {
+		log.Printf("Token service shutdown error: %v", err)
+	}
+
+	// Stop archive cleanup
+	if err := spaceArchiver.Stop(shutdownCtx); err != nil {
+		log.Printf("Space archiver shutdown error: %v", err)
+	}
+
+	// Stop template reloading
+	if err := templateLoader.Stop(shutdownCtx); err != nil {
+		log.Printf("Template loader shutdown error: %v", err)
+	}
+
+	// Stop related space discovery
+	if err := relatedSpaceService.Stop(shutdownCtx); err != nil {
+		log.Printf("Related space service shutdown error: %v", err)
+	}
+
 	log.Println("Shutdown complete")
 }
 
@@ -235,21 +582,3 @@ func initNATS(cfg config.NATSConfig) (*nats.Conn, error) {
 
 	return nc, nil
 }
-
-// Register space templates
-func registerSpaceTemplates(manager *spaceService.SpaceManager) {
-	// General template
-	manager.RegisterTemplate(spaceService.NewGeneralTemplate())
-
-	// Breaking news template
-	manager.RegisterTemplate(spaceService.NewBreakingNewsTemplate())
-
-	// Event template
-	manager.RegisterTemplate(spaceService.NewEventTemplate())
-
-	// Discussion template
-	manager.RegisterTemplate(spaceService.NewDiscussionTemplate())
-
-	// Local template
-	manager.RegisterTemplate(spaceService.NewLocalTemplate())
-}
@@ -0,0 +1,160 @@
+// cmd/backtest/main.go
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"essg/internal/adapter/storage"
+	"essg/internal/config"
+	"essg/internal/domain/space"
+	spaceService "essg/internal/service/space"
+)
+
+// Replays spaces' recorded analytics through engagement models and compares
+// the lifecycle each model would have produced with what was recorded.
+//
+//	backtest -spaces <id>,<id> [-models default,ewma]
+func main() {
+	spaceIDs := flag.String("spaces", "", "comma-separated IDs of the spaces to replay")
+	modelNames := flag.String("models", "default,ewma", "comma-separated engagement models to compare")
+	flag.Parse()
+
+	if *spaceIDs == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	available := map[string]space.EngagementModel{}
+	for _, model := range []space.EngagementModel{
+		spaceService.DefaultEngagementModel{},
+		spaceService.EWMAEngagementModel{
+			HalfLife:       cfg.Space.EWMAHalfLife,
+			ReactionWeight: cfg.Space.EWMAReactionWeight,
+		},
+	} {
+		available[model.Name()] = model
+	}
+
+	var models []space.EngagementModel
+	var availableNames []string
+	for name := range available {
+		availableNames = append(availableNames, name)
+	}
+	for _, name := range strings.Split(*modelNames, ",") {
+		model, ok := available[strings.TrimSpace(name)]
+		if !ok {
+			log.Fatalf("Unknown engagement model %q", name)
+		}
+		models = append(models, model)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	db, err := initDatabase(ctx, cfg.Database)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	lexicon, err := spaceService.LoadDefaultLexicon()
+	if err != nil {
+		log.Fatalf("Failed to load lexicon: %v", err)
+	}
+
+	backtester := spaceService.NewBacktester(
+		storage.NewAnalyticsStore(db),
+		storage.NewSpaceStore(db),
+		spaceService.BacktestConfig{
+			ActivityLimit: 100,
+			Signals: spaceService.SignalConfig{
+				Lexicon:             lexicon,
+				ToxicityThreshold:   cfg.Space.ToxicityThreshold,
+				ToxicityMinMessages: cfg.Space.ToxicityMinMessages,
+			},
+		},
+	)
+
+	// Replay spaces against the same thresholds their templates use
+	templateLoader := spaceService.NewTemplateLoader(
+		backtester,
+		spaceService.TemplateLoaderConfig{
+			Dir:              cfg.Space.TemplatesDir,
+			EngagementModels: availableNames,
+		},
+	)
+	if err := templateLoader.Load(); err != nil {
+		log.Fatalf("Failed to load space templates: %v", err)
+	}
+
+	results, err := backtester.Run(ctx, strings.Split(*spaceIDs, ","), models)
+	if err != nil {
+		log.Fatalf("Failed to run backtest: %v", err)
+	}
+
+	printResults(results)
+}
+
+// printResults writes a comparison table for each space
+func printResults(results []spaceService.BacktestResult) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+
+	for _, result := range results {
+		fmt.Fprintf(w, "%s (%s, %d samples)\n", result.SpaceID, result.TemplateType, result.Samples)
+		fmt.Fprintln(w, "MODEL\tPEAK\tMEAN\tDISSOLVES\tSTAGES")
+
+		outcomes := append([]spaceService.BacktestOutcome{result.Recorded}, result.Replays...)
+		for _, outcome := range outcomes {
+			dissolves := "never"
+			if !outcome.DissolvedAt.IsZero() {
+				dissolves = outcome.DissolvedAt.Format(time.RFC3339)
+			}
+
+			stages := make([]string, 0, len(outcome.Transitions))
+			for _, transition := range outcome.Transitions {
+				stages = append(stages, fmt.Sprintf("%s@%s", transition.Stage, transition.Time.Format("15:04")))
+			}
+
+			fmt.Fprintf(w, "%s\t%.1f\t%.1f\t%s\t%s\n",
+				outcome.Model, outcome.PeakScore, outcome.MeanScore, dissolves, strings.Join(stages, " "))
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// Initialize database connection
+func initDatabase(ctx context.Context, cfg config.DatabaseConfig) (*pgxpool.Pool, error) {
+	connString := fmt.Sprintf(
+		"postgres://%s:%s@%s:%d/%s?sslmode=%s",
+		cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.Database, cfg.SSLMode,
+	)
+
+	poolConfig, err := pgxpool.ParseConfig(connString)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse connection string: %w", err)
+	}
+
+	db, err := pgxpool.ConnectConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to database: %w", err)
+	}
+
+	return db, nil
+}
@@ -0,0 +1,211 @@
+// internal/adapter/storage/admission_store.go
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"essg/internal/domain/space"
+)
+
+// AdmissionStore implements storage for space admission control
+type AdmissionStore struct {
+	db *pgxpool.Pool
+}
+
+// NewAdmissionStore creates a new admission store
+func NewAdmissionStore(db *pgxpool.Pool) *AdmissionStore {
+	return &AdmissionStore{
+		db: db,
+	}
+}
+
+// CountLiveSpaces counts the spaces that have not yet dissolved
+func (s *AdmissionStore) CountLiveSpaces(ctx context.Context) (int, error) {
+	var count int
+
+	err := s.db.QueryRow(
+		ctx,
+		`SELECT COUNT(*) FROM spaces WHERE lifecycle_stage <> 'dissolved'`,
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("error counting live spaces: %w", err)
+	}
+
+	return count, nil
+}
+
+// FindEvictionCandidates finds waning spaces below an engagement score, least engaged first
+func (s *AdmissionStore) FindEvictionCandidates(ctx context.Context, maxScore float64, limit int) ([]string, error) {
+	query := `
+		SELECT s.id
+		FROM spaces s
+		LEFT JOIN LATERAL (
+			SELECT engagement_score
+			FROM space_analytics
+			WHERE space_id = s.id
+			ORDER BY timestamp DESC
+			LIMIT 1
+		) a ON TRUE
+		WHERE s.lifecycle_stage = 'waning'
+		AND NOT s.is_pinned
+		AND NOT s.expiry_override
+		AND COALESCE(a.engagement_score, 0) < $1
+		ORDER BY COALESCE(a.engagement_score, 0) ASC, s.last_active ASC
+		LIMIT $2
+	`
+
+	rows, err := s.db.Query(ctx, query, maxScore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error executing query: %w", err)
+	}
+	defer rows.Close()
+
+	var spaceIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("error scanning space ID: %w", err)
+		}
+		spaceIDs = append(spaceIDs, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating eviction candidates: %w", err)
+	}
+
+	return spaceIDs, nil
+}
+
+// SaveAdmissionDecision records an admission decision
+func (s *AdmissionStore) SaveAdmissionDecision(ctx context.Context, d space.AdmissionDecision) error {
+	query := `
+		INSERT INTO admission_decisions (
+			id, trend_id, trend_topic, trend_score, outcome, reason,
+			active_spaces, capacity, space_id, evicted_space_id, decided_at
+		) VALUES (
+			$1, NULLIF($2, ''), $3, $4, $5, $6,
+			$7, $8, NULLIF($9, ''), NULLIF($10, ''), $11
+		)
+	`
+
+	_, err := s.db.Exec(
+		ctx,
+		query,
+		d.ID,
+		d.TrendID,
+		d.TrendTopic,
+		d.TrendScore,
+		string(d.Outcome),
+		d.Reason,
+		d.ActiveSpaces,
+		d.Capacity,
+		d.SpaceID,
+		d.EvictedSpaceID,
+		d.DecidedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error executing query: %w", err)
+	}
+
+	return nil
+}
+
+// FindAdmissionDecisions finds admission decisions matching the filter, newest first
+func (s *AdmissionStore) FindAdmissionDecisions(
+	ctx context.Context,
+	filter space.AdmissionFilter,
+) ([]space.AdmissionDecision, error) {
+	// Build dynamic query
+	queryBuilder := strings.Builder{}
+	queryBuilder.WriteString(`
+		SELECT
+			id, COALESCE(trend_id, ''), COALESCE(trend_topic, ''), trend_score, outcome,
+			COALESCE(reason, ''), active_spaces, capacity,
+			COALESCE(space_id, ''), COALESCE(evicted_space_id, ''), decided_at
+		FROM admission_decisions
+		WHERE 1=1
+	`)
+
+	args := []interface{}{}
+	argIndex := 1
+
+	// Add trend filter
+	if filter.TrendID != "" {
+		queryBuilder.WriteString(fmt.Sprintf(" AND trend_id = $%d", argIndex))
+		args = append(args, filter.TrendID)
+		argIndex++
+	}
+
+	// Add outcome filter
+	if len(filter.Outcomes) > 0 {
+		queryBuilder.WriteString(" AND outcome IN (")
+
+		for i, outcome := range filter.Outcomes {
+			if i > 0 {
+				queryBuilder.WriteString(", ")
+			}
+			queryBuilder.WriteString(fmt.Sprintf("$%d", argIndex))
+			args = append(args, string(outcome))
+			argIndex++
+		}
+
+		queryBuilder.WriteString(")")
+	}
+
+	// Add time filter
+	if !filter.Since.IsZero() {
+		queryBuilder.WriteString(fmt.Sprintf(" AND decided_at >= $%d", argIndex))
+		args = append(args, filter.Since)
+		argIndex++
+	}
+
+	queryBuilder.WriteString(" ORDER BY decided_at DESC")
+
+	// Add limit
+	if filter.Limit > 0 {
+		queryBuilder.WriteString(fmt.Sprintf(" LIMIT $%d", argIndex))
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := s.db.Query(ctx, queryBuilder.String(), args...)
+	if err != nil {
+		return nil, fmt.Errorf("error executing query: %w", err)
+	}
+	defer rows.Close()
+
+	var decisions []space.AdmissionDecision
+	for rows.Next() {
+		var d space.AdmissionDecision
+		var outcome string
+
+		if err := rows.Scan(
+			&d.ID,
+			&d.TrendID,
+			&d.TrendTopic,
+			&d.TrendScore,
+			&outcome,
+			&d.Reason,
+			&d.ActiveSpaces,
+			&d.Capacity,
+			&d.SpaceID,
+			&d.EvictedSpaceID,
+			&d.DecidedAt,
+		); err != nil {
+			return nil, fmt.Errorf("error scanning admission decision: %w", err)
+		}
+
+		d.Outcome = space.AdmissionOutcome(outcome)
+		decisions = append(decisions, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating admission decisions: %w", err)
+	}
+
+	return decisions, nil
+}
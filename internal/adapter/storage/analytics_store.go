@@ -0,0 +1,185 @@
+// internal/adapter/storage/analytics_store.go
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"essg/internal/domain/space"
+)
+
+// AnalyticsStore implements storage for space engagement analytics
+type AnalyticsStore struct {
+	db *pgxpool.Pool
+}
+
+// NewAnalyticsStore creates a new analytics store
+func NewAnalyticsStore(db *pgxpool.Pool) *AnalyticsStore {
+	return &AnalyticsStore{
+		db: db,
+	}
+}
+
+// FindEngagementCurve retrieves a space's engagement between two times,
+// aggregated into time buckets
+func (s *AnalyticsStore) FindEngagementCurve(
+	ctx context.Context,
+	spaceID string,
+	from, to time.Time,
+	bucket time.Duration,
+) ([]space.EngagementPoint, error) {
+	query := `
+		SELECT
+			time_bucket($2::interval, timestamp) AS bucket,
+			AVG(engagement_score),
+			MAX(user_count),
+			MAX(active_users),
+			MAX(message_count),
+			LAST(lifecycle_stage, timestamp)::text
+		FROM space_analytics
+		WHERE space_id = $1 AND timestamp >= $3 AND timestamp < $4
+		GROUP BY bucket
+		ORDER BY bucket ASC
+	`
+
+	rows, err := s.db.Query(ctx, query, spaceID, bucket, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("error executing query: %w", err)
+	}
+	defer rows.Close()
+
+	var curve []space.EngagementPoint
+	for rows.Next() {
+		var point space.EngagementPoint
+		var stage string
+
+		if err := rows.Scan(
+			&point.Time,
+			&point.EngagementScore,
+			&point.UserCount,
+			&point.ActiveUsers,
+			&point.MessageCount,
+			&stage,
+		); err != nil {
+			return nil, fmt.Errorf("error scanning engagement point: %w", err)
+		}
+
+		point.LifecycleStage = space.LifecycleStage(stage)
+		curve = append(curve, point)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating engagement points: %w", err)
+	}
+
+	return curve, nil
+}
+
+// FindAnalyticsSamples retrieves every analytics sample recorded for a space, oldest first
+func (s *AnalyticsStore) FindAnalyticsSamples(ctx context.Context, spaceID string) ([]space.EngagementPoint, error) {
+	query := `
+		SELECT timestamp, engagement_score, user_count, active_users, message_count, lifecycle_stage::text
+		FROM space_analytics
+		WHERE space_id = $1
+		ORDER BY timestamp ASC
+	`
+
+	rows, err := s.db.Query(ctx, query, spaceID)
+	if err != nil {
+		return nil, fmt.Errorf("error executing query: %w", err)
+	}
+	defer rows.Close()
+
+	var samples []space.EngagementPoint
+	for rows.Next() {
+		var sample space.EngagementPoint
+		var stage string
+
+		if err := rows.Scan(
+			&sample.Time,
+			&sample.EngagementScore,
+			&sample.UserCount,
+			&sample.ActiveUsers,
+			&sample.MessageCount,
+			&stage,
+		); err != nil {
+			return nil, fmt.Errorf("error scanning analytics sample: %w", err)
+		}
+
+		sample.LifecycleStage = space.LifecycleStage(stage)
+		samples = append(samples, sample)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating analytics samples: %w", err)
+	}
+
+	return samples, nil
+}
+
+// FindMessageActivity retrieves each of a space's messages, oldest first
+func (s *AnalyticsStore) FindMessageActivity(ctx context.Context, spaceID string) ([]space.MessageActivity, error) {
+	query := `
+		SELECT created_at, reply_to_id IS NOT NULL, COALESCE(content, '')
+		FROM messages
+		WHERE space_id = $1
+		ORDER BY created_at ASC
+	`
+
+	rows, err := s.db.Query(ctx, query, spaceID)
+	if err != nil {
+		return nil, fmt.Errorf("error executing query: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []space.MessageActivity
+	for rows.Next() {
+		var msg space.MessageActivity
+		if err := rows.Scan(&msg.CreatedAt, &msg.IsReply, &msg.Content); err != nil {
+			return nil, fmt.Errorf("error scanning message: %w", err)
+		}
+		messages = append(messages, msg)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating messages: %w", err)
+	}
+
+	return messages, nil
+}
+
+// FindReactionActivity retrieves each reaction made in a space, oldest first
+func (s *AnalyticsStore) FindReactionActivity(ctx context.Context, spaceID string) ([]space.ReactionActivity, error) {
+	query := `
+		SELECT r.created_at, r.user_id
+		FROM reactions r
+		JOIN messages m ON m.id = r.message_id
+		WHERE m.space_id = $1
+		ORDER BY r.created_at ASC
+	`
+
+	rows, err := s.db.Query(ctx, query, spaceID)
+	if err != nil {
+		return nil, fmt.Errorf("error executing query: %w", err)
+	}
+	defer rows.Close()
+
+	var reactions []space.ReactionActivity
+	for rows.Next() {
+		var reaction space.ReactionActivity
+		if err := rows.Scan(&reaction.CreatedAt, &reaction.UserID); err != nil {
+			return nil, fmt.Errorf("error scanning reaction: %w", err)
+		}
+		reactions = append(reactions, reaction)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating reactions: %w", err)
+	}
+
+	return reactions, nil
+}
@@ -0,0 +1,333 @@
+// internal/adapter/storage/archive_store.go
+
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"essg/internal/domain/space"
+)
+
+// ArchiveStore implements storage for space archives
+type ArchiveStore struct {
+	db *pgxpool.Pool
+}
+
+// NewArchiveStore creates a new archive store
+func NewArchiveStore(db *pgxpool.Pool) *ArchiveStore {
+	return &ArchiveStore{
+		db: db,
+	}
+}
+
+// SaveArchive saves an archive to storage
+func (s *ArchiveStore) SaveArchive(ctx context.Context, archive space.Archive) error {
+	query := `
+		INSERT INTO space_archives (
+			space_id, title, trend_id, trend_topic, template_type,
+			created_at, dissolved_at, duration_seconds, peak_users, message_count,
+			top_messages, engagement_curve, archived_at, expires_at
+		) VALUES (
+			$1, $2, $3, $4, $5,
+			$6, $7, $8, $9, $10,
+			$11, $12, $13, $14
+		)
+		ON CONFLICT (space_id) DO UPDATE
+		SET
+			dissolved_at = $7,
+			duration_seconds = $8,
+			peak_users = $9,
+			message_count = $10,
+			top_messages = $11,
+			engagement_curve = $12,
+			archived_at = $13,
+			expires_at = $14
+	`
+
+	// Convert JSON fields
+	topMessagesJSON, err := json.Marshal(archive.TopMessages)
+	if err != nil {
+		return fmt.Errorf("error marshaling top messages: %w", err)
+	}
+
+	curveJSON, err := json.Marshal(archive.EngagementCurve)
+	if err != nil {
+		return fmt.Errorf("error marshaling engagement curve: %w", err)
+	}
+
+	// Execute query
+	_, err = s.db.Exec(
+		ctx,
+		query,
+		archive.SpaceID,
+		archive.Title,
+		archive.TrendID,
+		archive.TrendTopic,
+		string(archive.TemplateType),
+		archive.CreatedAt,
+		archive.DissolvedAt,
+		archive.DurationSeconds,
+		archive.PeakUsers,
+		archive.MessageCount,
+		topMessagesJSON,
+		curveJSON,
+		archive.ArchivedAt,
+		archive.ExpiresAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("error executing query: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpiredArchives removes archives past their retention period
+func (s *ArchiveStore) DeleteExpiredArchives(ctx context.Context) error {
+	if _, err := s.db.Exec(ctx, `SELECT cleanup_expired_archives()`); err != nil {
+		return fmt.Errorf("error cleaning up expired archives: %w", err)
+	}
+	return nil
+}
+
+// GetArchive retrieves an unexpired archive by space ID
+func (s *ArchiveStore) GetArchive(ctx context.Context, spaceID string) (*space.Archive, error) {
+	query := `
+		SELECT
+			space_id, title, trend_id, trend_topic, template_type,
+			created_at, dissolved_at, duration_seconds, peak_users, message_count,
+			top_messages, engagement_curve, archived_at, expires_at
+		FROM space_archives
+		WHERE space_id = $1
+		AND expires_at > NOW()
+	`
+
+	var archive space.Archive
+	var templateType string
+	var trendID, trendTopic *string
+	var topMessagesJSON, curveJSON []byte
+
+	err := s.db.QueryRow(ctx, query, spaceID).Scan(
+		&archive.SpaceID,
+		&archive.Title,
+		&trendID,
+		&trendTopic,
+		&templateType,
+		&archive.CreatedAt,
+		&archive.DissolvedAt,
+		&archive.DurationSeconds,
+		&archive.PeakUsers,
+		&archive.MessageCount,
+		&topMessagesJSON,
+		&curveJSON,
+		&archive.ArchivedAt,
+		&archive.ExpiresAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, space.ErrArchiveNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error querying archive: %w", err)
+	}
+
+	if trendID != nil {
+		archive.TrendID = *trendID
+	}
+	if trendTopic != nil {
+		archive.TrendTopic = *trendTopic
+	}
+
+	// Parse JSON fields
+	if err := json.Unmarshal(topMessagesJSON, &archive.TopMessages); err != nil {
+		return nil, fmt.Errorf("error unmarshaling top messages: %w", err)
+	}
+
+	if err := json.Unmarshal(curveJSON, &archive.EngagementCurve); err != nil {
+		return nil, fmt.Errorf("error unmarshaling engagement curve: %w", err)
+	}
+
+	archive.TemplateType = space.TemplateType(templateType)
+
+	return &archive, nil
+}
+
+// GetTrendTopic retrieves the topic of a trend
+func (s *ArchiveStore) GetTrendTopic(ctx context.Context, trendID string) (string, error) {
+	var topic string
+
+	err := s.db.QueryRow(ctx, `SELECT topic FROM trends WHERE id = $1`, trendID).Scan(&topic)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error querying trend topic: %w", err)
+	}
+
+	return topic, nil
+}
+
+// GetPeakUsers retrieves the highest user count recorded for a space
+func (s *ArchiveStore) GetPeakUsers(ctx context.Context, spaceID string) (int, error) {
+	var peak int
+
+	err := s.db.QueryRow(
+		ctx,
+		`SELECT COALESCE(MAX(user_count), 0) FROM space_analytics WHERE space_id = $1`,
+		spaceID,
+	).Scan(&peak)
+	if err != nil {
+		return 0, fmt.Errorf("error querying peak users: %w", err)
+	}
+
+	return peak, nil
+}
+
+// GetTopReactedMessages retrieves the most reacted-to messages of a space without author details
+func (s *ArchiveStore) GetTopReactedMessages(ctx context.Context, spaceID string, limit int) ([]space.ArchivedMessage, error) {
+	query := `
+		WITH top AS (
+			SELECT m.id, m.content, m.created_at, COUNT(r.id) AS total
+			FROM messages m
+			JOIN reactions r ON r.message_id = m.id
+			WHERE m.space_id = $1
+			AND m.status <> 'removed'
+			GROUP BY m.id, m.content, m.created_at
+			ORDER BY total DESC, m.created_at ASC
+			LIMIT $2
+		)
+		SELECT top.id, top.content, top.created_at, top.total, r.reaction, COUNT(*)
+		FROM top
+		JOIN reactions r ON r.message_id = top.id
+		GROUP BY top.id, top.content, top.created_at, top.total, r.reaction
+		ORDER BY top.total DESC, top.created_at ASC
+	`
+
+	rows, err := s.db.Query(ctx, query, spaceID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error executing query: %w", err)
+	}
+	defer rows.Close()
+
+	// Fold per-reaction rows into one entry per message
+	var messages []space.ArchivedMessage
+	index := make(map[string]int)
+	for rows.Next() {
+		var id, reaction string
+		var content *string
+		var postedAt time.Time
+		var total, count int
+
+		if err := rows.Scan(&id, &content, &postedAt, &total, &reaction, &count); err != nil {
+			return nil, fmt.Errorf("error scanning message: %w", err)
+		}
+
+		i, ok := index[id]
+		if !ok {
+			msg := space.ArchivedMessage{
+				Reactions:      make(map[string]int),
+				TotalReactions: total,
+				PostedAt:       postedAt,
+			}
+			if content != nil {
+				msg.Content = *content
+			}
+
+			i = len(messages)
+			index[id] = i
+			messages = append(messages, msg)
+		}
+
+		messages[i].Reactions[reaction] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating messages: %w", err)
+	}
+
+	return messages, nil
+}
+
+// GetNicknames retrieves the ephemeral nicknames used in a space
+func (s *ArchiveStore) GetNicknames(ctx context.Context, spaceID string) ([]string, error) {
+	rows, err := s.db.Query(ctx, `SELECT nickname FROM ephemeral_identities WHERE space_id = $1`, spaceID)
+	if err != nil {
+		return nil, fmt.Errorf("error executing query: %w", err)
+	}
+	defer rows.Close()
+
+	var nicknames []string
+	for rows.Next() {
+		var nickname string
+		if err := rows.Scan(&nickname); err != nil {
+			return nil, fmt.Errorf("error scanning nickname: %w", err)
+		}
+		nicknames = append(nicknames, nickname)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating nicknames: %w", err)
+	}
+
+	return nicknames, nil
+}
+
+// GetEngagementCurve retrieves a space's engagement aggregated into time buckets
+func (s *ArchiveStore) GetEngagementCurve(
+	ctx context.Context,
+	spaceID string,
+	bucket time.Duration,
+) ([]space.EngagementPoint, error) {
+	query := `
+		SELECT
+			time_bucket($2::interval, timestamp) AS bucket,
+			AVG(engagement_score),
+			MAX(user_count),
+			MAX(active_users),
+			MAX(message_count),
+			LAST(lifecycle_stage, timestamp)::text
+		FROM space_analytics
+		WHERE space_id = $1
+		GROUP BY bucket
+		ORDER BY bucket ASC
+	`
+
+	rows, err := s.db.Query(ctx, query, spaceID, bucket)
+	if err != nil {
+		return nil, fmt.Errorf("error executing query: %w", err)
+	}
+	defer rows.Close()
+
+	var curve []space.EngagementPoint
+	for rows.Next() {
+		var point space.EngagementPoint
+		var stage string
+
+		if err := rows.Scan(
+			&point.Time,
+			&point.EngagementScore,
+			&point.UserCount,
+			&point.ActiveUsers,
+			&point.MessageCount,
+			&stage,
+		); err != nil {
+			return nil, fmt.Errorf("error scanning engagement point: %w", err)
+		}
+
+		point.LifecycleStage = space.LifecycleStage(stage)
+		curve = append(curve, point)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating engagement curve: %w", err)
+	}
+
+	return curve, nil
+}
@@ -0,0 +1,102 @@
+// internal/adapter/storage/event_store.go
+
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"essg/internal/domain/space"
+)
+
+// EventStore implements storage for recent space events
+type EventStore struct {
+	db *pgxpool.Pool
+}
+
+// NewEventStore creates a new event store
+func NewEventStore(db *pgxpool.Pool) *EventStore {
+	return &EventStore{
+		db: db,
+	}
+}
+
+// SaveEvent records an event published to a space, returning its position in
+// the space's log. The position is taken under a lock on the space's counter
+// that is held until the event commits, so concurrent writers commit positions
+// in order and a stream resuming after one never skips an earlier one.
+func (s *EventStore) SaveEvent(ctx context.Context, event space.SpaceEvent) (int64, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var seq int64
+	err = tx.QueryRow(
+		ctx,
+		`INSERT INTO space_event_counters (space_id, last_seq) VALUES ($1, 1)
+		ON CONFLICT (space_id) DO UPDATE SET last_seq = space_event_counters.last_seq + 1
+		RETURNING last_seq`,
+		event.SpaceID,
+	).Scan(&seq)
+	if err != nil {
+		return 0, fmt.Errorf("error allocating event position: %w", err)
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		`INSERT INTO space_events (seq, space_id, topic, data, event_time) VALUES ($1, $2, $3, $4, $5)`,
+		seq, event.SpaceID, event.Topic, string(event.Data), event.Time,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("error saving event: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return seq, nil
+}
+
+// FindEventsSince returns a space's events after a position in the log, oldest first
+func (s *EventStore) FindEventsSince(
+	ctx context.Context,
+	spaceID string,
+	afterSeq int64,
+	limit int,
+) ([]space.SpaceEvent, error) {
+	query := `
+		SELECT seq, space_id, topic, data::text, event_time
+		FROM space_events
+		WHERE space_id = $1 AND seq > $2
+		ORDER BY seq
+		LIMIT $3
+	`
+
+	rows, err := s.db.Query(ctx, query, spaceID, afterSeq, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error querying events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []space.SpaceEvent
+	for rows.Next() {
+		var event space.SpaceEvent
+		var data string
+		if err := rows.Scan(&event.Seq, &event.SpaceID, &event.Topic, &data, &event.Time); err != nil {
+			return nil, fmt.Errorf("error scanning event: %w", err)
+		}
+		event.Data = []byte(data)
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating events: %w", err)
+	}
+
+	return events, nil
+}
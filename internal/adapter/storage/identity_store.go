@@ -0,0 +1,672 @@
+// internal/adapter/storage/identity_store.go
+
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"essg/internal/domain/identity"
+	"essg/internal/domain/trend"
+)
+
+// IdentityStore implements storage for users and their ephemeral identities
+type IdentityStore struct {
+	db *pgxpool.Pool
+}
+
+// NewIdentityStore creates a new identity store
+func NewIdentityStore(db *pgxpool.Pool) *IdentityStore {
+	return &IdentityStore{
+		db: db,
+	}
+}
+
+// userColumns are the columns scanned by scanUser
+const userColumns = `
+	id, external_ids, created_at, last_seen,
+	ST_X(location::geometry), ST_Y(location::geometry),
+	location_sharing_preference::text, default_anonymity, notification_preferences, is_guest
+`
+
+// identityColumns are the columns scanned by scanEphemeralIdentity
+const identityColumns = `
+	id, user_id, space_id, nickname, COALESCE(avatar, ''), is_anonymous,
+	ST_X(location::geometry), ST_Y(location::geometry),
+	location_share_level::text, created_at, last_active, reputation
+`
+
+// GetOrCreateUser returns the user linked to a platform account, creating it
+// from the given defaults if there is none
+func (s *IdentityStore) GetOrCreateUser(
+	ctx context.Context,
+	platform, platformID string,
+	defaults identity.User,
+) (*identity.User, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	externalID, err := lockAccount(ctx, tx, platform, platformID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := touchAccountUser(ctx, tx, externalID)
+	if errors.Is(err, identity.ErrUserNotFound) {
+		defaults.ExternalIDs = map[string]string{platform: platformID}
+		user, err = insertUser(ctx, tx, defaults)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return user, nil
+}
+
+// CreateGuest creates a guest user with no linked accounts
+func (s *IdentityStore) CreateGuest(ctx context.Context, guest identity.User) (*identity.User, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	guest.ExternalIDs = nil
+	guest.IsGuest = true
+
+	user, err := insertUser(ctx, tx, guest)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return user, nil
+}
+
+// RecordGuestIssued counts a guest session issued to a client in a window,
+// returning the window's count including it
+func (s *IdentityStore) RecordGuestIssued(ctx context.Context, clientKey string, windowStart time.Time) (int, error) {
+	var count int
+
+	err := s.db.QueryRow(
+		ctx,
+		`INSERT INTO guest_issuance (client_key, window_start, count)
+		VALUES ($1, $2, 1)
+		ON CONFLICT (client_key, window_start)
+		DO UPDATE SET count = guest_issuance.count + 1
+		RETURNING count`,
+		clientKey, windowStart,
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("error recording guest issuance: %w", err)
+	}
+
+	return count, nil
+}
+
+// DeleteExpiredGuests removes guests created before a time that were never
+// upgraded, hold no live token and have no identity, message or reaction, along
+// with their expired tokens and rate limit windows
+func (s *IdentityStore) DeleteExpiredGuests(ctx context.Context, before time.Time) (int64, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the guests, skipping any being upgraded
+	rows, err := tx.Query(
+		ctx,
+		`SELECT u.id FROM users u
+		WHERE u.is_guest
+		AND u.created_at < $1
+		AND NOT EXISTS (SELECT 1 FROM tokens t WHERE t.user_id = u.id AND t.expires_at > NOW())
+		AND NOT EXISTS (SELECT 1 FROM ephemeral_identities ei WHERE ei.user_id = u.id)
+		AND NOT EXISTS (SELECT 1 FROM messages m WHERE m.user_id = u.id)
+		AND NOT EXISTS (SELECT 1 FROM reactions r WHERE r.user_id = u.id)
+		FOR UPDATE SKIP LOCKED`,
+		before,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("error querying expired guests: %w", err)
+	}
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("error scanning expired guest: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating expired guests: %w", err)
+	}
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM tokens WHERE user_id = ANY($1)`, ids); err != nil {
+		return 0, fmt.Errorf("error deleting guest tokens: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM rate_limits WHERE user_id = ANY($1)`, ids); err != nil {
+		return 0, fmt.Errorf("error deleting guest rate limits: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx, `DELETE FROM users WHERE id = ANY($1)`, ids)
+	if err != nil {
+		return 0, fmt.Errorf("error deleting guests: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// LinkGuest links a platform account to a guest. A new account makes the guest
+// a full user in place. An account that already has a user absorbs the guest's
+// activity in spaces that have not dissolved, as in absorbGuest.
+func (s *IdentityStore) LinkGuest(ctx context.Context, guestID, platform, platformID string) (*identity.User, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	externalID, err := lockAccount(ctx, tx, platform, platformID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Lock the guest
+	var isGuest bool
+	err = tx.QueryRow(ctx, `SELECT is_guest FROM users WHERE id = $1 FOR UPDATE`, guestID).Scan(&isGuest)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, identity.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error locking guest: %w", err)
+	}
+	if !isGuest {
+		return nil, identity.ErrNotGuest
+	}
+
+	user, err := touchAccountUser(ctx, tx, externalID)
+	switch {
+	case errors.Is(err, identity.ErrUserNotFound):
+		// First time this account is seen, so the guest becomes its user
+		user, err = scanUser(tx.QueryRow(
+			ctx,
+			`UPDATE users
+			SET external_ids = $2, is_guest = FALSE, last_seen = NOW()
+			WHERE id = $1
+			RETURNING `+userColumns,
+			guestID, externalID,
+		))
+		if err != nil {
+			return nil, err
+		}
+
+	case err != nil:
+		return nil, err
+
+	default:
+		if err := absorbGuest(ctx, tx, guestID, user.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return user, nil
+}
+
+// absorbGuest hands a guest's activity in spaces that have not dissolved over to
+// another user. Identities in spaces the user has not joined move across whole.
+// Where the user already has an identity, the guest's is retired and its
+// messages are reattributed, so the space counts the person once. Reactions
+// move too, dropping any the user had already made.
+func absorbGuest(ctx context.Context, tx pgx.Tx, guestID, userID string) error {
+	// Find the spaces where both have an identity
+	rows, err := tx.Query(
+		ctx,
+		`SELECT guest.space_id, guest.id, own.id
+		FROM ephemeral_identities guest
+		JOIN ephemeral_identities own ON own.space_id = guest.space_id AND own.user_id = $2
+		JOIN spaces s ON s.id = guest.space_id
+		WHERE guest.user_id = $1 AND s.lifecycle_stage <> 'dissolved'
+		ORDER BY guest.space_id`,
+		guestID, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("error finding shared spaces: %w", err)
+	}
+
+	var spaceIDs, guestIdentityIDs, ownIdentityIDs []string
+	for rows.Next() {
+		var spaceID, guestIdentityID, ownIdentityID string
+		if err := rows.Scan(&spaceID, &guestIdentityID, &ownIdentityID); err != nil {
+			rows.Close()
+			return fmt.Errorf("error scanning shared space: %w", err)
+		}
+		spaceIDs = append(spaceIDs, spaceID)
+		guestIdentityIDs = append(guestIdentityIDs, guestIdentityID)
+		ownIdentityIDs = append(ownIdentityIDs, ownIdentityID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating shared spaces: %w", err)
+	}
+
+	// Lock the shared spaces, in order, before their members change
+	if len(spaceIDs) > 0 {
+		if _, err := tx.Exec(ctx, `SELECT 1 FROM spaces WHERE id = ANY($1) ORDER BY id FOR UPDATE`, spaceIDs); err != nil {
+			return fmt.Errorf("error locking spaces: %w", err)
+		}
+	}
+
+	// Hand the guest's other live identities over to the user
+	_, err = tx.Exec(
+		ctx,
+		`WITH moved AS (
+			UPDATE ephemeral_identities ei
+			SET user_id = $2
+			WHERE ei.user_id = $1
+			AND ei.space_id IN (SELECT id FROM spaces WHERE lifecycle_stage <> 'dissolved')
+			AND NOT (ei.space_id = ANY($3))
+			RETURNING ei.id
+		)
+		UPDATE messages SET user_id = $2
+		WHERE ephemeral_identity_id IN (SELECT id FROM moved)`,
+		guestID, userID, spaceIDs,
+	)
+	if err != nil {
+		return fmt.Errorf("error moving guest identities: %w", err)
+	}
+
+	for i, spaceID := range spaceIDs {
+		guestIdentityID, ownIdentityID := guestIdentityIDs[i], ownIdentityIDs[i]
+
+		// Keep the user in the space if the guest was still there
+		_, err := tx.Exec(
+			ctx,
+			`UPDATE ephemeral_identities own
+			SET left_at = NULL, last_active = GREATEST(own.last_active, guest.last_active)
+			FROM ephemeral_identities guest
+			WHERE own.id = $2 AND guest.id = $1 AND guest.left_at IS NULL`,
+			guestIdentityID, ownIdentityID,
+		)
+		if err != nil {
+			return fmt.Errorf("error keeping membership: %w", err)
+		}
+
+		// Retire the guest's identity; it stays for the polls and credits that reference it
+		if _, err := tx.Exec(ctx, `UPDATE ephemeral_identities SET left_at = COALESCE(left_at, NOW()) WHERE id = $1`, guestIdentityID); err != nil {
+			return fmt.Errorf("error retiring guest identity: %w", err)
+		}
+
+		_, err = tx.Exec(
+			ctx,
+			`UPDATE messages SET user_id = $3, ephemeral_identity_id = $2
+			WHERE ephemeral_identity_id = $1 AND space_id = $4`,
+			guestIdentityID, ownIdentityID, userID, spaceID,
+		)
+		if err != nil {
+			return fmt.Errorf("error moving guest messages: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx, `UPDATE message_authors SET identity_id = $2 WHERE identity_id = $1`, guestIdentityID, ownIdentityID); err != nil {
+			return fmt.Errorf("error moving guest message authors: %w", err)
+		}
+
+		if _, err := recountMembers(ctx, tx, spaceID); err != nil {
+			return err
+		}
+	}
+
+	// Move the guest's reactions in live spaces, dropping any the user repeated
+	_, err = tx.Exec(
+		ctx,
+		`WITH live_messages AS (
+			SELECT m.id FROM messages m
+			JOIN spaces s ON s.id = m.space_id
+			WHERE m.id IN (SELECT message_id FROM reactions WHERE user_id = $1)
+			AND s.lifecycle_stage <> 'dissolved'
+		),
+		dropped AS (
+			DELETE FROM reactions r
+			WHERE r.user_id = $1 AND r.message_id IN (SELECT id FROM live_messages)
+			AND EXISTS (
+				SELECT 1 FROM reactions own
+				WHERE own.user_id = $2 AND own.message_id = r.message_id AND own.reaction = r.reaction
+			)
+			RETURNING r.id
+		)
+		UPDATE reactions SET user_id = $2
+		WHERE user_id = $1 AND message_id IN (SELECT id FROM live_messages)
+		AND id NOT IN (SELECT id FROM dropped)`,
+		guestID, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("error moving guest reactions: %w", err)
+	}
+
+	return nil
+}
+
+// GetUser retrieves a user by ID
+func (s *IdentityStore) GetUser(ctx context.Context, id string) (*identity.User, error) {
+	return scanUser(s.db.QueryRow(ctx, `SELECT `+userColumns+` FROM users WHERE id = $1`, id))
+}
+
+// UpdateUserLocation updates a user's last known location
+func (s *IdentityStore) UpdateUserLocation(ctx context.Context, userID string, location trend.Location) error {
+	tag, err := s.db.Exec(
+		ctx,
+		`UPDATE users
+		SET location = ST_MakePoint($2, $3)::geography, last_seen = NOW()
+		WHERE id = $1`,
+		userID, location.Longitude, location.Latitude,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating user location: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return identity.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// UpdateLocationSharing updates a user's location sharing preference
+func (s *IdentityStore) UpdateLocationSharing(
+	ctx context.Context,
+	userID string,
+	level identity.LocationSharingLevel,
+) error {
+	tag, err := s.db.Exec(
+		ctx,
+		`UPDATE users SET location_sharing_preference = $2::location_sharing_level WHERE id = $1`,
+		userID, string(level),
+	)
+	if err != nil {
+		return fmt.Errorf("error updating location sharing: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return identity.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// GetEphemeralIdentity retrieves the identity a user has in a space, including
+// one kept after they left
+func (s *IdentityStore) GetEphemeralIdentity(
+	ctx context.Context,
+	userID, spaceID string,
+) (*identity.EphemeralIdentity, error) {
+	return scanEphemeralIdentity(s.db.QueryRow(
+		ctx,
+		`SELECT `+identityColumns+` FROM ephemeral_identities WHERE user_id = $1 AND space_id = $2`,
+		userID, spaceID,
+	))
+}
+
+// UpdateEphemeralIdentity updates an identity's settings and activity. Its
+// generated nickname and avatar are never changed.
+func (s *IdentityStore) UpdateEphemeralIdentity(ctx context.Context, ident identity.EphemeralIdentity) error {
+	reputationJSON, err := json.Marshal(ident.Reputation)
+	if err != nil {
+		return fmt.Errorf("error marshaling reputation: %w", err)
+	}
+
+	var lng, lat *float64
+	if ident.Location != nil {
+		lng = &ident.Location.Longitude
+		lat = &ident.Location.Latitude
+	}
+
+	query := `
+		UPDATE ephemeral_identities
+		SET
+			is_anonymous = $2,
+			location_share_level = $3::location_sharing_level,
+			location = CASE WHEN $4::float8 IS NOT NULL AND $5::float8 IS NOT NULL
+				THEN ST_MakePoint($4, $5)::geography ELSE NULL END,
+			last_active = GREATEST(last_active, $6),
+			reputation = $7
+		WHERE id = $1
+	`
+
+	tag, err := s.db.Exec(
+		ctx,
+		query,
+		ident.ID,
+		ident.IsAnonymous,
+		string(ident.LocationShareLevel),
+		lng,
+		lat,
+		ident.LastActive,
+		reputationJSON,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating ephemeral identity: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return identity.ErrIdentityNotFound
+	}
+
+	return nil
+}
+
+// FindSpaceIdentities returns the identities of a space's current members
+func (s *IdentityStore) FindSpaceIdentities(ctx context.Context, spaceID string) ([]identity.EphemeralIdentity, error) {
+	rows, err := s.db.Query(
+		ctx,
+		`SELECT `+identityColumns+` FROM ephemeral_identities
+		WHERE space_id = $1 AND left_at IS NULL
+		ORDER BY created_at`,
+		spaceID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error executing query: %w", err)
+	}
+	defer rows.Close()
+
+	var identities []identity.EphemeralIdentity
+	for rows.Next() {
+		ident, err := scanEphemeralIdentity(rows)
+		if err != nil {
+			return nil, err
+		}
+		identities = append(identities, *ident)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating identities: %w", err)
+	}
+
+	return identities, nil
+}
+
+// lockAccount serializes work on a platform account so its user is only
+// created once, returning the account's external ID document
+func lockAccount(ctx context.Context, tx pgx.Tx, platform, platformID string) ([]byte, error) {
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1 || ':' || $2))`, platform, platformID); err != nil {
+		return nil, fmt.Errorf("error locking account: %w", err)
+	}
+
+	externalID, err := json.Marshal(map[string]string{platform: platformID})
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling external ID: %w", err)
+	}
+
+	return externalID, nil
+}
+
+// touchAccountUser returns the user linked to an external ID, marking them as seen
+func touchAccountUser(ctx context.Context, tx pgx.Tx, externalID []byte) (*identity.User, error) {
+	return scanUser(tx.QueryRow(
+		ctx,
+		`UPDATE users SET last_seen = NOW()
+		WHERE id = (SELECT id FROM users WHERE external_ids @> $1 ORDER BY created_at LIMIT 1)
+		RETURNING `+userColumns,
+		externalID,
+	))
+}
+
+// insertUser creates a user
+func insertUser(ctx context.Context, tx pgx.Tx, user identity.User) (*identity.User, error) {
+	var externalJSON []byte
+	if len(user.ExternalIDs) > 0 {
+		data, err := json.Marshal(user.ExternalIDs)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling external IDs: %w", err)
+		}
+		externalJSON = data
+	}
+
+	prefsJSON, err := json.Marshal(user.NotificationPreferences)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling notification preferences: %w", err)
+	}
+
+	return scanUser(tx.QueryRow(
+		ctx,
+		`INSERT INTO users (
+			id, external_ids, created_at, last_seen,
+			location_sharing_preference, default_anonymity, notification_preferences, is_guest
+		) VALUES (
+			$1, $2, $3, $3, $4::location_sharing_level, $5, $6, $7
+		)
+		RETURNING `+userColumns,
+		user.ID,
+		externalJSON,
+		user.CreatedAt,
+		string(user.LocationSharingPreference),
+		user.DefaultAnonymity,
+		prefsJSON,
+		user.IsGuest,
+	))
+}
+
+// scanUser scans a row of userColumns into a user
+func scanUser(row pgx.Row) (*identity.User, error) {
+	var user identity.User
+	var lng, lat *float64
+	var sharing string
+	var externalJSON, prefsJSON []byte
+
+	err := row.Scan(
+		&user.ID,
+		&externalJSON,
+		&user.CreatedAt,
+		&user.LastSeen,
+		&lng,
+		&lat,
+		&sharing,
+		&user.DefaultAnonymity,
+		&prefsJSON,
+		&user.IsGuest,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, identity.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error scanning user: %w", err)
+	}
+
+	user.LocationSharingPreference = identity.LocationSharingLevel(sharing)
+	user.Location = scanLocation(lng, lat)
+
+	if len(externalJSON) > 0 {
+		if err := json.Unmarshal(externalJSON, &user.ExternalIDs); err != nil {
+			return nil, fmt.Errorf("error unmarshaling external IDs: %w", err)
+		}
+	}
+	if len(prefsJSON) > 0 {
+		if err := json.Unmarshal(prefsJSON, &user.NotificationPreferences); err != nil {
+			return nil, fmt.Errorf("error unmarshaling notification preferences: %w", err)
+		}
+	}
+
+	return &user, nil
+}
+
+// scanEphemeralIdentity scans a row of identityColumns into an ephemeral identity
+func scanEphemeralIdentity(row pgx.Row) (*identity.EphemeralIdentity, error) {
+	var ident identity.EphemeralIdentity
+	var lng, lat *float64
+	var shareLevel string
+	var reputationJSON []byte
+
+	err := row.Scan(
+		&ident.ID,
+		&ident.UserID,
+		&ident.SpaceID,
+		&ident.Nickname,
+		&ident.Avatar,
+		&ident.IsAnonymous,
+		&lng,
+		&lat,
+		&shareLevel,
+		&ident.CreatedAt,
+		&ident.LastActive,
+		&reputationJSON,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, identity.ErrIdentityNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error scanning ephemeral identity: %w", err)
+	}
+
+	ident.LocationShareLevel = identity.LocationSharingLevel(shareLevel)
+	ident.Location = scanLocation(lng, lat)
+
+	if len(reputationJSON) > 0 {
+		if err := json.Unmarshal(reputationJSON, &ident.Reputation); err != nil {
+			return nil, fmt.Errorf("error unmarshaling reputation: %w", err)
+		}
+	}
+
+	return &ident, nil
+}
+
+// scanLocation builds a location from nullable scanned coordinates
+func scanLocation(lng, lat *float64) *trend.Location {
+	if lng == nil || lat == nil {
+		return nil
+	}
+
+	return &trend.Location{
+		Latitude:  *lat,
+		Longitude: *lng,
+	}
+}
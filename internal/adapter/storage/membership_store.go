@@ -0,0 +1,425 @@
+// internal/adapter/storage/membership_store.go
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"essg/internal/domain/identity"
+	"essg/internal/domain/space"
+)
+
+// MembershipStore implements storage for space membership
+type MembershipStore struct {
+	db *pgxpool.Pool
+}
+
+// NewMembershipStore creates a new membership store
+func NewMembershipStore(db *pgxpool.Pool) *MembershipStore {
+	return &MembershipStore{
+		db: db,
+	}
+}
+
+// JoinSpace records a user as a member of a space, creating their ephemeral
+// identity on first join. It reports whether the user was newly joined and the
+// space's resulting user count. A nickname already used by another member of
+// the space is reported as identity.ErrNicknameTaken. Users who are already
+// members, such as those reconnecting, are only marked active, without
+// locking the space.
+func (s *MembershipStore) JoinSpace(
+	ctx context.Context,
+	ident identity.EphemeralIdentity,
+) (*identity.EphemeralIdentity, bool, int, error) {
+	member, userCount, err := s.touchLiveMember(ctx, ident.SpaceID, ident.UserID, ident.LastActive)
+	if err == nil {
+		return member, false, userCount, nil
+	}
+	if !errors.Is(err, space.ErrNotMember) {
+		return nil, false, 0, err
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, false, 0, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the space so concurrent joins and leaves serialize on its count
+	if err := lockOpenSpace(ctx, tx, ident.SpaceID); err != nil {
+		return nil, false, 0, err
+	}
+
+	// Make sure the user exists, letting their own preferences override the defaults
+	anonymous, shareLevel, err := ensureUser(ctx, tx, ident.UserID, ident.IsAnonymous, ident.LocationShareLevel)
+	if err != nil {
+		return nil, false, 0, err
+	}
+	ident.IsAnonymous = anonymous
+	ident.LocationShareLevel = shareLevel
+
+	// Check for an existing membership
+	var wasMember bool
+	err = tx.QueryRow(
+		ctx,
+		`SELECT left_at IS NULL FROM ephemeral_identities WHERE user_id = $1 AND space_id = $2`,
+		ident.UserID, ident.SpaceID,
+	).Scan(&wasMember)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, 0, fmt.Errorf("error checking membership: %w", err)
+	}
+
+	// Create the identity, or bring back the one used before leaving
+	query := `
+		INSERT INTO ephemeral_identities (
+			id, user_id, space_id, nickname, avatar, is_anonymous,
+			location_share_level, created_at, last_active
+		) VALUES (
+			$1, $2, $3, $4, $5, $6,
+			$7::location_sharing_level, $8, $8
+		)
+		ON CONFLICT (user_id, space_id) DO UPDATE
+		SET
+			left_at = NULL,
+			last_active = $8
+		RETURNING id, nickname, COALESCE(avatar, ''), is_anonymous,
+			location_share_level::text, created_at, last_active
+	`
+
+	var joined identity.EphemeralIdentity
+	var joinedShareLevel string
+
+	err = tx.QueryRow(
+		ctx,
+		query,
+		ident.ID,
+		ident.UserID,
+		ident.SpaceID,
+		ident.Nickname,
+		ident.Avatar,
+		ident.IsAnonymous,
+		string(ident.LocationShareLevel),
+		ident.LastActive,
+	).Scan(
+		&joined.ID,
+		&joined.Nickname,
+		&joined.Avatar,
+		&joined.IsAnonymous,
+		&joinedShareLevel,
+		&joined.CreatedAt,
+		&joined.LastActive,
+	)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "ephemeral_identities_nickname_key" {
+		return nil, false, 0, identity.ErrNicknameTaken
+	}
+	if err != nil {
+		return nil, false, 0, fmt.Errorf("error saving ephemeral identity: %w", err)
+	}
+
+	joined.UserID = ident.UserID
+	joined.SpaceID = ident.SpaceID
+	joined.LocationShareLevel = identity.LocationSharingLevel(joinedShareLevel)
+
+	// Only a new member changes the count; another join may have won the race
+	delta := 0
+	if !wasMember {
+		delta = 1
+	}
+	userCount, err = adjustMemberCount(ctx, tx, ident.SpaceID, delta)
+	if err != nil {
+		return nil, false, 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, 0, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return &joined, !wasMember, userCount, nil
+}
+
+// LeaveSpace marks a user as having left a space, returning the identity they
+// used and the space's resulting user count
+func (s *MembershipStore) LeaveSpace(
+	ctx context.Context,
+	spaceID, userID string,
+) (*identity.EphemeralIdentity, int, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the space so concurrent joins and leaves serialize on its count
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM spaces WHERE id = $1 FOR UPDATE`, spaceID); err != nil {
+		return nil, 0, fmt.Errorf("error locking space: %w", err)
+	}
+
+	left := identity.EphemeralIdentity{
+		UserID:  userID,
+		SpaceID: spaceID,
+	}
+
+	err = tx.QueryRow(
+		ctx,
+		`UPDATE ephemeral_identities
+		SET left_at = NOW()
+		WHERE user_id = $1 AND space_id = $2 AND left_at IS NULL
+		RETURNING id, nickname`,
+		userID, spaceID,
+	).Scan(&left.ID, &left.Nickname)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, 0, space.ErrNotMember
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("error leaving space: %w", err)
+	}
+
+	userCount, err := adjustMemberCount(ctx, tx, spaceID, -1)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, 0, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return &left, userCount, nil
+}
+
+// GetMember returns the identity a current member uses in a space
+func (s *MembershipStore) GetMember(ctx context.Context, spaceID, userID string) (*identity.EphemeralIdentity, error) {
+	member := identity.EphemeralIdentity{
+		UserID:  userID,
+		SpaceID: spaceID,
+	}
+	var shareLevel string
+
+	err := s.db.QueryRow(
+		ctx,
+		`SELECT id, nickname, COALESCE(avatar, ''), is_anonymous,
+			location_share_level::text, created_at, last_active
+		FROM ephemeral_identities
+		WHERE space_id = $1 AND user_id = $2 AND left_at IS NULL`,
+		spaceID, userID,
+	).Scan(
+		&member.ID,
+		&member.Nickname,
+		&member.Avatar,
+		&member.IsAnonymous,
+		&shareLevel,
+		&member.CreatedAt,
+		&member.LastActive,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, space.ErrNotMember
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error querying member: %w", err)
+	}
+
+	member.LocationShareLevel = identity.LocationSharingLevel(shareLevel)
+
+	return &member, nil
+}
+
+// touchLiveMember marks a current member of an open space active, returning
+// their identity and the space's user count. Only the member's own row is
+// locked.
+func (s *MembershipStore) touchLiveMember(
+	ctx context.Context,
+	spaceID, userID string,
+	at time.Time,
+) (*identity.EphemeralIdentity, int, error) {
+	member := identity.EphemeralIdentity{
+		UserID:  userID,
+		SpaceID: spaceID,
+	}
+	var shareLevel string
+	var userCount int
+
+	err := s.db.QueryRow(
+		ctx,
+		`UPDATE ephemeral_identities ei
+		SET last_active = $3
+		FROM spaces s
+		WHERE ei.user_id = $1 AND ei.space_id = $2 AND ei.left_at IS NULL
+		AND s.id = ei.space_id AND s.lifecycle_stage <> 'dissolved'
+		RETURNING ei.id, ei.nickname, COALESCE(ei.avatar, ''), ei.is_anonymous,
+			ei.location_share_level::text, ei.created_at, ei.last_active, s.user_count`,
+		userID, spaceID, at,
+	).Scan(
+		&member.ID,
+		&member.Nickname,
+		&member.Avatar,
+		&member.IsAnonymous,
+		&shareLevel,
+		&member.CreatedAt,
+		&member.LastActive,
+		&userCount,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, 0, space.ErrNotMember
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("error touching member: %w", err)
+	}
+
+	member.LocationShareLevel = identity.LocationSharingLevel(shareLevel)
+
+	return &member, userCount, nil
+}
+
+// TouchMember records a member as active now
+func (s *MembershipStore) TouchMember(ctx context.Context, spaceID, userID string) error {
+	tag, err := s.db.Exec(
+		ctx,
+		`UPDATE ephemeral_identities
+		SET last_active = NOW()
+		WHERE user_id = $1 AND space_id = $2 AND left_at IS NULL`,
+		userID, spaceID,
+	)
+	if err != nil {
+		return fmt.Errorf("error touching member: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return space.ErrNotMember
+	}
+
+	return nil
+}
+
+// GetOccupancy counts a space's members and those active within the window
+func (s *MembershipStore) GetOccupancy(ctx context.Context, spaceID string, window time.Duration) (int, int, error) {
+	query := `
+		SELECT
+			COUNT(*),
+			COUNT(*) FILTER (WHERE last_active > NOW() - $2::interval)
+		FROM ephemeral_identities
+		WHERE space_id = $1
+		AND left_at IS NULL
+	`
+
+	var members, active int
+	if err := s.db.QueryRow(ctx, query, spaceID, window).Scan(&members, &active); err != nil {
+		return 0, 0, fmt.Errorf("error querying occupancy: %w", err)
+	}
+
+	return members, active, nil
+}
+
+// findMemberIdentity returns the ephemeral identity a current member uses in a space
+func findMemberIdentity(ctx context.Context, db *pgxpool.Pool, spaceID, userID string) (string, error) {
+	var identityID string
+
+	err := db.QueryRow(
+		ctx,
+		`SELECT id FROM ephemeral_identities
+		WHERE space_id = $1 AND user_id = $2 AND left_at IS NULL`,
+		spaceID, userID,
+	).Scan(&identityID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", space.ErrNotMember
+	}
+	if err != nil {
+		return "", fmt.Errorf("error querying member identity: %w", err)
+	}
+
+	return identityID, nil
+}
+
+// ensureUser creates a user with the given preferences if they don't exist yet,
+// returning the anonymity and location sharing preferences now on record
+func ensureUser(
+	ctx context.Context,
+	tx pgx.Tx,
+	userID string,
+	anonymous bool,
+	sharing identity.LocationSharingLevel,
+) (bool, identity.LocationSharingLevel, error) {
+	var shareLevel string
+
+	err := tx.QueryRow(
+		ctx,
+		`INSERT INTO users (id, created_at, last_seen, default_anonymity, location_sharing_preference)
+		VALUES ($1, NOW(), NOW(), $2, $3::location_sharing_level)
+		ON CONFLICT (id) DO UPDATE SET last_seen = NOW()
+		RETURNING default_anonymity, location_sharing_preference::text`,
+		userID, anonymous, string(sharing),
+	).Scan(&anonymous, &shareLevel)
+	if err != nil {
+		return false, "", fmt.Errorf("error ensuring user: %w", err)
+	}
+
+	return anonymous, identity.LocationSharingLevel(shareLevel), nil
+}
+
+// lockOpenSpace locks a space row, failing if the space is missing or dissolved
+func lockOpenSpace(ctx context.Context, tx pgx.Tx, spaceID string) error {
+	var stage string
+
+	err := tx.QueryRow(
+		ctx,
+		`SELECT lifecycle_stage::text FROM spaces WHERE id = $1 FOR UPDATE`,
+		spaceID,
+	).Scan(&stage)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return space.ErrSpaceNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("error locking space: %w", err)
+	}
+
+	if space.LifecycleStage(stage) == space.StageDissolved {
+		return space.ErrSpaceClosed
+	}
+
+	return nil
+}
+
+// adjustMemberCount changes a space's user count by delta, returning the new
+// count. Callers hold the space's lock.
+func adjustMemberCount(ctx context.Context, tx pgx.Tx, spaceID string, delta int) (int, error) {
+	var userCount int
+
+	err := tx.QueryRow(
+		ctx,
+		`UPDATE spaces SET user_count = GREATEST(user_count + $2, 0) WHERE id = $1 RETURNING user_count`,
+		spaceID, delta,
+	).Scan(&userCount)
+	if err != nil {
+		return 0, fmt.Errorf("error updating user count: %w", err)
+	}
+
+	return userCount, nil
+}
+
+// recountMembers sets a space's user count from its current members
+func recountMembers(ctx context.Context, tx pgx.Tx, spaceID string) (int, error) {
+	var userCount int
+
+	err := tx.QueryRow(
+		ctx,
+		`UPDATE spaces
+		SET user_count = (
+			SELECT COUNT(*) FROM ephemeral_identities
+			WHERE space_id = $1 AND left_at IS NULL
+		)
+		WHERE id = $1
+		RETURNING user_count`,
+		spaceID,
+	).Scan(&userCount)
+	if err != nil {
+		return 0, fmt.Errorf("error updating user count: %w", err)
+	}
+
+	return userCount, nil
+}
@@ -0,0 +1,225 @@
+// internal/adapter/storage/message_store.go
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"essg/internal/domain/identity"
+	"essg/internal/domain/messaging"
+	"essg/internal/domain/trend"
+)
+
+// MessageStore implements storage for space messages and reactions
+type MessageStore struct {
+	db *pgxpool.Pool
+}
+
+// NewMessageStore creates a new message store
+func NewMessageStore(db *pgxpool.Pool) *MessageStore {
+	return &MessageStore{
+		db: db,
+	}
+}
+
+// messageColumns are the columns scanned by scanMessage, with the author's
+// identity and reaction counts
+const messageColumns = `
+	m.id, m.space_id, m.user_id, m.type::text, COALESCE(m.content, ''), m.media_urls,
+	COALESCE(m.reply_to_id, ''), m.status::text, m.created_at, m.updated_at,
+	ST_X(m.location::geometry), ST_Y(m.location::geometry), m.is_anonymous,
+	ei.id, ei.nickname, COALESCE(ei.avatar, ''), ei.is_anonymous,
+	COALESCE((
+		SELECT jsonb_object_agg(reaction, n)
+		FROM (SELECT reaction, COUNT(*) AS n FROM reactions WHERE message_id = m.id GROUP BY reaction) counts
+	), '{}'::jsonb)
+`
+
+// SaveMessage stores a message, returning the stored message and whether it
+// was newly stored. Messages are a hypertable, so their ID alone can't be
+// unique; resends are caught under a lock on the ID and the message first
+// stored is returned.
+func (s *MessageStore) SaveMessage(ctx context.Context, message messaging.Message) (*messaging.Message, bool, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('message:' || $1))`, message.ID); err != nil {
+		return nil, false, fmt.Errorf("error locking message: %w", err)
+	}
+
+	existing, err := scanMessage(tx.QueryRow(
+		ctx,
+		`SELECT `+messageColumns+`
+		FROM messages m
+		LEFT JOIN ephemeral_identities ei ON ei.id = m.ephemeral_identity_id
+		WHERE m.id = $1 AND m.space_id = $2`,
+		message.ID, message.SpaceID,
+	))
+	if err == nil {
+		return existing, false, nil
+	}
+	if !errors.Is(err, messaging.ErrMessageNotFound) {
+		return nil, false, err
+	}
+
+	var identityID *string
+	if message.EphemeralIdentity != nil {
+		identityID = &message.EphemeralIdentity.ID
+	}
+
+	var lng, lat *float64
+	if message.Location != nil {
+		lng = &message.Location.Longitude
+		lat = &message.Location.Latitude
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		`INSERT INTO messages (
+			id, space_id, user_id, ephemeral_identity_id, type, content, media_urls,
+			reply_to_id, status, created_at, updated_at, location, is_anonymous
+		) VALUES (
+			$1, $2, $3, $4, $5::message_type, $6, $7,
+			NULLIF($8, ''), $9::message_status, $10, $10,
+			CASE WHEN $11::float8 IS NOT NULL AND $12::float8 IS NOT NULL THEN ST_MakePoint($11, $12)::geography END,
+			$13
+		)`,
+		message.ID, message.SpaceID, message.UserID, identityID, string(message.Type), message.Content, message.MediaURLs,
+		message.ReplyToID, string(message.Status), message.CreatedAt,
+		lng, lat,
+		message.IsAnonymous,
+	)
+	if err != nil {
+		return nil, false, fmt.Errorf("error inserting message: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return &message, true, nil
+}
+
+// SaveReaction stores a reaction to a message in a space, reporting false if
+// the user had already given it
+func (s *MessageStore) SaveReaction(
+	ctx context.Context,
+	spaceID, messageID, userID, reaction string,
+	at time.Time,
+) (bool, error) {
+	var messageExists, inserted bool
+
+	err := s.db.QueryRow(
+		ctx,
+		`WITH message AS (
+			SELECT id FROM messages WHERE id = $2 AND space_id = $1 LIMIT 1
+		),
+		reaction AS (
+			INSERT INTO reactions (id, message_id, user_id, reaction, created_at)
+			SELECT $5, id, $3, $4, $6 FROM message
+			ON CONFLICT (message_id, user_id, reaction) DO NOTHING
+			RETURNING id
+		)
+		SELECT EXISTS (SELECT 1 FROM message), EXISTS (SELECT 1 FROM reaction)`,
+		spaceID, messageID, userID, reaction, uuid.New().String(), at,
+	).Scan(&messageExists, &inserted)
+	if err != nil {
+		return false, fmt.Errorf("error inserting reaction: %w", err)
+	}
+	if !messageExists {
+		return false, messaging.ErrMessageNotFound
+	}
+
+	return inserted, nil
+}
+
+// GetRecentMessages returns a space's latest messages with their reaction counts, oldest first
+func (s *MessageStore) GetRecentMessages(ctx context.Context, spaceID string, limit int) ([]messaging.Message, error) {
+	rows, err := s.db.Query(
+		ctx,
+		`SELECT * FROM (
+			SELECT `+messageColumns+`
+			FROM messages m
+			LEFT JOIN ephemeral_identities ei ON ei.id = m.ephemeral_identity_id
+			WHERE m.space_id = $1 AND m.status <> 'removed'
+			ORDER BY m.created_at DESC
+			LIMIT $2
+		) recent
+		ORDER BY created_at ASC`,
+		spaceID, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error querying messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []messaging.Message
+	for rows.Next() {
+		message, err := scanMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, *message)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating messages: %w", err)
+	}
+
+	return messages, nil
+}
+
+// scanMessage scans a row of messageColumns
+func scanMessage(row pgx.Row) (*messaging.Message, error) {
+	var message messaging.Message
+	var messageType, status string
+	var lng, lat *float64
+	var identityID, nickname, avatar *string
+	var identityAnonymous *bool
+
+	err := row.Scan(
+		&message.ID, &message.SpaceID, &message.UserID, &messageType, &message.Content, &message.MediaURLs,
+		&message.ReplyToID, &status, &message.CreatedAt, &message.UpdatedAt,
+		&lng, &lat, &message.IsAnonymous,
+		&identityID, &nickname, &avatar, &identityAnonymous,
+		&message.Reactions,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, messaging.ErrMessageNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error scanning message: %w", err)
+	}
+
+	message.Type = messaging.MessageType(messageType)
+	message.Status = messaging.MessageStatus(status)
+
+	if lng != nil && lat != nil {
+		message.Location = &trend.Location{
+			Latitude:  *lat,
+			Longitude: *lng,
+		}
+	}
+
+	if identityID != nil {
+		message.EphemeralIdentity = &identity.EphemeralIdentity{
+			ID:          *identityID,
+			SpaceID:     message.SpaceID,
+			Nickname:    *nickname,
+			Avatar:      *avatar,
+			IsAnonymous: *identityAnonymous,
+		}
+	}
+
+	return &message, nil
+}
@@ -0,0 +1,267 @@
+// internal/adapter/storage/poll_store.go
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"essg/internal/domain/space"
+)
+
+// PollStore implements storage for space polls
+type PollStore struct {
+	db *pgxpool.Pool
+}
+
+// NewPollStore creates a new poll store
+func NewPollStore(db *pgxpool.Pool) *PollStore {
+	return &PollStore{
+		db: db,
+	}
+}
+
+// FindMemberIdentity returns the ephemeral identity a current member uses in a space
+func (s *PollStore) FindMemberIdentity(ctx context.Context, spaceID, userID string) (string, error) {
+	return findMemberIdentity(ctx, s.db, spaceID, userID)
+}
+
+// SavePoll stores a new poll and its options
+func (s *PollStore) SavePoll(ctx context.Context, p space.Poll) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(
+		ctx,
+		`INSERT INTO polls (id, space_id, creator_identity_id, question, deadline, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		p.ID, p.SpaceID, p.CreatorIdentityID, p.Question, p.Deadline, p.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error inserting poll: %w", err)
+	}
+
+	for i, option := range p.Options {
+		_, err = tx.Exec(
+			ctx,
+			`INSERT INTO poll_options (id, poll_id, position, text) VALUES ($1, $2, $3, $4)`,
+			option.ID, p.ID, i, option.Text,
+		)
+		if err != nil {
+			return fmt.Errorf("error inserting poll option: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetPoll retrieves a poll in a space with its current tallies
+func (s *PollStore) GetPoll(ctx context.Context, spaceID, pollID string) (*space.Poll, error) {
+	polls, err := s.queryPolls(ctx, `WHERE p.space_id = $1 AND p.id = $2`, spaceID, pollID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(polls) == 0 {
+		return nil, space.ErrPollNotFound
+	}
+
+	return &polls[0], nil
+}
+
+// FindPolls retrieves a space's polls with their tallies, newest first
+func (s *PollStore) FindPolls(ctx context.Context, spaceID string) ([]space.Poll, error) {
+	return s.queryPolls(ctx, `WHERE p.space_id = $1`, spaceID)
+}
+
+// SaveVote records an identity's vote, failing if the poll has closed or the
+// identity has already voted
+func (s *PollStore) SaveVote(ctx context.Context, pollID, identityID, optionID string) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the poll against closing while the vote is recorded
+	var deadline, closedAt *time.Time
+	err = tx.QueryRow(
+		ctx,
+		`SELECT deadline, closed_at FROM polls WHERE id = $1 FOR SHARE`,
+		pollID,
+	).Scan(&deadline, &closedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return space.ErrPollNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("error locking poll: %w", err)
+	}
+
+	poll := space.Poll{Deadline: deadline, ClosedAt: closedAt}
+	if !poll.IsOpen(time.Now()) {
+		return space.ErrPollClosed
+	}
+
+	// Check the option belongs to the poll
+	var exists bool
+	err = tx.QueryRow(
+		ctx,
+		`SELECT EXISTS (SELECT 1 FROM poll_options WHERE id = $1 AND poll_id = $2)`,
+		optionID, pollID,
+	).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("error checking poll option: %w", err)
+	}
+	if !exists {
+		return space.ErrUnknownOption
+	}
+
+	tag, err := tx.Exec(
+		ctx,
+		`INSERT INTO poll_votes (poll_id, identity_id, option_id, voted_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (poll_id, identity_id) DO NOTHING`,
+		pollID, identityID, optionID,
+	)
+	if err != nil {
+		return fmt.Errorf("error inserting vote: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return space.ErrAlreadyVoted
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ClosePoll closes an open poll, reporting whether it was open
+func (s *PollStore) ClosePoll(ctx context.Context, pollID string) (bool, error) {
+	tag, err := s.db.Exec(
+		ctx,
+		`UPDATE polls SET closed_at = NOW() WHERE id = $1 AND closed_at IS NULL`,
+		pollID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("error closing poll: %w", err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// CloseSpacePolls closes every open poll in a space, returning the polls closed
+func (s *PollStore) CloseSpacePolls(ctx context.Context, spaceID string) ([]space.Poll, error) {
+	return s.closePolls(
+		ctx,
+		`UPDATE polls SET closed_at = NOW()
+		WHERE space_id = $1 AND closed_at IS NULL
+		RETURNING id, space_id`,
+		spaceID,
+	)
+}
+
+// CloseExpiredPolls closes open polls whose deadline has passed, returning the polls closed
+func (s *PollStore) CloseExpiredPolls(ctx context.Context) ([]space.Poll, error) {
+	return s.closePolls(
+		ctx,
+		`UPDATE polls SET closed_at = deadline
+		WHERE closed_at IS NULL AND deadline <= NOW()
+		RETURNING id, space_id`,
+	)
+}
+
+// closePolls runs a closing update and collects the polls it returns
+func (s *PollStore) closePolls(ctx context.Context, query string, args ...interface{}) ([]space.Poll, error) {
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error closing polls: %w", err)
+	}
+	defer rows.Close()
+
+	var closed []space.Poll
+	for rows.Next() {
+		var p space.Poll
+		if err := rows.Scan(&p.ID, &p.SpaceID); err != nil {
+			return nil, fmt.Errorf("error scanning closed poll: %w", err)
+		}
+		closed = append(closed, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating closed polls: %w", err)
+	}
+
+	return closed, nil
+}
+
+// queryPolls loads polls matching a condition along with their option tallies
+func (s *PollStore) queryPolls(ctx context.Context, where string, args ...interface{}) ([]space.Poll, error) {
+	query := `
+		SELECT
+			p.id, p.space_id, p.creator_identity_id, p.question, p.deadline,
+			p.created_at, p.closed_at,
+			o.id, o.text,
+			(SELECT COUNT(*) FROM poll_votes v WHERE v.option_id = o.id)
+		FROM polls p
+		JOIN poll_options o ON o.poll_id = p.id
+		` + where + `
+		ORDER BY p.created_at DESC, p.id, o.position
+	`
+
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error executing query: %w", err)
+	}
+	defer rows.Close()
+
+	var polls []space.Poll
+	for rows.Next() {
+		var p space.Poll
+		var option space.PollOption
+
+		err := rows.Scan(
+			&p.ID,
+			&p.SpaceID,
+			&p.CreatorIdentityID,
+			&p.Question,
+			&p.Deadline,
+			&p.CreatedAt,
+			&p.ClosedAt,
+			&option.ID,
+			&option.Text,
+			&option.Votes,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning poll: %w", err)
+		}
+
+		// Rows are grouped by poll, so options follow their poll
+		if len(polls) == 0 || polls[len(polls)-1].ID != p.ID {
+			polls = append(polls, p)
+		}
+
+		current := &polls[len(polls)-1]
+		current.Options = append(current.Options, option)
+		current.TotalVotes += option.Votes
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating polls: %w", err)
+	}
+
+	return polls, nil
+}
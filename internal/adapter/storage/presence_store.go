@@ -0,0 +1,363 @@
+// internal/adapter/storage/presence_store.go
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"essg/internal/domain/identity"
+	"essg/internal/domain/space"
+)
+
+// PresenceStore implements storage for presence shared between replicas.
+// Each replica keeps one record per identity it has connections from, so an
+// identity is present while any replica's record for it is fresh.
+type PresenceStore struct {
+	db *pgxpool.Pool
+}
+
+// NewPresenceStore creates a new presence store
+func NewPresenceStore(db *pgxpool.Pool) *PresenceStore {
+	return &PresenceStore{
+		db: db,
+	}
+}
+
+// AddConnection records that a replica has connections from an identity,
+// reporting whether the identity was newly present in the space
+func (s *PresenceStore) AddConnection(
+	ctx context.Context,
+	spaceID, identityID, replicaID string,
+	at, staleBefore time.Time,
+) (bool, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Serialize with other replicas adding or removing the same identity
+	if err := lockPresence(ctx, tx, spaceID, identityID); err != nil {
+		return false, err
+	}
+
+	present, err := isPresent(ctx, tx, spaceID, identityID, staleBefore)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		`INSERT INTO space_presence (space_id, identity_id, replica_id, status, connected_at, last_seen)
+		VALUES ($1, $2, $3, $4, $5, $5)
+		ON CONFLICT (space_id, identity_id, replica_id) DO UPDATE
+		SET status = EXCLUDED.status, last_seen = EXCLUDED.last_seen`,
+		spaceID, identityID, replicaID, string(space.PresenceActive), at,
+	)
+	if err != nil {
+		return false, fmt.Errorf("error adding presence: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return !present, nil
+}
+
+// RemoveConnection removes a replica's record of an identity, reporting
+// whether the identity is no longer present on any replica
+func (s *PresenceStore) RemoveConnection(
+	ctx context.Context,
+	spaceID, identityID, replicaID string,
+	staleBefore time.Time,
+) (bool, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockPresence(ctx, tx, spaceID, identityID); err != nil {
+		return false, err
+	}
+
+	tag, err := tx.Exec(
+		ctx,
+		`DELETE FROM space_presence WHERE space_id = $1 AND identity_id = $2 AND replica_id = $3`,
+		spaceID, identityID, replicaID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("error removing presence: %w", err)
+	}
+
+	present, err := isPresent(ctx, tx, spaceID, identityID, staleBefore)
+	if err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	// Nothing was removed if the record had already expired and been announced
+	return tag.RowsAffected() > 0 && !present, nil
+}
+
+// UpdatePresence updates a replica's status and typing state for an identity
+func (s *PresenceStore) UpdatePresence(
+	ctx context.Context,
+	spaceID, identityID, replicaID string,
+	status space.PresenceStatus,
+	typingUntil time.Time,
+) error {
+	var typing *time.Time
+	if !typingUntil.IsZero() {
+		typing = &typingUntil
+	}
+
+	_, err := s.db.Exec(
+		ctx,
+		`UPDATE space_presence SET status = $4, typing_until = $5
+		WHERE space_id = $1 AND identity_id = $2 AND replica_id = $3`,
+		spaceID, identityID, replicaID, string(status), typing,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating presence: %w", err)
+	}
+
+	return nil
+}
+
+// Heartbeat refreshes a replica's records for the identities it has
+// connections from, restoring any that were expired while it was unreachable
+func (s *PresenceStore) Heartbeat(
+	ctx context.Context,
+	replicaID string,
+	present []identity.EphemeralIdentity,
+	at time.Time,
+) error {
+	if len(present) == 0 {
+		return nil
+	}
+
+	spaceIDs := make([]string, len(present))
+	identityIDs := make([]string, len(present))
+	for i, ident := range present {
+		spaceIDs[i] = ident.SpaceID
+		identityIDs[i] = ident.ID
+	}
+
+	_, err := s.db.Exec(
+		ctx,
+		`INSERT INTO space_presence (space_id, identity_id, replica_id, status, connected_at, last_seen)
+		SELECT p.space_id, p.identity_id, $3, $4, $5, $5
+		FROM unnest($1::text[], $2::text[]) AS p(space_id, identity_id)
+		ON CONFLICT (space_id, identity_id, replica_id) DO UPDATE
+		SET last_seen = EXCLUDED.last_seen`,
+		spaceIDs, identityIDs, replicaID, string(space.PresenceActive), at,
+	)
+	if err != nil {
+		return fmt.Errorf("error refreshing presence: %w", err)
+	}
+
+	return nil
+}
+
+// ExpirePresence removes records not refreshed since staleBefore, left behind
+// by replicas that stopped without cleaning up. It returns the identities that
+// are no longer present anywhere.
+func (s *PresenceStore) ExpirePresence(ctx context.Context, staleBefore time.Time) ([]identity.EphemeralIdentity, error) {
+	return s.removePresence(ctx, `last_seen < $1`, staleBefore)
+}
+
+// RemoveReplica removes every record a replica holds, returning the
+// identities that are no longer present anywhere
+func (s *PresenceStore) RemoveReplica(
+	ctx context.Context,
+	replicaID string,
+	staleBefore time.Time,
+) ([]identity.EphemeralIdentity, error) {
+	return s.removePresence(ctx, `replica_id = $2`, staleBefore, replicaID)
+}
+
+// removePresence deletes the records matching a condition, returning the
+// identities left without fresh records. The condition may use $1 for
+// staleBefore and later parameters for args.
+func (s *PresenceStore) removePresence(
+	ctx context.Context,
+	condition string,
+	staleBefore time.Time,
+	args ...interface{},
+) ([]identity.EphemeralIdentity, error) {
+	// The outer query sees records as they were before the delete, so records
+	// removed here are excluded by their replica and freshness instead
+	query := `
+		WITH removed AS (
+			DELETE FROM space_presence WHERE ` + condition + `
+			RETURNING space_id, identity_id, replica_id
+		)
+		SELECT DISTINCT ei.id, ei.space_id, ei.nickname
+		FROM removed r
+		JOIN ephemeral_identities ei ON ei.id = r.identity_id
+		WHERE NOT EXISTS (
+			SELECT 1 FROM space_presence p
+			WHERE p.space_id = r.space_id AND p.identity_id = r.identity_id
+			AND p.last_seen >= $1
+			AND NOT EXISTS (
+				SELECT 1 FROM removed x
+				WHERE x.space_id = p.space_id AND x.identity_id = p.identity_id AND x.replica_id = p.replica_id
+			)
+		)
+	`
+
+	rows, err := s.db.Query(ctx, query, append([]interface{}{staleBefore}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("error removing presence: %w", err)
+	}
+	defer rows.Close()
+
+	var departed []identity.EphemeralIdentity
+	for rows.Next() {
+		var ident identity.EphemeralIdentity
+		if err := rows.Scan(&ident.ID, &ident.SpaceID, &ident.Nickname); err != nil {
+			return nil, fmt.Errorf("error scanning presence: %w", err)
+		}
+		departed = append(departed, ident)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating presence: %w", err)
+	}
+
+	return departed, nil
+}
+
+// FindPresence returns the identities with fresh records in a space. An
+// identity connected through several replicas is active if it is active on any.
+func (s *PresenceStore) FindPresence(
+	ctx context.Context,
+	spaceID string,
+	staleBefore time.Time,
+) ([]space.PresentIdentity, error) {
+	query := `
+		SELECT
+			ei.id, ei.nickname, COALESCE(ei.avatar, ''), ei.is_anonymous,
+			bool_or(p.status = 'active'),
+			COALESCE(MAX(p.typing_until) > NOW(), FALSE),
+			MIN(p.connected_at)
+		FROM space_presence p
+		JOIN ephemeral_identities ei ON ei.id = p.identity_id
+		WHERE p.space_id = $1 AND p.last_seen >= $2
+		GROUP BY ei.id, ei.nickname, ei.avatar, ei.is_anonymous
+		ORDER BY MIN(p.connected_at)
+	`
+
+	rows, err := s.db.Query(ctx, query, spaceID, staleBefore)
+	if err != nil {
+		return nil, fmt.Errorf("error querying presence: %w", err)
+	}
+	defer rows.Close()
+
+	var present []space.PresentIdentity
+	for rows.Next() {
+		var p space.PresentIdentity
+		var active bool
+		if err := rows.Scan(&p.IdentityID, &p.Nickname, &p.Avatar, &p.IsAnonymous, &active, &p.Typing, &p.ConnectedAt); err != nil {
+			return nil, fmt.Errorf("error scanning presence: %w", err)
+		}
+
+		p.Status = space.PresenceIdle
+		if active {
+			p.Status = space.PresenceActive
+		}
+		present = append(present, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating presence: %w", err)
+	}
+
+	return present, nil
+}
+
+// CountPresence counts the identities with fresh records in a space
+func (s *PresenceStore) CountPresence(ctx context.Context, spaceID string, staleBefore time.Time) (int, error) {
+	var count int
+	err := s.db.QueryRow(
+		ctx,
+		`SELECT COUNT(DISTINCT identity_id) FROM space_presence WHERE space_id = $1 AND last_seen >= $2`,
+		spaceID, staleBefore,
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("error counting presence: %w", err)
+	}
+
+	return count, nil
+}
+
+// CountPresenceBySpace counts the identities with fresh records in each of
+// several spaces. Spaces with nobody present are left out.
+func (s *PresenceStore) CountPresenceBySpace(ctx context.Context, spaceIDs []string, staleBefore time.Time) (map[string]int, error) {
+	rows, err := s.db.Query(
+		ctx,
+		`SELECT space_id, COUNT(DISTINCT identity_id)
+		FROM space_presence
+		WHERE space_id = ANY($1) AND last_seen >= $2
+		GROUP BY space_id`,
+		spaceIDs, staleBefore,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error counting presence: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int, len(spaceIDs))
+	for rows.Next() {
+		var spaceID string
+		var count int
+		if err := rows.Scan(&spaceID, &count); err != nil {
+			return nil, fmt.Errorf("error scanning presence count: %w", err)
+		}
+		counts[spaceID] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating presence counts: %w", err)
+	}
+
+	return counts, nil
+}
+
+// lockPresence takes a transaction-scoped lock on an identity's presence in a space
+func lockPresence(ctx context.Context, tx pgx.Tx, spaceID, identityID string) error {
+	_, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, "presence:"+spaceID+":"+identityID)
+	if err != nil {
+		return fmt.Errorf("error locking presence: %w", err)
+	}
+
+	return nil
+}
+
+// isPresent reports whether an identity has a fresh record in a space
+func isPresent(ctx context.Context, tx pgx.Tx, spaceID, identityID string, staleBefore time.Time) (bool, error) {
+	var present bool
+	err := tx.QueryRow(
+		ctx,
+		`SELECT EXISTS (
+			SELECT 1 FROM space_presence
+			WHERE space_id = $1 AND identity_id = $2 AND last_seen >= $3
+		)`,
+		spaceID, identityID, staleBefore,
+	).Scan(&present)
+	if err != nil {
+		return false, fmt.Errorf("error checking presence: %w", err)
+	}
+
+	return present, nil
+}
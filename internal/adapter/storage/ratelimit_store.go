@@ -0,0 +1,74 @@
+// internal/adapter/storage/ratelimit_store.go
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// RateLimitStore implements storage for fixed-window action counts
+type RateLimitStore struct {
+	db *pgxpool.Pool
+}
+
+// NewRateLimitStore creates a new rate limit store
+func NewRateLimitStore(db *pgxpool.Pool) *RateLimitStore {
+	return &RateLimitStore{
+		db: db,
+	}
+}
+
+// CountActions returns how many times a user took an action on a resource in a window
+func (s *RateLimitStore) CountActions(
+	ctx context.Context,
+	userID, actionType, resourceID string,
+	windowStart time.Time,
+) (int, error) {
+	var count int
+
+	err := s.db.QueryRow(
+		ctx,
+		`SELECT count FROM rate_limits
+		WHERE user_id = $1 AND action_type = $2 AND resource_id = $3 AND window_start = $4`,
+		userID, actionType, resourceID, windowStart,
+	).Scan(&count)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error querying rate limit: %w", err)
+	}
+
+	return count, nil
+}
+
+// RecordAction counts an action against a user's window, returning the
+// window's count including it
+func (s *RateLimitStore) RecordAction(
+	ctx context.Context,
+	userID, actionType, resourceID string,
+	windowStart time.Time,
+) (int, error) {
+	var count int
+
+	err := s.db.QueryRow(
+		ctx,
+		`INSERT INTO rate_limits (user_id, action_type, resource_id, count, window_start)
+		VALUES ($1, $2, $3, 1, $4)
+		ON CONFLICT (user_id, action_type, resource_id, window_start)
+		DO UPDATE SET count = rate_limits.count + 1
+		RETURNING count`,
+		userID, actionType, resourceID, windowStart,
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("error recording action: %w", err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,283 @@
+// internal/adapter/storage/relation_store.go
+
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"essg/internal/domain/space"
+	"essg/internal/domain/trend"
+)
+
+// RelationStore implements storage for space relations
+type RelationStore struct {
+	db *pgxpool.Pool
+}
+
+// NewRelationStore creates a new relation store
+func NewRelationStore(db *pgxpool.Pool) *RelationStore {
+	return &RelationStore{
+		db: db,
+	}
+}
+
+// SaveRelations replaces the stored relations for a space
+func (s *RelationStore) SaveRelations(ctx context.Context, spaceID string, relations []space.Relation) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Drop previous links
+	if _, err := tx.Exec(ctx, `DELETE FROM space_relations WHERE space_id = $1`, spaceID); err != nil {
+		return fmt.Errorf("error deleting relations: %w", err)
+	}
+
+	// Insert current links
+	relatedIDs := make([]string, 0, len(relations))
+	for _, rel := range relations {
+		factorsJSON, err := json.Marshal(rel.Factors)
+		if err != nil {
+			return fmt.Errorf("error marshaling relation factors: %w", err)
+		}
+
+		_, err = tx.Exec(
+			ctx,
+			`INSERT INTO space_relations (space_id, related_space_id, score, factors, updated_at)
+			VALUES ($1, $2, $3, $4, $5)`,
+			spaceID,
+			rel.RelatedSpaceID,
+			rel.Score,
+			factorsJSON,
+			rel.UpdatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("error inserting relation: %w", err)
+		}
+
+		relatedIDs = append(relatedIDs, rel.RelatedSpaceID)
+	}
+
+	// Keep the denormalized list on the space in sync
+	if _, err := tx.Exec(ctx, `UPDATE spaces SET related_spaces = $2 WHERE id = $1`, spaceID, relatedIDs); err != nil {
+		return fmt.Errorf("error updating related spaces: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing relations: %w", err)
+	}
+
+	return nil
+}
+
+// GetRelations retrieves the stored relations for a space, best first
+func (s *RelationStore) GetRelations(ctx context.Context, spaceID string, limit int) ([]space.Relation, error) {
+	query := `
+		SELECT sr.space_id, sr.related_space_id, sr.score, sr.factors, sr.updated_at
+		FROM space_relations sr
+		JOIN spaces sp ON sp.id = sr.related_space_id
+		WHERE sr.space_id = $1
+		AND sp.lifecycle_stage IN ('growing', 'peak', 'waning')
+		ORDER BY sr.score DESC
+		LIMIT $2
+	`
+
+	rows, err := s.db.Query(ctx, query, spaceID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error executing query: %w", err)
+	}
+	defer rows.Close()
+
+	var relations []space.Relation
+	for rows.Next() {
+		var rel space.Relation
+		var factorsJSON []byte
+
+		if err := rows.Scan(&rel.SpaceID, &rel.RelatedSpaceID, &rel.Score, &factorsJSON, &rel.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning relation: %w", err)
+		}
+
+		if err := json.Unmarshal(factorsJSON, &rel.Factors); err != nil {
+			return nil, fmt.Errorf("error unmarshaling relation factors: %w", err)
+		}
+
+		relations = append(relations, rel)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating relations: %w", err)
+	}
+
+	return relations, nil
+}
+
+// DeleteRelations removes every relation that involves a space, including it
+// from the denormalized lists of the spaces it was related to
+func (s *RelationStore) DeleteRelations(ctx context.Context, spaceID string) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(
+		ctx,
+		`UPDATE spaces SET related_spaces = array_remove(related_spaces, $1)
+		WHERE $1 = ANY(related_spaces)`,
+		spaceID,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating related spaces: %w", err)
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		`DELETE FROM space_relations WHERE space_id = $1 OR related_space_id = $1`,
+		spaceID,
+	)
+	if err != nil {
+		return fmt.Errorf("error deleting relations: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE spaces SET related_spaces = '{}' WHERE id = $1`, spaceID); err != nil {
+		return fmt.Errorf("error clearing related spaces: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing relations: %w", err)
+	}
+
+	return nil
+}
+
+// GetCoParticipation counts users shared between each pair of the given spaces
+func (s *RelationStore) GetCoParticipation(ctx context.Context, spaceIDs []string) (map[string]map[string]int, error) {
+	query := `
+		SELECT a.space_id, b.space_id, COUNT(DISTINCT a.user_id)
+		FROM ephemeral_identities a
+		JOIN ephemeral_identities b ON a.user_id = b.user_id AND a.space_id < b.space_id
+		WHERE a.space_id = ANY($1) AND b.space_id = ANY($1)
+		GROUP BY a.space_id, b.space_id
+	`
+
+	rows, err := s.db.Query(ctx, query, spaceIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error executing query: %w", err)
+	}
+	defer rows.Close()
+
+	shared := make(map[string]map[string]int)
+	for rows.Next() {
+		var a, b string
+		var count int
+
+		if err := rows.Scan(&a, &b, &count); err != nil {
+			return nil, fmt.Errorf("error scanning co-participation: %w", err)
+		}
+
+		// Record both directions so lookups don't depend on ordering
+		if shared[a] == nil {
+			shared[a] = make(map[string]int)
+		}
+		if shared[b] == nil {
+			shared[b] = make(map[string]int)
+		}
+		shared[a][b] = count
+		shared[b][a] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating co-participation: %w", err)
+	}
+
+	return shared, nil
+}
+
+// GetCoParticipationWith counts users one space shares with each of the given spaces
+func (s *RelationStore) GetCoParticipationWith(ctx context.Context, spaceID string, spaceIDs []string) (map[string]int, error) {
+	query := `
+		SELECT b.space_id, COUNT(DISTINCT a.user_id)
+		FROM ephemeral_identities a
+		JOIN ephemeral_identities b ON a.user_id = b.user_id AND b.space_id <> a.space_id
+		WHERE a.space_id = $1 AND b.space_id = ANY($2)
+		GROUP BY b.space_id
+	`
+
+	rows, err := s.db.Query(ctx, query, spaceID, spaceIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error executing query: %w", err)
+	}
+	defer rows.Close()
+
+	shared := make(map[string]int)
+	for rows.Next() {
+		var other string
+		var count int
+
+		if err := rows.Scan(&other, &count); err != nil {
+			return nil, fmt.Errorf("error scanning co-participation: %w", err)
+		}
+
+		shared[other] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating co-participation: %w", err)
+	}
+
+	return shared, nil
+}
+
+// GetTrendSources retrieves the sources of each of the given trends
+func (s *RelationStore) GetTrendSources(ctx context.Context, trendIDs []string) (map[string][]trend.Source, error) {
+	rows, err := s.db.Query(ctx, `SELECT id, sources FROM trends WHERE id = ANY($1)`, trendIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error executing query: %w", err)
+	}
+	defer rows.Close()
+
+	sources := make(map[string][]trend.Source)
+	for rows.Next() {
+		var id string
+		var sourcesJSON []byte
+
+		if err := rows.Scan(&id, &sourcesJSON); err != nil {
+			return nil, fmt.Errorf("error scanning trend sources: %w", err)
+		}
+
+		var trendSources []trend.Source
+		if len(sourcesJSON) > 0 {
+			if err := json.Unmarshal(sourcesJSON, &trendSources); err != nil {
+				return nil, fmt.Errorf("error unmarshaling sources: %w", err)
+			}
+		}
+
+		sources[id] = trendSources
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating trend sources: %w", err)
+	}
+
+	return sources, nil
+}
+
+// SaveRelatedTrends updates the related trends recorded for a trend
+func (s *RelationStore) SaveRelatedTrends(ctx context.Context, trendID string, related []string) error {
+	_, err := s.db.Exec(
+		ctx,
+		`UPDATE trends SET related_trends = $2 WHERE id = $1`,
+		trendID,
+		related,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating related trends: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,307 @@
+// internal/adapter/storage/reputation_store.go
+
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"essg/internal/domain/identity"
+)
+
+// ReputationStore implements storage for ephemeral identity reputation
+type ReputationStore struct {
+	db *pgxpool.Pool
+}
+
+// NewReputationStore creates a new reputation store
+func NewReputationStore(db *pgxpool.Pool) *ReputationStore {
+	return &ReputationStore{
+		db: db,
+	}
+}
+
+// addReputationQuery adds $3 to reputation dimension $2 of identity $1
+const addReputationQuery = `
+	UPDATE ephemeral_identities
+	SET reputation = jsonb_set(
+		COALESCE(reputation, '{}'::jsonb),
+		ARRAY[$2::text],
+		to_jsonb(COALESCE((reputation->>$2::text)::float8, 0) + $3::float8)
+	)
+	WHERE id = $1
+`
+
+// lockMessageCreditsQuery serializes recording a message's author with
+// crediting it, so a credit can't be queued just after the author arrives
+const lockMessageCreditsQuery = `SELECT pg_advisory_xact_lock(hashtext('message_credits:' || $1))`
+
+// SaveMessageAuthor records which identity wrote a message, if it belongs to
+// the message's space, and applies credits given before it was recorded.
+// The author is returned if any queued credit was applied to them.
+func (s *ReputationStore) SaveMessageAuthor(
+	ctx context.Context,
+	messageID, spaceID, identityID string,
+	createdAt time.Time,
+) (*identity.EphemeralIdentity, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, lockMessageCreditsQuery, messageID); err != nil {
+		return nil, fmt.Errorf("error locking message credits: %w", err)
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		`INSERT INTO message_authors (message_id, space_id, identity_id, created_at)
+		SELECT $1, space_id, id, $4 FROM ephemeral_identities WHERE id = $3 AND space_id = $2
+		ON CONFLICT (message_id) DO NOTHING`,
+		messageID, spaceID, identityID, createdAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error saving message author: %w", err)
+	}
+
+	// Take the credits that arrived first
+	rows, err := tx.Query(
+		ctx,
+		`DELETE FROM pending_reputation_credits WHERE message_id = $1
+		RETURNING giver_identity_id, dimension, delta`,
+		messageID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error taking pending credits: %w", err)
+	}
+
+	type pendingCredit struct {
+		giverID   string
+		dimension string
+		delta     float64
+	}
+	var pending []pendingCredit
+	for rows.Next() {
+		var credit pendingCredit
+		if err := rows.Scan(&credit.giverID, &credit.dimension, &credit.delta); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error scanning pending credit: %w", err)
+		}
+		pending = append(pending, credit)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating pending credits: %w", err)
+	}
+
+	var author *identity.EphemeralIdentity
+	for _, credit := range pending {
+		credited, err := creditAuthor(ctx, tx, messageID, credit.giverID, credit.dimension, credit.delta)
+		if err != nil {
+			return nil, err
+		}
+		if credited != nil {
+			author = credited
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return author, nil
+}
+
+// CreditAuthor adds to a reputation dimension of a message's author on behalf
+// of another identity in the same space. Each giver credits a message at most
+// once per dimension; nil is returned when nothing was credited. Credits for a
+// message whose author hasn't been recorded yet are queued until it is.
+func (s *ReputationStore) CreditAuthor(
+	ctx context.Context,
+	messageID, giverIdentityID, dimension string,
+	delta float64,
+) (*identity.EphemeralIdentity, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, lockMessageCreditsQuery, messageID); err != nil {
+		return nil, fmt.Errorf("error locking message credits: %w", err)
+	}
+
+	var known bool
+	err = tx.QueryRow(
+		ctx,
+		`SELECT EXISTS (SELECT 1 FROM message_authors WHERE message_id = $1)`,
+		messageID,
+	).Scan(&known)
+	if err != nil {
+		return nil, fmt.Errorf("error checking message author: %w", err)
+	}
+
+	var author *identity.EphemeralIdentity
+	if known {
+		author, err = creditAuthor(ctx, tx, messageID, giverIdentityID, dimension, delta)
+	} else {
+		_, err = tx.Exec(
+			ctx,
+			`INSERT INTO pending_reputation_credits (message_id, giver_identity_id, dimension, delta, created_at)
+			VALUES ($1, $2, $3, $4, NOW())`,
+			messageID, giverIdentityID, dimension, delta,
+		)
+		if err != nil {
+			err = fmt.Errorf("error queuing credit: %w", err)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return author, nil
+}
+
+// creditAuthor credits a recorded message author within a transaction,
+// skipping self-credit, givers from other spaces and repeat credits
+func creditAuthor(
+	ctx context.Context,
+	tx pgx.Tx,
+	messageID, giverIdentityID, dimension string,
+	delta float64,
+) (*identity.EphemeralIdentity, error) {
+	query := `
+		WITH author AS (
+			SELECT ma.message_id, ma.identity_id
+			FROM message_authors ma
+			JOIN ephemeral_identities giver ON giver.id = $2 AND giver.space_id = ma.space_id
+			WHERE ma.message_id = $1 AND ma.identity_id <> $2
+		),
+		credit AS (
+			INSERT INTO reputation_credits (message_id, giver_identity_id, dimension, created_at)
+			SELECT message_id, $2, $3, NOW() FROM author
+			ON CONFLICT DO NOTHING
+			RETURNING message_id
+		)
+		SELECT author.identity_id FROM author JOIN credit USING (message_id)
+	`
+
+	var authorID string
+	err := tx.QueryRow(ctx, query, messageID, giverIdentityID, dimension).Scan(&authorID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error recording credit: %w", err)
+	}
+
+	return scanEphemeralIdentity(tx.QueryRow(
+		ctx,
+		addReputationQuery+` RETURNING `+identityColumns,
+		authorID, dimension, delta,
+	))
+}
+
+// AddStrike records a moderation strike against an identity in a space
+func (s *ReputationStore) AddStrike(ctx context.Context, spaceID, identityID string) (*identity.EphemeralIdentity, error) {
+	return scanEphemeralIdentity(s.db.QueryRow(
+		ctx,
+		addReputationQuery+` AND space_id = $4 RETURNING `+identityColumns,
+		identityID, identity.ReputationStrikes, 1.0, spaceID,
+	))
+}
+
+// GetTrustInputs returns a user's reputation in a space, which is empty if
+// they have no identity there, and the trust tier carried over to them
+func (s *ReputationStore) GetTrustInputs(
+	ctx context.Context,
+	userID, spaceID string,
+) (map[string]float64, identity.TrustTier, error) {
+	var reputationJSON []byte
+	var carried string
+
+	err := s.db.QueryRow(
+		ctx,
+		`SELECT ei.reputation, u.carried_trust
+		FROM users u
+		LEFT JOIN ephemeral_identities ei ON ei.user_id = u.id AND ei.space_id = $2
+		WHERE u.id = $1`,
+		userID, spaceID,
+	).Scan(&reputationJSON, &carried)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, "", identity.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("error querying trust: %w", err)
+	}
+
+	reputation := map[string]float64{}
+	if len(reputationJSON) > 0 {
+		if err := json.Unmarshal(reputationJSON, &reputation); err != nil {
+			return nil, "", fmt.Errorf("error unmarshaling reputation: %w", err)
+		}
+	}
+
+	return reputation, identity.TrustTier(carried), nil
+}
+
+// GetGiverStanding returns an identity's reputation, the trust tier carried
+// over to its owner and whether its owner is a guest
+func (s *ReputationStore) GetGiverStanding(
+	ctx context.Context,
+	identityID string,
+) (map[string]float64, identity.TrustTier, bool, error) {
+	var reputationJSON []byte
+	var carried string
+	var isGuest bool
+
+	err := s.db.QueryRow(
+		ctx,
+		`SELECT ei.reputation, u.carried_trust, u.is_guest
+		FROM ephemeral_identities ei
+		JOIN users u ON u.id = ei.user_id
+		WHERE ei.id = $1`,
+		identityID,
+	).Scan(&reputationJSON, &carried, &isGuest)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, "", false, identity.ErrIdentityNotFound
+	}
+	if err != nil {
+		return nil, "", false, fmt.Errorf("error querying giver standing: %w", err)
+	}
+
+	reputation := map[string]float64{}
+	if len(reputationJSON) > 0 {
+		if err := json.Unmarshal(reputationJSON, &reputation); err != nil {
+			return nil, "", false, fmt.Errorf("error unmarshaling reputation: %w", err)
+		}
+	}
+
+	return reputation, identity.TrustTier(carried), isGuest, nil
+}
+
+// SetCarriedTrust sets the trust tier carried into the identity owner's future identities
+func (s *ReputationStore) SetCarriedTrust(ctx context.Context, identityID string, tier identity.TrustTier) error {
+	_, err := s.db.Exec(
+		ctx,
+		`UPDATE users SET carried_trust = $2
+		WHERE id = (SELECT user_id FROM ephemeral_identities WHERE id = $1)`,
+		identityID, string(tier),
+	)
+	if err != nil {
+		return fmt.Errorf("error setting carried trust: %w", err)
+	}
+
+	return nil
+}
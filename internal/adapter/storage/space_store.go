@@ -5,9 +5,11 @@ package storage
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 
 	"essg/internal/domain/space"
@@ -26,38 +28,43 @@ func NewSpaceStore(db *pgxpool.Pool) *SpaceStore {
 	}
 }
 
-// SaveSpace saves a space to storage
+// SaveSpace saves a space to storage. The user count and related spaces of an
+// existing space are left alone since membership changes and relation
+// refreshes maintain them.
 func (s *SpaceStore) SaveSpace(ctx context.Context, sp space.Space) error {
 	query := `
 		INSERT INTO spaces (
 			id, title, description, trend_id, template_type, lifecycle_stage,
 			created_at, last_active, expires_at, user_count, message_count,
 			location, location_radius, is_geo_local,
-			topic_tags, related_spaces, engagement_metrics, features
+			topic_tags, related_spaces, engagement_metrics, features,
+			owner_id, is_pinned, expiry_override
 		) VALUES (
-			$1, $2, $3, $4, $5::template_type, $6::lifecycle_stage,
+			$1, $2, $3, NULLIF($4, ''), $5, $6::lifecycle_stage,
 			$7, $8, $9, $10, $11,
 			ST_MakePoint($12, $13)::geography, $14, $15,
-			$16, $17, $18, $19
+			$16, $17, $18, $19,
+			NULLIF($20, ''), $21, $22
 		)
 		ON CONFLICT (id) DO UPDATE
 		SET
 			title = $2,
 			description = $3,
-			trend_id = $4,
-			template_type = $5::template_type,
+			trend_id = NULLIF($4, ''),
+			template_type = $5,
 			lifecycle_stage = $6::lifecycle_stage,
 			last_active = $8,
 			expires_at = $9,
-			user_count = $10,
 			message_count = $11,
 			location = CASE WHEN $12 IS NOT NULL AND $13 IS NOT NULL THEN ST_MakePoint($12, $13)::geography ELSE spaces.location END,
 			location_radius = $14,
 			is_geo_local = $15,
 			topic_tags = $16,
-			related_spaces = $17,
 			engagement_metrics = $18,
-			features = $19
+			features = $19,
+			owner_id = NULLIF($20, ''),
+			is_pinned = $21,
+			expiry_override = $22
 	`
 
 	// Prepare location data
@@ -101,6 +108,9 @@ func (s *SpaceStore) SaveSpace(ctx context.Context, sp space.Space) error {
 		sp.RelatedSpaces,
 		metricsJSON,
 		featuresJSON,
+		sp.OwnerID,
+		sp.IsPinned,
+		sp.ExpiryOverride,
 	)
 
 	if err != nil {
@@ -110,25 +120,70 @@ func (s *SpaceStore) SaveSpace(ctx context.Context, sp space.Space) error {
 	return nil
 }
 
+// spaceColumns are the columns scanned by scanSpace
+const spaceColumns = `
+	id, title, description, COALESCE(trend_id, ''), template_type, lifecycle_stage::text,
+	created_at, last_active, expires_at, user_count, message_count,
+	ST_X(location::geometry) as lng, ST_Y(location::geometry) as lat,
+	location_radius, is_geo_local,
+	topic_tags, related_spaces, engagement_metrics, features,
+	COALESCE(owner_id, ''), is_pinned, expiry_override
+`
+
 // GetSpace retrieves a space by ID
 func (s *SpaceStore) GetSpace(ctx context.Context, id string) (*space.Space, error) {
-	query := `
-		SELECT
-			id, title, description, trend_id, template_type::text, lifecycle_stage::text,
-			created_at, last_active, expires_at, user_count, message_count,
-			ST_X(location::geometry) as lng, ST_Y(location::geometry) as lat,
-			location_radius, is_geo_local,
-			topic_tags, related_spaces, engagement_metrics, features
-		FROM spaces
-		WHERE id = $1
-	`
+	query := `SELECT ` + spaceColumns + ` FROM spaces WHERE id = $1`
+
+	sp, err := scanSpace(s.db.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, space.ErrSpaceNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error querying space: %w", err)
+	}
+
+	return sp, nil
+}
 
+// GetSpaces retrieves several spaces by ID in one query. Spaces that do not
+// exist are left out.
+func (s *SpaceStore) GetSpaces(ctx context.Context, ids []string) ([]space.Space, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	query := `SELECT ` + spaceColumns + ` FROM spaces WHERE id = ANY($1)`
+
+	rows, err := s.db.Query(ctx, query, ids)
+	if err != nil {
+		return nil, fmt.Errorf("error executing query: %w", err)
+	}
+	defer rows.Close()
+
+	var spaces []space.Space
+	for rows.Next() {
+		sp, err := scanSpace(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning space: %w", err)
+		}
+		spaces = append(spaces, *sp)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating spaces: %w", err)
+	}
+
+	return spaces, nil
+}
+
+// scanSpace scans a row of spaceColumns
+func scanSpace(row pgx.Row) (*space.Space, error) {
 	var sp space.Space
 	var templateType, lifecycleStage string
 	var lng, lat *float64
 	var metricsJSON, featuresJSON []byte
 
-	err := s.db.QueryRow(ctx, query, id).Scan(
+	err := row.Scan(
 		&sp.ID,
 		&sp.Title,
 		&sp.Description,
@@ -148,10 +203,12 @@ func (s *SpaceStore) GetSpace(ctx context.Context, id string) (*space.Space, err
 		&sp.RelatedSpaces,
 		&metricsJSON,
 		&featuresJSON,
+		&sp.OwnerID,
+		&sp.IsPinned,
+		&sp.ExpiryOverride,
 	)
-
 	if err != nil {
-		return nil, fmt.Errorf("error querying space: %w", err)
+		return nil, err
 	}
 
 	// Set location if coordinates are present
@@ -185,11 +242,11 @@ func (s *SpaceStore) FindSpaces(ctx context.Context, filter space.SpaceFilter) (
 	queryBuilder := strings.Builder{}
 	queryBuilder.WriteString(`
 		SELECT
-			id, title, description, trend_id, template_type::text, lifecycle_stage::text,
+			id, title, description, COALESCE(trend_id, ''), template_type, lifecycle_stage::text,
 			created_at, last_active, user_count, message_count,
 			ST_X(location::geometry) as lng, ST_Y(location::geometry) as lat,
 			location_radius, is_geo_local,
-			topic_tags
+			topic_tags, COALESCE(owner_id, ''), is_pinned, expires_at
 		FROM spaces
 		WHERE 1=1
 	`)
@@ -221,7 +278,7 @@ func (s *SpaceStore) FindSpaces(ctx context.Context, filter space.SpaceFilter) (
 			if i > 0 {
 				queryBuilder.WriteString(", ")
 			}
-			queryBuilder.WriteString(fmt.Sprintf("$%d::template_type", argIndex))
+			queryBuilder.WriteString(fmt.Sprintf("$%d", argIndex))
 			args = append(args, string(templateType))
 			argIndex++
 		}
@@ -290,7 +347,7 @@ func (s *SpaceStore) FindSpaces(ctx context.Context, filter space.SpaceFilter) (
 	}
 
 	// Add ordering, limit and offset
-	queryBuilder.WriteString(" ORDER BY created_at DESC")
+	queryBuilder.WriteString(" ORDER BY is_pinned DESC, created_at DESC")
 
 	if filter.Limit > 0 {
 		queryBuilder.WriteString(fmt.Sprintf(" LIMIT $%d", argIndex))
@@ -335,6 +392,9 @@ func (s *SpaceStore) FindSpaces(ctx context.Context, filter space.SpaceFilter) (
 			&sp.LocationRadius,
 			&sp.IsGeoLocal,
 			&sp.TopicTags,
+			&sp.OwnerID,
+			&sp.IsPinned,
+			&sp.ExpiresAt,
 		)
 
 		if err != nil {
@@ -372,7 +432,7 @@ func (s *SpaceStore) FindNearbySpaces(
 ) ([]space.Space, error) {
 	query := `
 		SELECT
-			id, title, description, trend_id, template_type::text, lifecycle_stage::text,
+			id, title, description, COALESCE(trend_id, ''), template_type, lifecycle_stage::text,
 			created_at, last_active, user_count, message_count,
 			ST_X(location::geometry) as lng, ST_Y(location::geometry) as lat,
 			location_radius, is_geo_local,
@@ -0,0 +1,249 @@
+// internal/adapter/storage/timeline_store.go
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"essg/internal/domain/space"
+)
+
+// TimelineStore implements storage for space timelines
+type TimelineStore struct {
+	db *pgxpool.Pool
+}
+
+// NewTimelineStore creates a new timeline store
+func NewTimelineStore(db *pgxpool.Pool) *TimelineStore {
+	return &TimelineStore{
+		db: db,
+	}
+}
+
+// timelineColumns are the columns scanned by scanTimelineEntry
+const timelineColumns = `
+	id, space_id, status, content, COALESCE(source_url, ''), COALESCE(source_platform, ''),
+	COALESCE(source_key, ''), COALESCE(author_identity_id, ''), occurred_at, created_at, pinned_at
+`
+
+// FindMemberIdentity returns the ephemeral identity a current member uses in a space
+func (s *TimelineStore) FindMemberIdentity(ctx context.Context, spaceID, userID string) (string, error) {
+	return findMemberIdentity(ctx, s.db, spaceID, userID)
+}
+
+// FindLiveTrendSpaces returns the IDs of spaces created for a trend that have not dissolved
+func (s *TimelineStore) FindLiveTrendSpaces(ctx context.Context, trendID string) ([]string, error) {
+	rows, err := s.db.Query(
+		ctx,
+		`SELECT id FROM spaces WHERE trend_id = $1 AND lifecycle_stage <> 'dissolved'`,
+		trendID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error executing query: %w", err)
+	}
+	defer rows.Close()
+
+	var spaceIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("error scanning space ID: %w", err)
+		}
+		spaceIDs = append(spaceIDs, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating trend spaces: %w", err)
+	}
+
+	return spaceIDs, nil
+}
+
+// SaveEntry stores a new timeline entry
+func (s *TimelineStore) SaveEntry(ctx context.Context, e space.TimelineEntry) error {
+	_, err := s.db.Exec(
+		ctx,
+		`INSERT INTO timeline_entries (
+			id, space_id, status, content, source_url, source_platform,
+			source_key, author_identity_id, occurred_at, created_at, pinned_at
+		) VALUES (
+			$1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''),
+			NULLIF($7, ''), NULLIF($8, ''), $9, $10, $11
+		)`,
+		e.ID,
+		e.SpaceID,
+		string(e.Status),
+		e.Content,
+		e.SourceURL,
+		e.SourcePlatform,
+		e.SourceKey,
+		e.AuthorIdentityID,
+		e.OccurredAt,
+		e.CreatedAt,
+		e.PinnedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error inserting timeline entry: %w", err)
+	}
+
+	return nil
+}
+
+// SaveSuggestion stores a suggested entry unless its source was already
+// suggested for the space, reporting whether it was stored
+func (s *TimelineStore) SaveSuggestion(ctx context.Context, e space.TimelineEntry) (bool, error) {
+	tag, err := s.db.Exec(
+		ctx,
+		`INSERT INTO timeline_entries (
+			id, space_id, status, content, source_url, source_platform,
+			source_key, occurred_at, created_at
+		) VALUES (
+			$1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''),
+			$7, $8, $9
+		)
+		ON CONFLICT (space_id, source_key) DO NOTHING`,
+		e.ID,
+		e.SpaceID,
+		string(space.TimelineSuggested),
+		e.Content,
+		e.SourceURL,
+		e.SourcePlatform,
+		e.SourceKey,
+		e.OccurredAt,
+		e.CreatedAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("error inserting timeline suggestion: %w", err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// PinSuggestion pins a suggested entry, replacing its content when given
+func (s *TimelineStore) PinSuggestion(
+	ctx context.Context,
+	spaceID, entryID, content, authorIdentityID string,
+) (*space.TimelineEntry, error) {
+	row := s.db.QueryRow(
+		ctx,
+		`UPDATE timeline_entries
+		SET
+			status = $3,
+			content = COALESCE(NULLIF($4, ''), content),
+			author_identity_id = NULLIF($5, ''),
+			pinned_at = NOW()
+		WHERE space_id = $1 AND id = $2 AND status = $6
+		RETURNING `+timelineColumns,
+		spaceID, entryID, string(space.TimelinePinned), content, authorIdentityID,
+		string(space.TimelineSuggested),
+	)
+
+	entry, err := scanTimelineEntry(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, space.ErrEntryNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error pinning suggestion: %w", err)
+	}
+
+	return entry, nil
+}
+
+// DismissSuggestion marks a suggested entry as dismissed
+func (s *TimelineStore) DismissSuggestion(ctx context.Context, spaceID, entryID string) error {
+	tag, err := s.db.Exec(
+		ctx,
+		`UPDATE timeline_entries SET status = $3
+		WHERE space_id = $1 AND id = $2 AND status = $4`,
+		spaceID, entryID, string(space.TimelineDismissed), string(space.TimelineSuggested),
+	)
+	if err != nil {
+		return fmt.Errorf("error dismissing suggestion: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return space.ErrEntryNotFound
+	}
+
+	return nil
+}
+
+// FindPinnedEntries retrieves a space's pinned entries since a time, in the order they happened
+func (s *TimelineStore) FindPinnedEntries(ctx context.Context, spaceID string, since time.Time) ([]space.TimelineEntry, error) {
+	return s.queryEntries(
+		ctx,
+		`SELECT `+timelineColumns+` FROM timeline_entries
+		WHERE space_id = $1 AND status = $2 AND pinned_at > $3
+		ORDER BY occurred_at ASC, pinned_at ASC`,
+		spaceID, string(space.TimelinePinned), since,
+	)
+}
+
+// FindSuggestions retrieves a space's pending suggestions, newest first
+func (s *TimelineStore) FindSuggestions(ctx context.Context, spaceID string, limit int) ([]space.TimelineEntry, error) {
+	return s.queryEntries(
+		ctx,
+		`SELECT `+timelineColumns+` FROM timeline_entries
+		WHERE space_id = $1 AND status = $2
+		ORDER BY created_at DESC
+		LIMIT $3`,
+		spaceID, string(space.TimelineSuggested), limit,
+	)
+}
+
+// queryEntries runs a timeline query and scans the entries it returns
+func (s *TimelineStore) queryEntries(ctx context.Context, query string, args ...interface{}) ([]space.TimelineEntry, error) {
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error executing query: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []space.TimelineEntry
+	for rows.Next() {
+		entry, err := scanTimelineEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning timeline entry: %w", err)
+		}
+		entries = append(entries, *entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating timeline entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+// scanTimelineEntry scans a row selected with timelineColumns
+func scanTimelineEntry(row pgx.Row) (*space.TimelineEntry, error) {
+	var entry space.TimelineEntry
+	var status string
+
+	err := row.Scan(
+		&entry.ID,
+		&entry.SpaceID,
+		&status,
+		&entry.Content,
+		&entry.SourceURL,
+		&entry.SourcePlatform,
+		&entry.SourceKey,
+		&entry.AuthorIdentityID,
+		&entry.OccurredAt,
+		&entry.CreatedAt,
+		&entry.PinnedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.Status = space.TimelineStatus(status)
+
+	return &entry, nil
+}
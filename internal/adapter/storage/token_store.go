@@ -0,0 +1,104 @@
+// internal/adapter/storage/token_store.go
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// TokenStore implements storage for issued authentication tokens
+type TokenStore struct {
+	db *pgxpool.Pool
+}
+
+// NewTokenStore creates a new token store
+func NewTokenStore(db *pgxpool.Pool) *TokenStore {
+	return &TokenStore{
+		db: db,
+	}
+}
+
+// SaveToken records an issued token, creating its user if needed
+func (s *TokenStore) SaveToken(ctx context.Context, tokenID, userID string, createdAt, expiresAt time.Time) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Make sure the user exists
+	_, err = tx.Exec(
+		ctx,
+		`INSERT INTO users (id, created_at, last_seen) VALUES ($1, NOW(), NOW())
+		ON CONFLICT (id) DO UPDATE SET last_seen = NOW()`,
+		userID,
+	)
+	if err != nil {
+		return fmt.Errorf("error ensuring user: %w", err)
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		`INSERT INTO tokens (token, user_id, created_at, expires_at) VALUES ($1, $2, $3, $4)`,
+		tokenID, userID, createdAt, expiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error inserting token: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetTokenUser returns the user an unexpired token was issued to, reporting
+// false when the token has been revoked or has expired
+func (s *TokenStore) GetTokenUser(ctx context.Context, tokenID string) (string, bool, error) {
+	var userID string
+
+	err := s.db.QueryRow(
+		ctx,
+		`SELECT user_id FROM tokens WHERE token = $1 AND expires_at > NOW()`,
+		tokenID,
+	).Scan(&userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("error querying token: %w", err)
+	}
+
+	return userID, true, nil
+}
+
+// DeleteToken removes an issued token
+func (s *TokenStore) DeleteToken(ctx context.Context, tokenID string) error {
+	if _, err := s.db.Exec(ctx, `DELETE FROM tokens WHERE token = $1`, tokenID); err != nil {
+		return fmt.Errorf("error deleting token: %w", err)
+	}
+	return nil
+}
+
+// DeleteUserTokens removes every token issued to a user
+func (s *TokenStore) DeleteUserTokens(ctx context.Context, userID string) error {
+	if _, err := s.db.Exec(ctx, `DELETE FROM tokens WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("error deleting user tokens: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpiredTokens removes tokens past their expiry
+func (s *TokenStore) DeleteExpiredTokens(ctx context.Context) error {
+	if _, err := s.db.Exec(ctx, `SELECT cleanup_expired_tokens()`); err != nil {
+		return fmt.Errorf("error cleaning up expired tokens: %w", err)
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"strconv"
 	"strings"
@@ -31,6 +32,8 @@ type ServerConfig struct {
 	WriteTimeout    time.Duration
 	ShutdownTimeout time.Duration
 	CorsOrigins     []string
+	AdminToken      string
+	TrustedProxies  []string // CIDRs of proxies whose forwarded client addresses are believed
 }
 
 // DatabaseConfig holds database configuration
@@ -68,8 +71,38 @@ type TrendConfig struct {
 type SpaceConfig struct {
 	EventsTopic         string
 	DefaultGracePeriod  time.Duration
+	CountdownInterval   time.Duration
 	MonitoringInterval  time.Duration
+	EWMAHalfLife        time.Duration
+	EWMAReactionWeight  float64
+	ToxicityThreshold   float64
+	ToxicityMinMessages int
+	LocalRatioSample    float64
 	MaxConcurrentSpaces int
+	AdmissionPolicy     string
+	MaxQueuedTrends     int
+	QueuedTrendTTL      time.Duration
+	EvictionGracePeriod time.Duration
+	EvictionMaxScore    float64
+	RelatedRefresh      time.Duration
+	MaxRelatedSpaces    int
+	RelatedMinScore     float64
+	RelatedProximityKm  float64
+	ArchiveEnabled      bool
+	ArchiveTopMessages  int
+	ArchiveCurveBucket  time.Duration
+	AnalyticsBucket     time.Duration
+	AnalyticsMaxBuckets int
+	ActiveWindow        time.Duration
+	PresenceIdleAfter   time.Duration
+	PresenceHeartbeat   time.Duration
+	TypingTimeout       time.Duration
+	TypingDebounce      time.Duration
+	TemplatesDir        string
+	TemplatesReload     time.Duration
+	PollMaxOptions      int
+	PollSweepInterval   time.Duration
+	TimelineSuggestions int
 }
 
 // GeoConfig holds geospatial service configuration
@@ -79,20 +112,29 @@ type GeoConfig struct {
 	MaxRadius                   float64
 	ClusterThreshold            float64
 	PopulationDensityThresholds map[string]float64
+	PopulationDataset           string // ESRI ASCII grid of population counts
 }
 
 // IdentityConfig holds identity service configuration
 type IdentityConfig struct {
 	TokenSecret            string
 	TokenExpiry            time.Duration
+	GuestTokenExpiry       time.Duration
+	GuestLimit             int
+	GuestLimitWindow       time.Duration
+	PersonaSecret          string
 	DefaultLocationSharing string
 	DefaultAnonymity       bool
+	TrustCarryOver         bool
 }
 
 // MessagingConfig holds messaging service configuration
 type MessagingConfig struct {
 	MessageLimit       int
 	RateLimitWindow    time.Duration
+	RateLimit          int
+	GuestRateLimit     int
+	TrustedRateLimit   int
 	MaxMessageLength   int
 	MessageRetention   time.Duration
 	MonitoringInterval time.Duration
@@ -109,6 +151,8 @@ func Load() (Config, error) {
 			WriteTimeout:    getEnvAsDuration("SERVER_WRITE_TIMEOUT", 10*time.Second),
 			ShutdownTimeout: getEnvAsDuration("SERVER_SHUTDOWN_TIMEOUT", 10*time.Second),
 			CorsOrigins:     getEnvAsSlice("SERVER_CORS_ORIGINS", []string{"*"}),
+			AdminToken:      getEnv("SERVER_ADMIN_TOKEN", ""),
+			TrustedProxies:  getEnvAsSlice("SERVER_TRUSTED_PROXIES", nil),
 		},
 		Database: DatabaseConfig{
 			Host:         getEnv("DB_HOST", "localhost"),
@@ -138,8 +182,38 @@ func Load() (Config, error) {
 		Space: SpaceConfig{
 			EventsTopic:         getEnv("SPACE_EVENTS_TOPIC", "space"),
 			DefaultGracePeriod:  getEnvAsDuration("SPACE_DEFAULT_GRACE_PERIOD", 24*time.Hour),
+			CountdownInterval:   getEnvAsDuration("SPACE_COUNTDOWN_INTERVAL", time.Minute),
 			MonitoringInterval:  getEnvAsDuration("SPACE_MONITORING_INTERVAL", 1*time.Minute),
+			EWMAHalfLife:        getEnvAsDuration("SPACE_EWMA_HALF_LIFE", 10*time.Minute),
+			EWMAReactionWeight:  getEnvAsFloat("SPACE_EWMA_REACTION_WEIGHT", 0.5),
+			ToxicityThreshold:   getEnvAsFloat("SPACE_TOXICITY_THRESHOLD", 0.3),
+			ToxicityMinMessages: getEnvAsInt("SPACE_TOXICITY_MIN_MESSAGES", 10),
+			LocalRatioSample:    getEnvAsFloat("SPACE_LOCAL_RATIO_SAMPLE", 10),
 			MaxConcurrentSpaces: getEnvAsInt("SPACE_MAX_CONCURRENT_SPACES", 1000),
+			AdmissionPolicy:     getEnv("SPACE_ADMISSION_POLICY", "queue"),
+			MaxQueuedTrends:     getEnvAsInt("SPACE_MAX_QUEUED_TRENDS", 100),
+			QueuedTrendTTL:      getEnvAsDuration("SPACE_QUEUED_TREND_TTL", 2*time.Hour),
+			EvictionGracePeriod: getEnvAsDuration("SPACE_EVICTION_GRACE_PERIOD", 15*time.Minute),
+			EvictionMaxScore:    getEnvAsFloat("SPACE_EVICTION_MAX_SCORE", 30.0),
+			RelatedRefresh:      getEnvAsDuration("SPACE_RELATED_REFRESH", 5*time.Minute),
+			MaxRelatedSpaces:    getEnvAsInt("SPACE_MAX_RELATED_SPACES", 10),
+			RelatedMinScore:     getEnvAsFloat("SPACE_RELATED_MIN_SCORE", 0.15),
+			RelatedProximityKm:  getEnvAsFloat("SPACE_RELATED_PROXIMITY_KM", 25.0),
+			ArchiveEnabled:      getEnvAsBool("SPACE_ARCHIVE_ENABLED", false),
+			ArchiveTopMessages:  getEnvAsInt("SPACE_ARCHIVE_TOP_MESSAGES", 10),
+			ArchiveCurveBucket:  getEnvAsDuration("SPACE_ARCHIVE_CURVE_BUCKET", 15*time.Minute),
+			AnalyticsBucket:     getEnvAsDuration("SPACE_ANALYTICS_BUCKET", 5*time.Minute),
+			AnalyticsMaxBuckets: getEnvAsInt("SPACE_ANALYTICS_MAX_BUCKETS", 1000),
+			ActiveWindow:        getEnvAsDuration("SPACE_ACTIVE_WINDOW", 5*time.Minute),
+			PresenceIdleAfter:   getEnvAsDuration("SPACE_PRESENCE_IDLE_AFTER", 5*time.Minute),
+			PresenceHeartbeat:   getEnvAsDuration("SPACE_PRESENCE_HEARTBEAT", 15*time.Second),
+			TypingTimeout:       getEnvAsDuration("SPACE_TYPING_TIMEOUT", 6*time.Second),
+			TypingDebounce:      getEnvAsDuration("SPACE_TYPING_DEBOUNCE", 2*time.Second),
+			TemplatesDir:        getEnv("SPACE_TEMPLATES_DIR", ""),
+			TemplatesReload:     getEnvAsDuration("SPACE_TEMPLATES_RELOAD", 30*time.Second),
+			PollMaxOptions:      getEnvAsInt("SPACE_POLL_MAX_OPTIONS", 10),
+			PollSweepInterval:   getEnvAsDuration("SPACE_POLL_SWEEP_INTERVAL", 15*time.Second),
+			TimelineSuggestions: getEnvAsInt("SPACE_TIMELINE_MAX_SUGGESTIONS", 50),
 		},
 		Geo: GeoConfig{
 			DefaultRadius:    getEnvAsFloat("GEO_DEFAULT_RADIUS", 5.0),
@@ -151,16 +225,25 @@ func Load() (Config, error) {
 				"suburban": getEnvAsFloat("GEO_DENSITY_SUBURBAN", 1000.0),
 				"rural":    getEnvAsFloat("GEO_DENSITY_RURAL", 100.0),
 			},
+			PopulationDataset: getEnv("GEO_POPULATION_DATASET", ""),
 		},
 		Identity: IdentityConfig{
 			TokenSecret:            getEnv("IDENTITY_TOKEN_SECRET", "your-secret-key"),
 			TokenExpiry:            getEnvAsDuration("IDENTITY_TOKEN_EXPIRY", 24*time.Hour),
+			GuestTokenExpiry:       getEnvAsDuration("IDENTITY_GUEST_TOKEN_EXPIRY", 2*time.Hour),
+			GuestLimit:             getEnvAsInt("IDENTITY_GUEST_LIMIT", 5),
+			GuestLimitWindow:       getEnvAsDuration("IDENTITY_GUEST_LIMIT_WINDOW", time.Hour),
+			PersonaSecret:          getEnv("IDENTITY_PERSONA_SECRET", ""),
 			DefaultLocationSharing: getEnv("IDENTITY_DEFAULT_LOCATION_SHARING", "neighborhood"),
 			DefaultAnonymity:       getEnvAsBool("IDENTITY_DEFAULT_ANONYMITY", true),
+			TrustCarryOver:         getEnvAsBool("IDENTITY_TRUST_CARRYOVER", true),
 		},
 		Messaging: MessagingConfig{
 			MessageLimit:       getEnvAsInt("MESSAGING_MESSAGE_LIMIT", 100),
 			RateLimitWindow:    getEnvAsDuration("MESSAGING_RATE_LIMIT_WINDOW", 1*time.Minute),
+			RateLimit:          getEnvAsInt("MESSAGING_RATE_LIMIT", 30),
+			GuestRateLimit:     getEnvAsInt("MESSAGING_GUEST_RATE_LIMIT", 5),
+			TrustedRateLimit:   getEnvAsInt("MESSAGING_TRUSTED_RATE_LIMIT", 60),
 			MaxMessageLength:   getEnvAsInt("MESSAGING_MAX_MESSAGE_LENGTH", 1000),
 			MessageRetention:   getEnvAsDuration("MESSAGING_MESSAGE_RETENTION", 30*24*time.Hour),
 			MonitoringInterval: getEnvAsDuration("MESSAGING_MONITORING_INTERVAL", 1*time.Minute),
@@ -176,6 +259,17 @@ func validate(config Config) error {
 		return fmt.Errorf("token secret must be set in non-development environments")
 	}
 
+	for _, cidr := range config.Server.TrustedProxies {
+		if _, _, err := net.ParseCIDR(strings.TrimSpace(cidr)); err != nil {
+			return fmt.Errorf("invalid SERVER_TRUSTED_PROXIES entry %q: %w", cidr, err)
+		}
+	}
+
+	// A zero half-life would make every EWMA engagement metric NaN
+	if config.Space.EWMAHalfLife <= 0 {
+		return fmt.Errorf("SPACE_EWMA_HALF_LIFE must be positive")
+	}
+
 	return nil
 }
 
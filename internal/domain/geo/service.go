@@ -4,11 +4,15 @@ package geo
 
 import (
 	"context"
+	"errors"
 
 	"essg/internal/domain/space"
 	"essg/internal/domain/trend"
 )
 
+// ErrNoPopulationData is returned when no population data covers an area
+var ErrNoPopulationData = errors.New("no population data for area")
+
 // LocationContext provides additional information about a geographic location
 type LocationContext struct {
 	PlaceID       string
@@ -62,9 +66,6 @@ type Service interface {
 	// GetLocationContext returns context information for a location
 	GetLocationContext(ctx context.Context, location trend.Location) (*LocationContext, error)
 
-	// FuzzLocation reduces the precision of a location for privacy
-	FuzzLocation(location trend.Location, precisionLevel string) trend.Location
-
 	// CalculateDistance calculates the distance between two locations
 	CalculateDistance(a, b trend.Location) float64
 
@@ -83,15 +84,3 @@ type Service interface {
 	// AddLocalSource adds a source of local information
 	AddLocalSource(source LocalSource) error
 }
-
-// GeoPrivacyManager manages location privacy
-type GeoPrivacyManager interface {
-	// ApplyPrivacySettings applies privacy settings to a location
-	ApplyPrivacySettings(location trend.Location, privacySetting string, contextRadius float64) trend.Location
-
-	// GetPrivacyLevels returns available privacy levels
-	GetPrivacyLevels() []string
-
-	// ValidatePrivacySetting checks if a privacy setting is valid
-	ValidatePrivacySetting(setting string) bool
-}
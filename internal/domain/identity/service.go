@@ -4,11 +4,24 @@ package identity
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"essg/internal/domain/trend"
 )
 
+// Common errors
+var (
+	ErrInvalidToken     = errors.New("invalid token")
+	ErrTokenExpired     = errors.New("token expired")
+	ErrTokenRevoked     = errors.New("token revoked")
+	ErrUserNotFound     = errors.New("user not found")
+	ErrIdentityNotFound = errors.New("ephemeral identity not found")
+	ErrNotGuest         = errors.New("user is not a guest")
+	ErrTooManyGuests    = errors.New("too many guest sessions")
+	ErrNicknameTaken    = errors.New("nickname already taken in space")
+)
+
 // LocationSharingLevel defines how precisely a user's location is shared
 type LocationSharingLevel string
 
@@ -29,8 +42,26 @@ type User struct {
 	LocationSharingPreference LocationSharingLevel
 	DefaultAnonymity          bool
 	NotificationPreferences   map[string]bool
+	IsGuest                   bool // Anonymous session with no linked accounts
 }
 
+// Reputation dimensions tracked per ephemeral identity
+const (
+	ReputationHelpfulness = "helpfulness" // Reactions others gave the identity's messages
+	ReputationReplies     = "replies"     // Replies others made to the identity's messages
+	ReputationStrikes     = "strikes"     // Moderation strikes
+)
+
+// TrustTier is a coarse standing derived from reputation
+type TrustTier string
+
+const (
+	TrustRestricted  TrustTier = "restricted"
+	TrustNew         TrustTier = "new"
+	TrustEstablished TrustTier = "established"
+	TrustTrusted     TrustTier = "trusted"
+)
+
 // EphemeralIdentity represents a temporary identity in a specific space
 type EphemeralIdentity struct {
 	ID                 string
@@ -46,6 +77,21 @@ type EphemeralIdentity struct {
 	Reputation         map[string]float64 // Different dimensions of reputation
 }
 
+// Persona is the generated public face of an ephemeral identity
+type Persona struct {
+	Nickname string
+	Avatar   string
+}
+
+// PersonaGenerator creates per-space personas that are stable for a user within
+// a space but cannot be correlated across spaces
+type PersonaGenerator interface {
+	// GeneratePersona returns the persona for a user in a space. Attempt 0 is
+	// the user's usual persona; later attempts derive alternatives for when its
+	// nickname is already taken in the space.
+	GeneratePersona(userID, spaceID string, attempt int) Persona
+}
+
 // Service defines the interface for identity services
 type Service interface {
 	// GetOrCreateUser gets an existing user or creates a new one
@@ -70,6 +116,41 @@ type Service interface {
 	GetIdentitiesInSpace(ctx context.Context, spaceID string) ([]EphemeralIdentity, error)
 }
 
+// ReputationManager tracks the reputation of ephemeral identities
+type ReputationManager interface {
+	// RecordStrike records a moderation strike against an identity in a space
+	RecordStrike(ctx context.Context, spaceID, identityID string) (*EphemeralIdentity, error)
+
+	// GetTrustTier returns the trust tier a user holds in a space
+	GetTrustTier(ctx context.Context, userID, spaceID string) (TrustTier, error)
+}
+
+// Session is a user and the token they authenticate with
+type Session struct {
+	User  *User
+	Token string
+}
+
+// GuestManager manages anonymous guest sessions
+type GuestManager interface {
+	// CreateGuestSession creates a guest user and issues them a short-lived
+	// token, unless the client has already been issued too many
+	CreateGuestSession(ctx context.Context, clientKey string) (*Session, error)
+
+	// UpgradeGuest links a platform account to a guest, keeping their identities in live spaces
+	UpgradeGuest(ctx context.Context, guestID, platformID, platform string) (*Session, error)
+}
+
+// TokensRevokedSubject is the event bus subject on which revocations of all a
+// user's tokens are announced, so open connections can be closed
+const TokensRevokedSubject = "identity.tokens_revoked"
+
+// TokensRevokedEvent announces that every token issued to a user was revoked
+type TokensRevokedEvent struct {
+	UserID    string    `json:"user_id"`
+	RevokedAt time.Time `json:"revoked_at"`
+}
+
 // TokenManager handles authentication tokens
 type TokenManager interface {
 	// GenerateToken generates a token for a user
@@ -81,7 +162,7 @@ type TokenManager interface {
 	// RevokeToken revokes a token
 	RevokeToken(token string) error
 
-	// RevokeAllForUser revokes all tokens for a user
+	// RevokeAllForUser revokes all tokens for a user, announcing it on TokensRevokedSubject
 	RevokeAllForUser(userID string) error
 }
 
@@ -100,11 +181,19 @@ type PrivacyConfig interface {
 	GetDataRetentionPolicy() map[string]interface{}
 }
 
+// LocationScope identifies whose location is being shared and where
+type LocationScope struct {
+	UserID  string
+	SpaceID string
+}
+
 // LocationPrivacyManager manages location privacy
 type LocationPrivacyManager interface {
-	// ApplyPrivacySettings applies privacy settings to a location
-	ApplyPrivacySettings(location trend.Location, preference LocationSharingLevel, contextRadius float64) *trend.Location
+	// ApplyPrivacySettings reduces a location to the precision a sharing
+	// preference allows, returning nil when sharing is disabled. The result is
+	// stable for a user within a scope, so repeated reads reveal nothing more.
+	ApplyPrivacySettings(location trend.Location, preference LocationSharingLevel, scope LocationScope) *trend.Location
 
-	// GetLocationPrecisionLevel returns the precision level for a sharing preference
+	// GetLocationPrecisionLevel returns the precision in meters for a sharing preference
 	GetLocationPrecisionLevel(preference LocationSharingLevel) float64
 }
@@ -4,6 +4,7 @@ package messaging
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"essg/internal/domain/geo"
@@ -11,6 +12,11 @@ import (
 	"essg/internal/domain/trend"
 )
 
+// Domain errors
+var (
+	ErrMessageNotFound = errors.New("message not found")
+)
+
 // MessageType defines the type of message
 type MessageType string
 
@@ -88,6 +94,22 @@ type Service interface {
 	EnrichWithGeoContext(ctx context.Context, message Message) (Message, error)
 }
 
+// History stores the messages and reactions sent to spaces, so they are kept
+// before anyone is told they were sent
+type History interface {
+	// SaveMessage stores a message, returning the stored message, which is
+	// the one first sent when a message with the same ID is resent, and
+	// whether it was newly stored
+	SaveMessage(ctx context.Context, message Message) (*Message, bool, error)
+
+	// SaveReaction stores a reaction to a message in a space, reporting false
+	// if the user had already given it
+	SaveReaction(ctx context.Context, spaceID, messageID, userID, reaction string, at time.Time) (bool, error)
+
+	// GetRecentMessages returns a space's latest messages with their reaction counts, oldest first
+	GetRecentMessages(ctx context.Context, spaceID string, limit int) ([]Message, error)
+}
+
 // MessageFilter defines criteria for filtering messages
 type MessageFilter struct {
 	Types         []MessageType
@@ -100,6 +122,22 @@ type MessageFilter struct {
 	Offset        int
 }
 
+// RateTier groups users that share rate limits
+type RateTier string
+
+const (
+	TierGuest   RateTier = "guest"
+	TierMember  RateTier = "member"
+	TierTrusted RateTier = "trusted"
+)
+
+// Rate-limited action types
+const (
+	ActionMessage  = "message"
+	ActionReaction = "reaction"
+	ActionPoll     = "poll"
+)
+
 // RateLimiter defines rate limiting for messaging
 type RateLimiter interface {
 	// CheckLimit checks if an action exceeds rate limits
@@ -110,6 +148,10 @@ type RateLimiter interface {
 
 	// GetRemainingLimit gets remaining actions allowed
 	GetRemainingLimit(userID, actionType, resourceID string) (int, time.Time, error)
+
+	// AllowAction records an action and reports whether it was within the
+	// limit, in one atomic step, and when the limit resets
+	AllowAction(userID, actionType, resourceID string) (bool, time.Time, error)
 }
 
 // MessageProcessor handles content processing for messages
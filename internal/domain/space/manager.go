@@ -6,6 +6,7 @@ import (
 	"context"
 	"time"
 
+	"essg/internal/domain/identity"
 	"essg/internal/domain/trend"
 )
 
@@ -22,6 +23,24 @@ type Template interface {
 
 	// IsGeoAware returns true if this template supports location features
 	IsGeoAware() bool
+
+	// GetLifecycleThresholds returns the engagement scores that move spaces between stages
+	GetLifecycleThresholds() LifecycleThresholds
+
+	// GetGracePeriod returns how long spaces linger once dissolution begins, zero for the default
+	GetGracePeriod() time.Duration
+
+	// GetEngagementModel returns the name of the model that scores the template's spaces, empty for the default
+	GetEngagementModel() string
+}
+
+// EngagementModel scores how engaged a space is from its recent activity
+type EngagementModel interface {
+	// Name identifies the model in template definitions
+	Name() string
+
+	// Score returns engagement metrics for the activity, including an engagement_score from 0 to 100
+	Score(activity EngagementActivity) map[string]float64
 }
 
 // Manager defines the interface for space management
@@ -29,6 +48,21 @@ type Manager interface {
 	// CreateSpace creates a new ephemeral space from a detected trend
 	CreateSpace(ctx context.Context, trend trend.Trend) (*Space, error)
 
+	// CreateCustomSpace creates a user-initiated space from an explicit template
+	CreateCustomSpace(ctx context.Context, req CustomSpaceRequest) (*Space, error)
+
+	// UpdateSpace updates a space's curated details on behalf of its owner
+	UpdateSpace(ctx context.Context, spaceID, userID string, update SpaceUpdate) (*Space, error)
+
+	// PinSpace pins or unpins a space
+	PinSpace(ctx context.Context, spaceID string, pinned bool) error
+
+	// SetFeatureEnabled turns one of a space's declared features on or off
+	SetFeatureEnabled(ctx context.Context, spaceID, featureID string, enabled bool) (*Space, error)
+
+	// OverrideExpiry sets the time at which a space dissolves, ignoring engagement
+	OverrideExpiry(ctx context.Context, spaceID string, expiresAt time.Time) error
+
 	// GetSpace returns a space by ID
 	GetSpace(ctx context.Context, id string) (*Space, error)
 
@@ -70,15 +104,138 @@ type EngagementAnalyzer interface {
 	// AnalyzeEngagement calculates engagement metrics for a space
 	AnalyzeEngagement(ctx context.Context, spaceID string) (map[string]float64, error)
 
-	// DetermineLifecycleStage determines the appropriate lifecycle stage
-	DetermineLifecycleStage(ctx context.Context, space *Space) (LifecycleStage, error)
+	// AnalyzeSpaces calculates engagement metrics for many spaces at once
+	AnalyzeSpaces(ctx context.Context, spaces []*Space) ([]SpaceEngagement, error)
+
+	// RecordEngagement stores measured engagement as analytics and publishes it to clients
+	RecordEngagement(ctx context.Context, engagement []SpaceEngagement) error
+
+	// DetermineLifecycleStage determines the appropriate lifecycle stage from measured engagement
+	DetermineLifecycleStage(engagement SpaceEngagement) LifecycleStage
+
+	// ShouldDissolve determines from measured engagement if a space should begin dissolution
+	ShouldDissolve(engagement SpaceEngagement) bool
+}
+
+// RelationFinder defines the interface for discovering related spaces
+type RelationFinder interface {
+	// GetRelatedSpaces returns the spaces most closely related to a space
+	GetRelatedSpaces(ctx context.Context, spaceID string, limit int) ([]RelatedSpace, error)
+
+	// RefreshRelations recomputes the relations for a single space
+	RefreshRelations(ctx context.Context, spaceID string) error
+}
+
+// Archiver defines the interface for archiving dissolved spaces
+type Archiver interface {
+	// ArchiveSpace produces and stores an archive for a dissolved space
+	ArchiveSpace(ctx context.Context, s Space) (*Archive, error)
+
+	// GetArchive returns the archive for a space if it is still retained
+	GetArchive(ctx context.Context, spaceID string) (*Archive, error)
+}
+
+// AdmissionReporter exposes the decisions made by space admission control
+type AdmissionReporter interface {
+	// ListAdmissionDecisions returns recorded admission decisions, newest first
+	ListAdmissionDecisions(ctx context.Context, filter AdmissionFilter) ([]AdmissionDecision, error)
+
+	// GetAdmissionQueue returns the trends waiting for capacity, best first
+	GetAdmissionQueue(ctx context.Context) ([]QueuedTrend, error)
+}
+
+// AnalyticsReporter reports how engagement in a space has developed
+type AnalyticsReporter interface {
+	// GetAnalytics returns a space's engagement between two times, aggregated into buckets
+	GetAnalytics(ctx context.Context, spaceID string, from, to time.Time, bucket time.Duration) (*SpaceAnalytics, error)
+}
+
+// MembershipManager manages users joining and leaving spaces
+type MembershipManager interface {
+	// JoinSpace adds a user to a space, creating their ephemeral identity on first join
+	JoinSpace(ctx context.Context, spaceID, userID string) (*identity.EphemeralIdentity, error)
+
+	// LeaveSpace removes a user from a space, keeping their identity for past messages
+	LeaveSpace(ctx context.Context, spaceID, userID string) error
+
+	// GetMember returns the identity a current member uses in a space
+	GetMember(ctx context.Context, spaceID, userID string) (*identity.EphemeralIdentity, error)
+
+	// TouchPresence records that a member is currently present in a space
+	TouchPresence(ctx context.Context, spaceID, userID string) error
+
+	// GetOccupancy returns member and active counts for a space
+	GetOccupancy(ctx context.Context, spaceID string) (*Occupancy, error)
+}
+
+// PresenceTracker tracks the identities connected to spaces and which of them are typing
+type PresenceTracker interface {
+	// Connect records a connection from an identity, announcing it if it wasn't already present
+	Connect(ctx context.Context, ident identity.EphemeralIdentity) error
+
+	// Disconnect ends a connection, announcing the identity's departure once its last connection closes
+	Disconnect(ctx context.Context, ident identity.EphemeralIdentity) error
+
+	// Activity records that a connected identity is interacting
+	Activity(ctx context.Context, ident identity.EphemeralIdentity) error
+
+	// SetTyping records whether a connected identity is typing
+	SetTyping(ctx context.Context, ident identity.EphemeralIdentity, typing bool) error
+
+	// GetPresence returns the identities connected to a space
+	GetPresence(ctx context.Context, spaceID string) (*Presence, error)
+
+	// CountPresent counts the identities connected to a space
+	CountPresent(ctx context.Context, spaceID string) (int, error)
+
+	// CountPresentBySpace counts the identities connected to each of several spaces
+	CountPresentBySpace(ctx context.Context, spaceIDs []string) (map[string]int, error)
+}
+
+// EventLog keeps recent space events for clients catching up after a reconnect
+type EventLog interface {
+	// EventsSince returns a space's events after a position in the log, oldest first
+	EventsSince(ctx context.Context, spaceID string, afterSeq int64, limit int) ([]SpaceEvent, error)
+}
+
+// TemplateSelector chooses templates for trends
+type TemplateSelector interface {
+	// ExplainTemplateSelection scores every template against a trend and reports the choice
+	ExplainTemplateSelection(ctx context.Context, t trend.Trend) (*TemplateExplanation, error)
+}
+
+// PollManager manages polls in spaces with the polls feature
+type PollManager interface {
+	// CreatePoll starts a poll on behalf of a space member
+	CreatePoll(ctx context.Context, spaceID string, req PollRequest) (*Poll, error)
+
+	// GetPoll returns a poll with its current tallies
+	GetPoll(ctx context.Context, spaceID, pollID string) (*Poll, error)
+
+	// ListPolls returns a space's polls, newest first
+	ListPolls(ctx context.Context, spaceID string) ([]Poll, error)
+
+	// Vote records a member's vote, allowing one vote per ephemeral identity
+	Vote(ctx context.Context, spaceID, pollID, userID, optionID string) (*Poll, error)
+
+	// ClosePoll closes a poll early on behalf of its creator
+	ClosePoll(ctx context.Context, spaceID, pollID, userID string) (*Poll, error)
+}
+
+// Timeline manages the curated timelines of spaces with the timeline feature
+type Timeline interface {
+	// PinUpdate adds an update to a space's timeline
+	PinUpdate(ctx context.Context, spaceID string, curator Curator, update TimelineUpdate) (*TimelineEntry, error)
+
+	// AcceptSuggestion pins a suggested update, optionally rewording it
+	AcceptSuggestion(ctx context.Context, spaceID, entryID string, curator Curator, content string) (*TimelineEntry, error)
 
-	// ShouldDissolve determines if a space should begin dissolution
-	ShouldDissolve(ctx context.Context, space *Space) (bool, error)
+	// DismissSuggestion discards a suggested update
+	DismissSuggestion(ctx context.Context, spaceID, entryID string, curator Curator) error
 
-	// StartMonitoring begins monitoring a space's engagement
-	StartMonitoring(ctx context.Context, spaceID string) error
+	// GetTimeline returns a space's pinned updates since a time, oldest first
+	GetTimeline(ctx context.Context, spaceID string, since time.Time) ([]TimelineEntry, error)
 
-	// StopMonitoring stops monitoring a space's engagement
-	StopMonitoring(ctx context.Context, spaceID string) error
+	// ListSuggestions returns the updates waiting for a curator, newest first
+	ListSuggestions(ctx context.Context, spaceID string) ([]TimelineEntry, error)
 }
@@ -1,11 +1,35 @@
 package space
 
 import (
+	"errors"
 	"time"
 
 	"essg/internal/domain/trend"
 )
 
+// Common errors
+var (
+	ErrSpaceNotFound   = errors.New("space not found")
+	ErrArchiveNotFound = errors.New("archive not found")
+	ErrNotOwner        = errors.New("not the space owner")
+	ErrInvalidTemplate = errors.New("invalid template type")
+	ErrInvalidExpiry   = errors.New("invalid expiry")
+	ErrAtCapacity      = errors.New("space capacity reached")
+	ErrSpaceClosed     = errors.New("space is closed to new members")
+	ErrNotMember       = errors.New("user is not a member of the space")
+	ErrFeatureDisabled = errors.New("feature is not enabled in this space")
+	ErrUnknownFeature  = errors.New("feature is not available in this space")
+	ErrPollNotFound    = errors.New("poll not found")
+	ErrPollClosed      = errors.New("poll is closed")
+	ErrInvalidPoll     = errors.New("invalid poll")
+	ErrUnknownOption   = errors.New("poll option not found")
+	ErrAlreadyVoted    = errors.New("already voted in this poll")
+	ErrEntryNotFound   = errors.New("timeline entry not found")
+	ErrNotCurator      = errors.New("not allowed to curate this space")
+	ErrInvalidEntry    = errors.New("invalid timeline entry")
+	ErrInvalidRange    = errors.New("invalid analytics range")
+)
+
 // LifecycleStage represents the current stage in a space's lifecycle
 type LifecycleStage string
 
@@ -29,6 +53,17 @@ const (
 	TemplateLocal        TemplateType = "local"
 )
 
+// Feature IDs checked when users interact with a space. Templates may list
+// other features, such as proximity, that only change how clients display it.
+const (
+	FeatureMessaging  = "messaging"
+	FeatureReactions  = "reactions"
+	FeatureMedia      = "media"
+	FeatureGeoContext = "geo_context" // Messages keep their location; without it, locations are dropped
+	FeaturePolls      = "polls"
+	FeatureTimeline   = "timeline"
+)
+
 // Feature represents a feature enabled for a space
 type Feature struct {
 	ID          string
@@ -58,4 +93,363 @@ type Space struct {
 	TopicTags         []string
 	RelatedSpaces     []string
 	EngagementMetrics map[string]float64
+	OwnerID           string // Set for user-initiated spaces
+	IsPinned          bool
+	ExpiryOverride    bool                 // ExpiresAt was set by an admin and overrides engagement
+	Lifecycle         *LifecycleThresholds // Taken from the template when evaluated; not persisted
+}
+
+// HasFeature reports whether a feature is declared for the space and enabled
+func (s *Space) HasFeature(id string) bool {
+	for _, f := range s.Features {
+		if f.ID == id {
+			return f.IsEnabled
+		}
+	}
+	return false
+}
+
+// EnabledFeatures returns the IDs of the space's enabled features
+func (s *Space) EnabledFeatures() []string {
+	ids := make([]string, 0, len(s.Features))
+	for _, f := range s.Features {
+		if f.IsEnabled {
+			ids = append(ids, f.ID)
+		}
+	}
+	return ids
+}
+
+// LifecycleThresholds are the engagement scores at which a space changes stage
+type LifecycleThresholds struct {
+	PeakScore    float64 // Growing spaces above this reach their peak
+	WaningScore  float64 // Peaking spaces below this start to wane
+	RecoverScore float64 // Waning spaces above this return to their peak
+	DevolveScore float64 // Waning spaces below this begin to dissolve
+}
+
+// Relation links a space to an adjacent live space
+type Relation struct {
+	SpaceID        string
+	RelatedSpaceID string
+	Score          float64
+	Factors        map[string]float64 // Contribution of each relatedness signal
+	UpdatedAt      time.Time
+}
+
+// RelatedSpace is a space returned alongside its relatedness score
+type RelatedSpace struct {
+	Space   Space
+	Score   float64
+	Factors map[string]float64
+}
+
+// Archive is a compact, anonymized summary of a dissolved space
+type Archive struct {
+	SpaceID         string
+	Title           string
+	TrendID         string
+	TrendTopic      string
+	TemplateType    TemplateType
+	CreatedAt       time.Time
+	DissolvedAt     time.Time
+	DurationSeconds float64
+	PeakUsers       int
+	MessageCount    int
+	TopMessages     []ArchivedMessage
+	EngagementCurve []EngagementPoint
+	ArchivedAt      time.Time
+	ExpiresAt       time.Time
+}
+
+// ArchivedMessage is a message kept in an archive without any author details
+type ArchivedMessage struct {
+	Content        string
+	Reactions      map[string]int
+	TotalReactions int
+	PostedAt       time.Time
+}
+
+// EngagementPoint is a single bucket of a space's engagement curve
+type EngagementPoint struct {
+	Time            time.Time
+	EngagementScore float64
+	UserCount       int
+	ActiveUsers     int
+	MessageCount    int
+	LifecycleStage  LifecycleStage
+}
+
+// EngagementActivity is a space's recent activity as scored by engagement models
+type EngagementActivity struct {
+	At          time.Time          // When the activity is measured; recency is relative to this
+	Messages    []MessageActivity  // Most recent messages, newest first
+	Reactions   []ReactionActivity // Most recent reactions, newest first
+	ActiveUsers int
+	TotalUsers  int
+}
+
+// SpaceEngagement is a space's engagement as measured at one time, shared by
+// everything that acts on it so each metric is only computed once
+type SpaceEngagement struct {
+	Space       *Space
+	MeasuredAt  time.Time
+	ActiveUsers int
+	Metrics     map[string]float64
+}
+
+// MessageActivity is a message as seen by engagement models
+type MessageActivity struct {
+	CreatedAt time.Time
+	IsReply   bool
+	Content   string
+}
+
+// ReactionActivity is a reaction as seen by engagement models
+type ReactionActivity struct {
+	CreatedAt time.Time
+	UserID    string
+}
+
+// SpaceAnalytics is a space's engagement curve over a time range, alongside
+// the thresholds that move it between lifecycle stages
+type SpaceAnalytics struct {
+	SpaceID    string
+	Stage      LifecycleStage
+	Thresholds LifecycleThresholds
+	From       time.Time
+	To         time.Time
+	Bucket     time.Duration
+	Curve      []EngagementPoint
+}
+
+// CustomSpaceRequest describes a user-initiated space that has no originating trend
+type CustomSpaceRequest struct {
+	TemplateType   TemplateType
+	Title          string
+	Description    string
+	TopicTags      []string
+	Location       *trend.Location
+	LocationRadius float64
+	OwnerID        string
+}
+
+// SpaceUpdate describes changes to a space's curated details
+type SpaceUpdate struct {
+	Title       *string
+	Description *string
+	TopicTags   []string
+}
+
+// AdmissionOutcome describes what admission control decided for a trend
+type AdmissionOutcome string
+
+const (
+	AdmissionAdmitted AdmissionOutcome = "admitted"
+	AdmissionQueued   AdmissionOutcome = "queued"
+	AdmissionEvicted  AdmissionOutcome = "evicted"
+	AdmissionRejected AdmissionOutcome = "rejected"
+	AdmissionExpired  AdmissionOutcome = "expired"
+)
+
+// AdmissionDecision records why a trend did or did not get a space
+type AdmissionDecision struct {
+	ID             string
+	TrendID        string
+	TrendTopic     string
+	TrendScore     float64
+	Outcome        AdmissionOutcome
+	Reason         string
+	ActiveSpaces   int
+	Capacity       int
+	SpaceID        string // Space created on admission
+	EvictedSpaceID string // Space whose dissolution was accelerated to make room
+	DecidedAt      time.Time
+}
+
+// QueuedTrend is a trend waiting for capacity to get a space
+type QueuedTrend struct {
+	TrendID  string
+	Topic    string
+	Score    float64
+	QueuedAt time.Time
+}
+
+// AdmissionFilter defines criteria for filtering admission decisions
+type AdmissionFilter struct {
+	TrendID  string
+	Outcomes []AdmissionOutcome
+	Since    time.Time
+	Limit    int
+}
+
+// Occupancy summarizes the membership of a space
+type Occupancy struct {
+	SpaceID      string
+	Members      int           // Users currently joined
+	Active       int           // Members seen within the active window
+	ActiveWindow time.Duration // How recently a member must have been seen to count as active
+}
+
+// PresenceStatus describes how engaged a connected identity is
+type PresenceStatus string
+
+const (
+	PresenceActive PresenceStatus = "active"
+	PresenceIdle   PresenceStatus = "idle"
+)
+
+// Presence event types published on a space's presence topic
+const (
+	PresenceJoin   = "join"
+	PresenceLeave  = "leave"
+	PresenceIdled  = "idle"
+	PresenceReturn = "active"
+)
+
+// Lifecycle event types published on a space's lifecycle topic
+const (
+	LifecycleChanged   = "lifecycle" // The space moved to another stage
+	LifecycleCountdown = "countdown" // Periodic reminder of a pending dissolution
+	LifecycleDissolved = "dissolved" // Final event; connections to the space are closed after it
+)
+
+// PresentIdentity is an ephemeral identity connected to a space
+type PresentIdentity struct {
+	IdentityID  string
+	Nickname    string
+	Avatar      string
+	IsAnonymous bool
+	Status      PresenceStatus
+	Typing      bool
+	ConnectedAt time.Time
+}
+
+// Presence lists the identities connected to a space across all replicas
+type Presence struct {
+	SpaceID    string
+	Identities []PresentIdentity
+	Active     int
+	Idle       int
+}
+
+// SpaceEvent is an event published to a space, kept briefly so streams can resume
+type SpaceEvent struct {
+	Seq     int64 // Position in the space's event log, which resuming streams continue after
+	SpaceID string
+	Topic   string // Subject suffix the event was published on, such as messages
+	Data    []byte // Event as published
+	Time    time.Time
+}
+
+// Topics the event log keeps for streams to resume. Transient topics like
+// typing are left out.
+var LoggedEventTopics = []string{"messages", "reactions", "lifecycle", "polls", "timeline", "features"}
+
+// EventLogTopic is the topic the event log republishes a space's events on
+// once they are logged, with their position, so streams can follow them in
+// log order
+const EventLogTopic = "log"
+
+// TemplateExplanation shows how a template was chosen for a trend
+type TemplateExplanation struct {
+	TrendID    string
+	Selected   TemplateType
+	Fallback   bool // No template qualified, so the general template was used
+	Candidates []TemplateCandidate
+}
+
+// TemplateCandidate is a template's score for a trend
+type TemplateCandidate struct {
+	TemplateType TemplateType
+	Score        float64
+	MinScore     float64
+	Eligible     bool
+	Reason       string
+	Rules        []RuleEvaluation
+}
+
+// RuleEvaluation is the outcome of one template selection rule
+type RuleEvaluation struct {
+	Field    string
+	Op       string
+	Expected interface{}
+	Actual   interface{}
+	Weight   float64
+	Required bool
+	Matched  bool
+}
+
+// Poll is a question put to the members of a space
+type Poll struct {
+	ID                string
+	SpaceID           string
+	CreatorIdentityID string // Ephemeral identity of the member who asked
+	Question          string
+	Options           []PollOption
+	TotalVotes        int
+	Deadline          *time.Time
+	CreatedAt         time.Time
+	ClosedAt          *time.Time
+}
+
+// PollOption is one of a poll's answers with its current tally
+type PollOption struct {
+	ID    string
+	Text  string
+	Votes int
+}
+
+// IsOpen reports whether the poll still accepts votes at the given time
+func (p *Poll) IsOpen(at time.Time) bool {
+	if p.ClosedAt != nil {
+		return false
+	}
+	return p.Deadline == nil || at.Before(*p.Deadline)
+}
+
+// PollRequest describes a poll a member wants to start
+type PollRequest struct {
+	UserID   string
+	Question string
+	Options  []string
+	Deadline *time.Time
+}
+
+// TimelineStatus describes where a timeline entry is in curation
+type TimelineStatus string
+
+const (
+	TimelineSuggested TimelineStatus = "suggested"
+	TimelinePinned    TimelineStatus = "pinned"
+	TimelineDismissed TimelineStatus = "dismissed"
+)
+
+// TimelineEntry is a timestamped update in a space's curated timeline
+type TimelineEntry struct {
+	ID               string
+	SpaceID          string
+	Status           TimelineStatus
+	Content          string
+	SourceURL        string
+	SourcePlatform   string
+	SourceKey        string // Identifies the trend source a suggestion came from
+	AuthorIdentityID string // Empty for updates pinned by moderators or suggested automatically
+	OccurredAt       time.Time
+	CreatedAt        time.Time
+	PinnedAt         *time.Time
+}
+
+// TimelineUpdate describes an update a curator wants to pin
+type TimelineUpdate struct {
+	Content        string
+	SourceURL      string
+	SourcePlatform string
+	OccurredAt     time.Time // Defaults to now
+}
+
+// Curator is someone acting on a space's timeline
+type Curator struct {
+	UserID    string
+	Moderator bool // Moderators can curate any space
 }
@@ -0,0 +1,251 @@
+// internal/server/handlers/admin.go
+
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"essg/internal/domain/space"
+)
+
+// AdminHandler handles administrative HTTP requests
+type AdminHandler struct {
+	manager   space.Manager
+	admission space.AdmissionReporter
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(manager space.Manager, admission space.AdmissionReporter) *AdminHandler {
+	return &AdminHandler{
+		manager:   manager,
+		admission: admission,
+	}
+}
+
+// RequireAdminToken returns middleware that only admits requests carrying the admin token
+func RequireAdminToken(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			provided := r.Header.Get("X-Admin-Token")
+			if token == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+				respondWithError(w, http.StatusUnauthorized, "Admin token required", nil)
+				return
+			}
+
+			// Mark the request as made by a moderator for handlers shared with the public API
+			ctx := context.WithValue(r.Context(), moderatorKey{}, true)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// moderatorKey marks requests authenticated with the admin token
+type moderatorKey struct{}
+
+// isModerator reports whether a request was authenticated with the admin token
+func isModerator(r *http.Request) bool {
+	moderator, _ := r.Context().Value(moderatorKey{}).(bool)
+	return moderator
+}
+
+// PinSpace pins or unpins a space
+func (h *AdminHandler) PinSpace(w http.ResponseWriter, r *http.Request) {
+	// Get space ID from URL
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing space ID", nil)
+		return
+	}
+
+	// Define request body struct
+	type pinRequest struct {
+		Pinned bool `json:"pinned"`
+	}
+
+	// Parse request body
+	var req pinRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	// Pin space
+	if err := h.manager.PinSpace(r.Context(), id, req.Pinned); err != nil {
+		if errors.Is(err, space.ErrSpaceNotFound) {
+			respondWithError(w, http.StatusNotFound, "Space not found", nil)
+		} else {
+			respondWithError(w, http.StatusInternalServerError, "Failed to pin space", err)
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"space_id": id,
+		"pinned":   req.Pinned,
+	})
+}
+
+// OverrideExpiry extends or shortens the lifetime of a space
+func (h *AdminHandler) OverrideExpiry(w http.ResponseWriter, r *http.Request) {
+	// Get space ID from URL
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing space ID", nil)
+		return
+	}
+
+	// Define request body struct; either an absolute time or an offset from now
+	type expiryRequest struct {
+		ExpiresAt *time.Time `json:"expires_at"`
+		ExpiresIn string     `json:"expires_in"`
+	}
+
+	// Parse request body
+	var req expiryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	var expiresAt time.Time
+	switch {
+	case req.ExpiresAt != nil:
+		expiresAt = *req.ExpiresAt
+	case req.ExpiresIn != "":
+		d, err := time.ParseDuration(req.ExpiresIn)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid expires_in duration", err)
+			return
+		}
+		expiresAt = time.Now().Add(d)
+	default:
+		respondWithError(w, http.StatusBadRequest, "Missing expires_at or expires_in", nil)
+		return
+	}
+
+	// Override expiry
+	if err := h.manager.OverrideExpiry(r.Context(), id, expiresAt); err != nil {
+		switch {
+		case errors.Is(err, space.ErrSpaceNotFound):
+			respondWithError(w, http.StatusNotFound, "Space not found", nil)
+		case errors.Is(err, space.ErrInvalidExpiry):
+			respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		default:
+			respondWithError(w, http.StatusInternalServerError, "Failed to override expiry", err)
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"space_id":   id,
+		"expires_at": expiresAt,
+	})
+}
+
+// SetFeature enables or disables one of a space's features
+func (h *AdminHandler) SetFeature(w http.ResponseWriter, r *http.Request) {
+	// Get space and feature IDs from URL
+	id := chi.URLParam(r, "id")
+	featureID := chi.URLParam(r, "feature")
+	if id == "" || featureID == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing space or feature ID", nil)
+		return
+	}
+
+	// Define request body struct
+	type featureRequest struct {
+		Enabled bool `json:"enabled"`
+	}
+
+	// Parse request body
+	var req featureRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	// Toggle feature
+	s, err := h.manager.SetFeatureEnabled(r.Context(), id, featureID, req.Enabled)
+	if err != nil {
+		switch {
+		case errors.Is(err, space.ErrSpaceNotFound):
+			respondWithError(w, http.StatusNotFound, "Space not found", nil)
+		case errors.Is(err, space.ErrUnknownFeature):
+			respondWithError(w, http.StatusNotFound, err.Error(), nil)
+		default:
+			respondWithError(w, http.StatusInternalServerError, "Failed to update feature", err)
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"space_id": id,
+		"features": s.Features,
+	})
+}
+
+// ListAdmissionDecisions returns recorded space admission decisions
+func (h *AdminHandler) ListAdmissionDecisions(w http.ResponseWriter, r *http.Request) {
+	// Parse query parameters
+	query := r.URL.Query()
+
+	filter := space.AdmissionFilter{
+		TrendID: query.Get("trend_id"),
+		Limit:   100,
+	}
+
+	// Parse outcomes
+	if outcomes := query.Get("outcome"); outcomes != "" {
+		for _, outcome := range strings.Split(outcomes, ",") {
+			filter.Outcomes = append(filter.Outcomes, space.AdmissionOutcome(strings.TrimSpace(outcome)))
+		}
+	}
+
+	// Parse since
+	if sinceStr := query.Get("since"); sinceStr != "" {
+		since, err := time.Parse(time.RFC3339, sinceStr)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid since timestamp", err)
+			return
+		}
+		filter.Since = since
+	}
+
+	// Parse limit
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			respondWithError(w, http.StatusBadRequest, "Invalid limit", err)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	decisions, err := h.admission.ListAdmissionDecisions(r.Context(), filter)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to list admission decisions", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, decisions)
+}
+
+// GetAdmissionQueue returns the trends waiting for space capacity
+func (h *AdminHandler) GetAdmissionQueue(w http.ResponseWriter, r *http.Request) {
+	queue, err := h.admission.GetAdmissionQueue(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to get admission queue", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, queue)
+}
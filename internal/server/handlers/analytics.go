@@ -0,0 +1,109 @@
+// internal/server/handlers/analytics.go
+
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"essg/internal/domain/space"
+)
+
+// AnalyticsHandler handles space analytics HTTP requests
+type AnalyticsHandler struct {
+	analytics space.AnalyticsReporter
+}
+
+// NewAnalyticsHandler creates a new analytics handler
+func NewAnalyticsHandler(analytics space.AnalyticsReporter) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		analytics: analytics,
+	}
+}
+
+// GetAnalytics returns a space's engagement curve and lifecycle thresholds.
+// from and to are RFC 3339 timestamps and bucket a duration such as 5m; each
+// falls back to a default when omitted.
+func (h *AnalyticsHandler) GetAnalytics(w http.ResponseWriter, r *http.Request) {
+	// Get space ID from URL
+	spaceID := chi.URLParam(r, "id")
+	if spaceID == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing space ID", nil)
+		return
+	}
+
+	query := r.URL.Query()
+
+	// Parse range
+	var from, to time.Time
+	if fromStr := query.Get("from"); fromStr != "" {
+		t, err := time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid from timestamp", err)
+			return
+		}
+		from = t
+	}
+	if toStr := query.Get("to"); toStr != "" {
+		t, err := time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid to timestamp", err)
+			return
+		}
+		to = t
+	}
+
+	// Parse bucket
+	var bucket time.Duration
+	if bucketStr := query.Get("bucket"); bucketStr != "" {
+		d, err := time.ParseDuration(bucketStr)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid bucket duration", err)
+			return
+		}
+		bucket = d
+	}
+
+	analytics, err := h.analytics.GetAnalytics(r.Context(), spaceID, from, to, bucket)
+	if err != nil {
+		switch {
+		case errors.Is(err, space.ErrSpaceNotFound):
+			respondWithError(w, http.StatusNotFound, "Space not found", nil)
+		case errors.Is(err, space.ErrInvalidRange):
+			respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		default:
+			respondWithError(w, http.StatusInternalServerError, "Failed to get analytics", err)
+		}
+		return
+	}
+
+	curve := make([]map[string]interface{}, 0, len(analytics.Curve))
+	for _, point := range analytics.Curve {
+		curve = append(curve, map[string]interface{}{
+			"time":             point.Time,
+			"engagement_score": point.EngagementScore,
+			"user_count":       point.UserCount,
+			"active_users":     point.ActiveUsers,
+			"message_count":    point.MessageCount,
+			"lifecycle_stage":  point.LifecycleStage,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"space_id":        analytics.SpaceID,
+		"lifecycle_stage": analytics.Stage,
+		"thresholds": map[string]interface{}{
+			"peak_score":    analytics.Thresholds.PeakScore,
+			"waning_score":  analytics.Thresholds.WaningScore,
+			"recover_score": analytics.Thresholds.RecoverScore,
+			"devolve_score": analytics.Thresholds.DevolveScore,
+		},
+		"from":   analytics.From,
+		"to":     analytics.To,
+		"bucket": analytics.Bucket.String(),
+		"curve":  curve,
+	})
+}
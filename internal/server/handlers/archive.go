@@ -0,0 +1,47 @@
+// internal/server/handlers/archive.go
+
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"essg/internal/domain/space"
+)
+
+// ArchiveHandler handles space archive HTTP requests
+type ArchiveHandler struct {
+	archiver space.Archiver
+}
+
+// NewArchiveHandler creates a new archive handler
+func NewArchiveHandler(archiver space.Archiver) *ArchiveHandler {
+	return &ArchiveHandler{
+		archiver: archiver,
+	}
+}
+
+// GetArchive returns the archived summary of a dissolved space
+func (h *ArchiveHandler) GetArchive(w http.ResponseWriter, r *http.Request) {
+	// Get space ID from URL
+	spaceID := chi.URLParam(r, "id")
+	if spaceID == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing space ID", nil)
+		return
+	}
+
+	// Get archive
+	archive, err := h.archiver.GetArchive(r.Context(), spaceID)
+	if err != nil {
+		if errors.Is(err, space.ErrArchiveNotFound) {
+			respondWithError(w, http.StatusNotFound, "Archive not found", nil)
+		} else {
+			respondWithError(w, http.StatusInternalServerError, "Failed to get archive", err)
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, archive)
+}
@@ -0,0 +1,320 @@
+// internal/server/handlers/auth.go
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"essg/internal/domain/identity"
+)
+
+// userKey holds the authenticated user ID on a request context
+type userKey struct{}
+
+// tokenKey holds the token a request was authenticated with
+type tokenKey struct{}
+
+// RequireAuth returns middleware that only admits requests carrying a valid
+// token and puts the authenticated user on the request context. Tokens are read
+// from the Authorization header, or from the access_token query parameter for
+// clients such as browser WebSockets and event streams that cannot set headers.
+func RequireAuth(tokens identity.TokenManager) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := requestToken(r)
+			if token == "" {
+				respondWithError(w, http.StatusUnauthorized, "Authentication required", nil)
+				return
+			}
+
+			userID, err := tokens.ValidateToken(token)
+			if err != nil {
+				switch {
+				case errors.Is(err, identity.ErrInvalidToken),
+					errors.Is(err, identity.ErrTokenExpired),
+					errors.Is(err, identity.ErrTokenRevoked):
+					respondWithError(w, http.StatusUnauthorized, err.Error(), nil)
+				default:
+					respondWithError(w, http.StatusInternalServerError, "Failed to validate token", err)
+				}
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), userKey{}, userID)
+			ctx = context.WithValue(ctx, tokenKey{}, token)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// UserIDFromContext returns the authenticated user, if any
+func UserIDFromContext(ctx context.Context) (string, bool) {
+	userID, ok := ctx.Value(userKey{}).(string)
+	return userID, ok && userID != ""
+}
+
+// requestToken extracts a bearer token from a request
+func requestToken(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, token, ok := strings.Cut(header, " ")
+		if ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+		return ""
+	}
+
+	return r.URL.Query().Get("access_token")
+}
+
+// AuthHandler handles token HTTP requests
+type AuthHandler struct {
+	tokens identity.TokenManager
+	guests identity.GuestManager
+}
+
+// NewAuthHandler creates a new auth handler
+func NewAuthHandler(tokens identity.TokenManager, guests identity.GuestManager) *AuthHandler {
+	return &AuthHandler{
+		tokens: tokens,
+		guests: guests,
+	}
+}
+
+// CreateGuestSession starts an anonymous guest session, limited per client address
+func (h *AuthHandler) CreateGuestSession(w http.ResponseWriter, r *http.Request) {
+	session, err := h.guests.CreateGuestSession(r.Context(), clientKey(r))
+	if err != nil {
+		if errors.Is(err, identity.ErrTooManyGuests) {
+			respondWithError(w, http.StatusTooManyRequests, "Too many guest sessions, try again later", nil)
+		} else {
+			respondWithError(w, http.StatusInternalServerError, "Failed to create guest session", err)
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, formatSession(session))
+}
+
+// UpgradeGuest links a platform account, verified by a trusted backend, to a
+// guest and returns a full session in place of their guest tokens
+func (h *AuthHandler) UpgradeGuest(w http.ResponseWriter, r *http.Request) {
+	// Get guest ID from URL
+	guestID := chi.URLParam(r, "id")
+	if guestID == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing user ID", nil)
+		return
+	}
+
+	// Define request body struct
+	type upgradeRequest struct {
+		Platform   string `json:"platform"`
+		PlatformID string `json:"platform_id"`
+	}
+
+	// Parse request body
+	var req upgradeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if req.Platform == "" || req.PlatformID == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing platform or platform ID", nil)
+		return
+	}
+
+	// Upgrade guest
+	session, err := h.guests.UpgradeGuest(r.Context(), guestID, req.PlatformID, req.Platform)
+	if err != nil {
+		switch {
+		case errors.Is(err, identity.ErrUserNotFound):
+			respondWithError(w, http.StatusNotFound, "User not found", nil)
+		case errors.Is(err, identity.ErrNotGuest):
+			respondWithError(w, http.StatusConflict, "User is not a guest", nil)
+		default:
+			respondWithError(w, http.StatusInternalServerError, "Failed to upgrade guest", err)
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, formatSession(session))
+}
+
+// IssueToken issues a token for a user on behalf of a trusted backend
+func (h *AuthHandler) IssueToken(w http.ResponseWriter, r *http.Request) {
+	// Define request body struct
+	type issueRequest struct {
+		UserID    string `json:"user_id"`
+		ExpiresIn string `json:"expires_in"`
+	}
+
+	// Parse request body
+	var req issueRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if req.UserID == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing user ID", nil)
+		return
+	}
+
+	// Parse lifetime, leaving zero for the default
+	var ttl time.Duration
+	if req.ExpiresIn != "" {
+		d, err := time.ParseDuration(req.ExpiresIn)
+		if err != nil || d <= 0 {
+			respondWithError(w, http.StatusBadRequest, "Invalid expires_in duration", err)
+			return
+		}
+		ttl = d
+	}
+
+	// Generate token
+	token, err := h.tokens.GenerateToken(req.UserID, ttl)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to generate token", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, map[string]interface{}{
+		"user_id": req.UserID,
+		"token":   token,
+	})
+}
+
+// Logout revokes the token the request was authenticated with
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	token, _ := r.Context().Value(tokenKey{}).(string)
+
+	if err := h.tokens.RevokeToken(token); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to revoke token", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeUserTokens revokes every token issued to a user
+func (h *AuthHandler) RevokeUserTokens(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from URL
+	userID := chi.URLParam(r, "id")
+	if userID == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing user ID", nil)
+		return
+	}
+
+	if err := h.tokens.RevokeAllForUser(userID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to revoke tokens", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// TrustedRealIP returns middleware that replaces a request's remote address with
+// the client address forwarded by a proxy, but only when the request came
+// directly from one of the trusted proxies. Forwarded headers from anyone else
+// are ignored, so clients can't choose the address they are throttled by.
+func TrustedRealIP(proxies []string) func(http.Handler) http.Handler {
+	var trusted []*net.IPNet
+	for _, cidr := range proxies {
+		if _, network, err := net.ParseCIDR(strings.TrimSpace(cidr)); err == nil {
+			trusted = append(trusted, network)
+		}
+	}
+
+	isTrusted := func(addr string) bool {
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			return false
+		}
+		for _, network := range trusted {
+			if network.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			peer, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				peer = r.RemoteAddr
+			}
+
+			if isTrusted(peer) {
+				if client := forwardedClient(r, isTrusted); client != "" {
+					r.RemoteAddr = client
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// forwardedClient returns the client address a trusted proxy forwarded. Proxies
+// append to X-Forwarded-For, so it is read from the right, skipping the trusted
+// proxies in the chain; anything further left could have been sent by the client.
+func forwardedClient(r *http.Request, isTrusted func(string) bool) string {
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if net.ParseIP(hop) == nil {
+			return ""
+		}
+		if !isTrusted(hop) {
+			return hop
+		}
+	}
+
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(realIP) != nil {
+		return realIP
+	}
+
+	return ""
+}
+
+// clientKey identifies the client a request came from by its address, as set by
+// TrustedRealIP. IPv6 clients are keyed by their /64, since a single host is
+// usually given one.
+func clientKey(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return host
+	}
+	if ip.To4() == nil {
+		return ip.Mask(net.CIDRMask(64, 128)).String() + "/64"
+	}
+
+	return ip.String()
+}
+
+// formatSession formats a session for a response
+func formatSession(session *identity.Session) map[string]interface{} {
+	return map[string]interface{}{
+		"user_id":  session.User.ID,
+		"is_guest": session.User.IsGuest,
+		"token":    session.Token,
+	}
+}
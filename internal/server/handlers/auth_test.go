@@ -0,0 +1,73 @@
+// internal/server/handlers/auth_test.go
+
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTrustedRealIPClientKey(t *testing.T) {
+	tests := []struct {
+		name          string
+		remoteAddr    string
+		forwardedFor  string
+		realIP        string
+		wantClientKey string
+	}{
+		{
+			name:          "direct client forging headers",
+			remoteAddr:    "203.0.113.7:5555",
+			forwardedFor:  "198.51.100.1",
+			realIP:        "198.51.100.2",
+			wantClientKey: "203.0.113.7",
+		},
+		{
+			name:          "through a trusted proxy",
+			remoteAddr:    "10.0.0.2:5555",
+			forwardedFor:  "203.0.113.7",
+			wantClientKey: "203.0.113.7",
+		},
+		{
+			name:          "client prepending to the chain",
+			remoteAddr:    "10.0.0.2:5555",
+			forwardedFor:  "198.51.100.1, 203.0.113.7, 10.0.0.3",
+			wantClientKey: "203.0.113.7",
+		},
+		{
+			name:          "trusted proxy with X-Real-IP",
+			remoteAddr:    "10.0.0.2:5555",
+			realIP:        "203.0.113.7",
+			wantClientKey: "203.0.113.7",
+		},
+		{
+			name:          "IPv6 client",
+			remoteAddr:    "[2001:db8:1:2:3:4:5:6]:5555",
+			wantClientKey: "2001:db8:1:2::/64",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := TrustedRealIP([]string{"10.0.0.0/8"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = clientKey(r)
+			}))
+
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/auth/guest", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+
+			if got != tt.wantClientKey {
+				t.Errorf("clientKey = %q, want %q", got, tt.wantClientKey)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+// internal/server/handlers/features.go
+
+package handlers
+
+import (
+	"net/http"
+
+	"essg/internal/domain/space"
+)
+
+// messageFeatures returns the features a message needs based on what it
+// carries. Locations need none, since spaces without geo context drop them.
+func messageFeatures(hasContent, hasMedia bool) []string {
+	var features []string
+	if hasContent {
+		features = append(features, space.FeatureMessaging)
+	}
+	if hasMedia {
+		features = append(features, space.FeatureMedia)
+	}
+	return features
+}
+
+// firstDisabledFeature returns the first feature the space does not allow, or ""
+func firstDisabledFeature(s *space.Space, features []string) string {
+	for _, id := range features {
+		if !s.HasFeature(id) {
+			return id
+		}
+	}
+	return ""
+}
+
+// respondWithFeatureDisabled rejects a request that uses a disabled feature
+func respondWithFeatureDisabled(w http.ResponseWriter, feature string) {
+	respondWithJSON(w, http.StatusForbidden, map[string]interface{}{
+		"error":   space.ErrFeatureDisabled.Error(),
+		"feature": feature,
+	})
+}
@@ -0,0 +1,288 @@
+// internal/server/handlers/hub.go
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/nats-io/nats.go"
+
+	"essg/internal/domain/identity"
+	"essg/internal/domain/space"
+)
+
+// ErrHubStopped is returned when registering with a hub that has shut down
+var ErrHubStopped = errors.New("hub stopped")
+
+// hubClient is a connection the hub fans space events out to
+type hubClient interface {
+	// deliver queues an event without blocking, returning false if the client's
+	// buffer is full. Clients may skip topics they have not opted in to.
+	deliver(topic string, data []byte) bool
+
+	// disconnect asks the client to close with a WebSocket close code and reason,
+	// after sending the events already queued for it
+	disconnect(code int, reason string)
+
+	// user returns the ID of the user the client authenticated as
+	user() string
+}
+
+// HubConfig contains configuration for the space event hub
+type HubConfig struct {
+	// Topics forwarded to clients; other events on a space's subjects stay server-side
+	Topics []string
+
+	// Topics a slow client may miss instead of being disconnected
+	DroppableTopics []string
+}
+
+// DefaultHubConfig returns the default hub configuration
+func DefaultHubConfig() HubConfig {
+	return HubConfig{
+		Topics: []string{
+			"messages", "typing", "reactions", "presence", "lifecycle",
+			"polls", "timeline", "features", "metrics", space.EventLogTopic,
+		},
+		DroppableTopics: []string{"typing", "metrics"},
+	}
+}
+
+// spaceSubscribers holds a space's event subscription and the local clients following it
+type spaceSubscribers struct {
+	sub     *nats.Subscription
+	clients map[hubClient]struct{}
+}
+
+// Hub shares one event bus subscription per space among all of this process's
+// clients in that space and fans events out to them. Delivery never blocks:
+// a client too slow to keep up misses droppable events such as typing, and is
+// disconnected when it would miss anything else, so it can reconnect and
+// catch up rather than silently losing messages. Clients whose user has their
+// tokens revoked are disconnected too, since tokens are only checked when a
+// connection opens.
+type Hub struct {
+	natsConn    *nats.Conn
+	config      HubConfig
+	topics      map[string]bool
+	droppable   map[string]bool
+	mu          sync.RWMutex
+	spaces      map[string]*spaceSubscribers
+	revocations *nats.Subscription // Subscribed with the first client
+	stopped     bool
+	wg          sync.WaitGroup
+}
+
+// NewHub creates a new space event hub
+func NewHub(natsConn *nats.Conn, config HubConfig) *Hub {
+	topics := make(map[string]bool, len(config.Topics))
+	for _, topic := range config.Topics {
+		topics[topic] = true
+	}
+
+	droppable := make(map[string]bool, len(config.DroppableTopics))
+	for _, topic := range config.DroppableTopics {
+		droppable[topic] = true
+	}
+
+	return &Hub{
+		natsConn:  natsConn,
+		config:    config,
+		topics:    topics,
+		droppable: droppable,
+		spaces:    make(map[string]*spaceSubscribers),
+	}
+}
+
+// register starts fanning a space's events out to a client, subscribing to
+// the space if it is the first local client there
+func (h *Hub) register(spaceID string, client hubClient) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.stopped {
+		return ErrHubStopped
+	}
+
+	if h.revocations == nil {
+		sub, err := h.natsConn.Subscribe(identity.TokensRevokedSubject, h.revoke)
+		if err != nil {
+			return fmt.Errorf("error subscribing to token revocations: %w", err)
+		}
+		h.revocations = sub
+	}
+
+	subscribers, ok := h.spaces[spaceID]
+	if !ok {
+		sub, err := h.natsConn.Subscribe(fmt.Sprintf("space.%s.*", spaceID), h.fanOut)
+		if err != nil {
+			return fmt.Errorf("error subscribing to space: %w", err)
+		}
+
+		subscribers = &spaceSubscribers{
+			sub:     sub,
+			clients: make(map[hubClient]struct{}),
+		}
+		h.spaces[spaceID] = subscribers
+	}
+
+	subscribers.clients[client] = struct{}{}
+	h.wg.Add(1)
+
+	return nil
+}
+
+// unregister stops fanning out to a client, unsubscribing from the space once
+// no local clients remain. Unregistering a client twice is harmless.
+func (h *Hub) unregister(spaceID string, client hubClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	subscribers, ok := h.spaces[spaceID]
+	if !ok {
+		return
+	}
+	if _, ok := subscribers.clients[client]; !ok {
+		return
+	}
+
+	delete(subscribers.clients, client)
+	h.wg.Done()
+
+	if len(subscribers.clients) > 0 {
+		return
+	}
+
+	delete(h.spaces, spaceID)
+	if subscribers.sub != nil {
+		if err := subscribers.sub.Unsubscribe(); err != nil {
+			log.Printf("Failed to unsubscribe from space %s: %v", spaceID, err)
+		}
+	}
+}
+
+// fanOut delivers an event to every local client in its space
+func (h *Hub) fanOut(msg *nats.Msg) {
+	parts := strings.Split(msg.Subject, ".")
+	if len(parts) != 3 || !h.topics[parts[2]] {
+		return
+	}
+	spaceID, topic := parts[1], parts[2]
+	dissolved := topic == "lifecycle" && isDissolvedEvent(msg.Data)
+
+	var slow, closing []hubClient
+
+	h.mu.RLock()
+	if subscribers, ok := h.spaces[spaceID]; ok {
+		for client := range subscribers.clients {
+			if !client.deliver(topic, msg.Data) && !h.droppable[topic] {
+				slow = append(slow, client)
+			} else if dissolved {
+				closing = append(closing, client)
+			}
+		}
+	}
+	h.mu.RUnlock()
+
+	// Disconnect clients that fell behind; they stop receiving once they unregister
+	for _, client := range slow {
+		client.disconnect(websocket.CloseTryAgainLater, "slow consumer")
+	}
+
+	// Nothing more happens in a dissolved space, so close its connections
+	// once they have been sent the dissolved event
+	for _, client := range closing {
+		client.disconnect(websocket.CloseNormalClosure, "space dissolved")
+	}
+}
+
+// revoke disconnects every local client of a user whose tokens were revoked
+func (h *Hub) revoke(msg *nats.Msg) {
+	var event identity.TokensRevokedEvent
+	if err := json.Unmarshal(msg.Data, &event); err != nil {
+		log.Printf("Failed to parse token revocation: %v", err)
+		return
+	}
+	if event.UserID == "" {
+		return
+	}
+
+	var revoked []hubClient
+
+	h.mu.RLock()
+	for _, subscribers := range h.spaces {
+		for client := range subscribers.clients {
+			if client.user() == event.UserID {
+				revoked = append(revoked, client)
+			}
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, client := range revoked {
+		client.disconnect(websocket.ClosePolicyViolation, "token revoked")
+	}
+}
+
+// isDissolvedEvent reports whether a lifecycle event is a space's final dissolved event
+func isDissolvedEvent(data []byte) bool {
+	var event struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &event); err != nil {
+		return false
+	}
+	return event.Type == space.LifecycleDissolved
+}
+
+// Stop unsubscribes from every space and disconnects all clients, waiting for
+// them to close until the context is done
+func (h *Hub) Stop(ctx context.Context) error {
+	h.mu.Lock()
+	h.stopped = true
+
+	if h.revocations != nil {
+		if err := h.revocations.Unsubscribe(); err != nil {
+			log.Printf("Failed to unsubscribe from token revocations: %v", err)
+		}
+		h.revocations = nil
+	}
+
+	var clients []hubClient
+	for spaceID, subscribers := range h.spaces {
+		if err := subscribers.sub.Unsubscribe(); err != nil {
+			log.Printf("Failed to unsubscribe from space %s: %v", spaceID, err)
+		}
+		subscribers.sub = nil
+
+		for client := range subscribers.clients {
+			clients = append(clients, client)
+		}
+	}
+	h.mu.Unlock()
+
+	for _, client := range clients {
+		client.disconnect(websocket.CloseGoingAway, "server shutting down")
+	}
+
+	c := make(chan struct{})
+	go func() {
+		h.wg.Wait()
+		close(c)
+	}()
+
+	select {
+	case <-c:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return nil
+}
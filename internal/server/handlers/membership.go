@@ -0,0 +1,124 @@
+// internal/server/handlers/membership.go
+
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"essg/internal/domain/space"
+)
+
+// MembershipHandler handles space membership HTTP requests
+type MembershipHandler struct {
+	membership space.MembershipManager
+}
+
+// NewMembershipHandler creates a new membership handler
+func NewMembershipHandler(membership space.MembershipManager) *MembershipHandler {
+	return &MembershipHandler{
+		membership: membership,
+	}
+}
+
+// JoinSpace adds the requesting user to a space
+func (h *MembershipHandler) JoinSpace(w http.ResponseWriter, r *http.Request) {
+	spaceID, userID, ok := parseMembershipRequest(w, r)
+	if !ok {
+		return
+	}
+
+	// Join space
+	ident, err := h.membership.JoinSpace(r.Context(), spaceID, userID)
+	if err != nil {
+		respondWithMembershipError(w, "Failed to join space", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"space_id":     spaceID,
+		"identity_id":  ident.ID,
+		"nickname":     ident.Nickname,
+		"avatar":       ident.Avatar,
+		"is_anonymous": ident.IsAnonymous,
+		"joined_at":    ident.CreatedAt,
+	})
+}
+
+// LeaveSpace removes the requesting user from a space
+func (h *MembershipHandler) LeaveSpace(w http.ResponseWriter, r *http.Request) {
+	spaceID, userID, ok := parseMembershipRequest(w, r)
+	if !ok {
+		return
+	}
+
+	// Leave space
+	if err := h.membership.LeaveSpace(r.Context(), spaceID, userID); err != nil {
+		respondWithMembershipError(w, "Failed to leave space", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"space_id": spaceID,
+		"left":     true,
+	})
+}
+
+// GetOccupancy returns member and active counts for a space
+func (h *MembershipHandler) GetOccupancy(w http.ResponseWriter, r *http.Request) {
+	// Get space ID from URL
+	spaceID := chi.URLParam(r, "id")
+	if spaceID == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing space ID", nil)
+		return
+	}
+
+	occupancy, err := h.membership.GetOccupancy(r.Context(), spaceID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to get occupancy", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"space_id":      occupancy.SpaceID,
+		"members":       occupancy.Members,
+		"active":        occupancy.Active,
+		"active_window": occupancy.ActiveWindow.String(),
+	})
+}
+
+// parseMembershipRequest reads the space ID and authenticated user from a
+// join or leave request
+func parseMembershipRequest(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	// Get space ID from URL
+	spaceID := chi.URLParam(r, "id")
+	if spaceID == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing space ID", nil)
+		return "", "", false
+	}
+
+	// Members only ever join and leave as themselves
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "Authentication required", nil)
+		return "", "", false
+	}
+
+	return spaceID, userID, true
+}
+
+// respondWithMembershipError maps membership errors to HTTP statuses
+func respondWithMembershipError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, space.ErrSpaceNotFound):
+		respondWithError(w, http.StatusNotFound, "Space not found", nil)
+	case errors.Is(err, space.ErrSpaceClosed):
+		respondWithError(w, http.StatusGone, "Space has dissolved", nil)
+	case errors.Is(err, space.ErrNotMember):
+		respondWithError(w, http.StatusConflict, "Not a member of this space", nil)
+	default:
+		respondWithError(w, http.StatusInternalServerError, message, err)
+	}
+}
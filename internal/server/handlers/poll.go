@@ -0,0 +1,268 @@
+// internal/server/handlers/poll.go
+
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"essg/internal/domain/messaging"
+	"essg/internal/domain/space"
+)
+
+// PollHandler handles space poll HTTP requests. Polls are created, voted in
+// and closed as the authenticated user, counted against their rate limit.
+type PollHandler struct {
+	polls   space.PollManager
+	limiter messaging.RateLimiter
+}
+
+// NewPollHandler creates a new poll handler
+func NewPollHandler(polls space.PollManager, limiter messaging.RateLimiter) *PollHandler {
+	return &PollHandler{
+		polls:   polls,
+		limiter: limiter,
+	}
+}
+
+// CreatePoll starts a poll in a space
+func (h *PollHandler) CreatePoll(w http.ResponseWriter, r *http.Request) {
+	// Get space ID from URL
+	spaceID := chi.URLParam(r, "id")
+	if spaceID == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing space ID", nil)
+		return
+	}
+
+	// Define request body struct; the deadline is an absolute time or an offset from now
+	type createPollRequest struct {
+		Question  string     `json:"question"`
+		Options   []string   `json:"options"`
+		Deadline  *time.Time `json:"deadline"`
+		ExpiresIn string     `json:"expires_in"`
+	}
+
+	// Parse request body
+	var req createPollRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	deadline := req.Deadline
+	if deadline == nil && req.ExpiresIn != "" {
+		d, err := time.ParseDuration(req.ExpiresIn)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid expires_in duration", err)
+			return
+		}
+		at := time.Now().Add(d)
+		deadline = &at
+	}
+
+	userID, ok := h.allow(w, r, spaceID)
+	if !ok {
+		return
+	}
+
+	// Create poll
+	poll, err := h.polls.CreatePoll(r.Context(), spaceID, space.PollRequest{
+		UserID:   userID,
+		Question: req.Question,
+		Options:  req.Options,
+		Deadline: deadline,
+	})
+	if err != nil {
+		respondWithPollError(w, "Failed to create poll", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, formatPoll(*poll))
+}
+
+// ListPolls returns a space's polls, newest first
+func (h *PollHandler) ListPolls(w http.ResponseWriter, r *http.Request) {
+	// Get space ID from URL
+	spaceID := chi.URLParam(r, "id")
+	if spaceID == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing space ID", nil)
+		return
+	}
+
+	// Get polls
+	polls, err := h.polls.ListPolls(r.Context(), spaceID)
+	if err != nil {
+		respondWithPollError(w, "Failed to list polls", err)
+		return
+	}
+
+	response := make([]map[string]interface{}, 0, len(polls))
+	for _, poll := range polls {
+		response = append(response, formatPoll(poll))
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// GetPoll returns a poll with its current tallies
+func (h *PollHandler) GetPoll(w http.ResponseWriter, r *http.Request) {
+	// Get space and poll IDs from URL
+	spaceID := chi.URLParam(r, "id")
+	pollID := chi.URLParam(r, "pollID")
+	if spaceID == "" || pollID == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing space or poll ID", nil)
+		return
+	}
+
+	// Get poll
+	poll, err := h.polls.GetPoll(r.Context(), spaceID, pollID)
+	if err != nil {
+		respondWithPollError(w, "Failed to get poll", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, formatPoll(*poll))
+}
+
+// Vote records the requesting member's vote in a poll
+func (h *PollHandler) Vote(w http.ResponseWriter, r *http.Request) {
+	// Get space and poll IDs from URL
+	spaceID := chi.URLParam(r, "id")
+	pollID := chi.URLParam(r, "pollID")
+	if spaceID == "" || pollID == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing space or poll ID", nil)
+		return
+	}
+
+	// Define request body struct
+	type voteRequest struct {
+		OptionID string `json:"option_id"`
+	}
+
+	// Parse request body
+	var req voteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if req.OptionID == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing option ID", nil)
+		return
+	}
+
+	userID, ok := h.allow(w, r, spaceID)
+	if !ok {
+		return
+	}
+
+	// Vote
+	poll, err := h.polls.Vote(r.Context(), spaceID, pollID, userID, req.OptionID)
+	if err != nil {
+		respondWithPollError(w, "Failed to vote", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, formatPoll(*poll))
+}
+
+// ClosePoll closes a poll early on behalf of its creator
+func (h *PollHandler) ClosePoll(w http.ResponseWriter, r *http.Request) {
+	// Get space and poll IDs from URL
+	spaceID := chi.URLParam(r, "id")
+	pollID := chi.URLParam(r, "pollID")
+	if spaceID == "" || pollID == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing space or poll ID", nil)
+		return
+	}
+
+	userID, ok := h.allow(w, r, spaceID)
+	if !ok {
+		return
+	}
+
+	// Close poll
+	poll, err := h.polls.ClosePoll(r.Context(), spaceID, pollID, userID)
+	if err != nil {
+		respondWithPollError(w, "Failed to close poll", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, formatPoll(*poll))
+}
+
+// allow returns the authenticated user if their rate limit allows another
+// poll action in the space, otherwise responding with why not
+func (h *PollHandler) allow(w http.ResponseWriter, r *http.Request, spaceID string) (string, bool) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "Authentication required", nil)
+		return "", false
+	}
+
+	allowed, resetAt, err := h.limiter.AllowAction(userID, messaging.ActionPoll, spaceID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to check rate limit", err)
+		return "", false
+	}
+	if !allowed {
+		respondWithRateLimited(w, resetAt)
+		return "", false
+	}
+
+	return userID, true
+}
+
+// formatPoll formats a poll and its tallies for a response
+func formatPoll(poll space.Poll) map[string]interface{} {
+	options := make([]map[string]interface{}, 0, len(poll.Options))
+	for _, option := range poll.Options {
+		options = append(options, map[string]interface{}{
+			"id":    option.ID,
+			"text":  option.Text,
+			"votes": option.Votes,
+		})
+	}
+
+	return map[string]interface{}{
+		"id":          poll.ID,
+		"space_id":    poll.SpaceID,
+		"question":    poll.Question,
+		"options":     options,
+		"total_votes": poll.TotalVotes,
+		"deadline":    poll.Deadline,
+		"created_at":  poll.CreatedAt,
+		"closed_at":   poll.ClosedAt,
+		"is_open":     poll.IsOpen(time.Now()),
+	}
+}
+
+// respondWithPollError maps poll errors to HTTP statuses
+func respondWithPollError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, space.ErrFeatureDisabled):
+		respondWithFeatureDisabled(w, space.FeaturePolls)
+	case errors.Is(err, space.ErrSpaceNotFound):
+		respondWithError(w, http.StatusNotFound, "Space not found", nil)
+	case errors.Is(err, space.ErrPollNotFound):
+		respondWithError(w, http.StatusNotFound, "Poll not found", nil)
+	case errors.Is(err, space.ErrSpaceClosed):
+		respondWithError(w, http.StatusGone, "Space has dissolved", nil)
+	case errors.Is(err, space.ErrInvalidPoll), errors.Is(err, space.ErrUnknownOption):
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+	case errors.Is(err, space.ErrNotMember):
+		respondWithError(w, http.StatusForbidden, "Not a member of this space", nil)
+	case errors.Is(err, space.ErrNotOwner):
+		respondWithError(w, http.StatusForbidden, "Only the poll's creator can close it", nil)
+	case errors.Is(err, space.ErrPollClosed):
+		respondWithError(w, http.StatusConflict, "Poll is closed", nil)
+	case errors.Is(err, space.ErrAlreadyVoted):
+		respondWithError(w, http.StatusConflict, "Already voted in this poll", nil)
+	default:
+		respondWithError(w, http.StatusInternalServerError, message, err)
+	}
+}
@@ -0,0 +1,60 @@
+// internal/server/handlers/presence.go
+
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"essg/internal/domain/space"
+)
+
+// PresenceHandler handles space presence HTTP requests
+type PresenceHandler struct {
+	presence space.PresenceTracker
+}
+
+// NewPresenceHandler creates a new presence handler
+func NewPresenceHandler(presence space.PresenceTracker) *PresenceHandler {
+	return &PresenceHandler{
+		presence: presence,
+	}
+}
+
+// GetPresence returns the identities connected to a space
+func (h *PresenceHandler) GetPresence(w http.ResponseWriter, r *http.Request) {
+	// Get space ID from URL
+	spaceID := chi.URLParam(r, "id")
+	if spaceID == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing space ID", nil)
+		return
+	}
+
+	presence, err := h.presence.GetPresence(r.Context(), spaceID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to get presence", err)
+		return
+	}
+
+	identities := make([]map[string]interface{}, 0, len(presence.Identities))
+	for _, ident := range presence.Identities {
+		identities = append(identities, map[string]interface{}{
+			"identity_id":  ident.IdentityID,
+			"nickname":     ident.Nickname,
+			"avatar":       ident.Avatar,
+			"is_anonymous": ident.IsAnonymous,
+			"status":       ident.Status,
+			"typing":       ident.Typing,
+			"connected_at": ident.ConnectedAt,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"space_id":   presence.SpaceID,
+		"present":    len(presence.Identities),
+		"active":     presence.Active,
+		"idle":       presence.Idle,
+		"identities": identities,
+	})
+}
@@ -0,0 +1,31 @@
+// internal/server/handlers/privacy.go
+
+package handlers
+
+import (
+	"net/http"
+
+	"essg/internal/domain/identity"
+)
+
+// PrivacyHandler handles privacy policy HTTP requests
+type PrivacyHandler struct {
+	config identity.PrivacyConfig
+}
+
+// NewPrivacyHandler creates a new privacy handler
+func NewPrivacyHandler(config identity.PrivacyConfig) *PrivacyHandler {
+	return &PrivacyHandler{
+		config: config,
+	}
+}
+
+// GetPolicy returns the privacy options available to users
+func (h *PrivacyHandler) GetPolicy(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"location_sharing_options": h.config.GetLocationSharingOptions(),
+		"default_location_sharing": h.config.GetDefaultLocationSharing(),
+		"anonymity":                h.config.GetAnonymityOptions(),
+		"data_retention":           h.config.GetDataRetentionPolicy(),
+	})
+}
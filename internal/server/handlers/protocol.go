@@ -0,0 +1,220 @@
+// internal/server/handlers/protocol.go
+
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"essg/internal/domain/trend"
+)
+
+// WebSocket protocol versions the server speaks. Clients ask for a version
+// with the protocol query parameter and get the newest one both sides support.
+// Every WebSocket message carries exactly one JSON frame, in both directions.
+// The frames below are mirrored for the web client in web/lib/protocol.ts.
+const (
+	ProtocolVersion    = 1
+	MinProtocolVersion = 1
+)
+
+// maxClientIDLength limits client-generated message IDs
+const maxClientIDLength = 64
+
+// Frame types sent by clients
+const (
+	FrameMessage  = "message"
+	FrameTyping   = "typing"
+	FrameReaction = "reaction"
+	FramePing     = "ping"
+)
+
+// Frame types sent by the server, besides events relayed from the space
+const (
+	FrameWelcome = "welcome"
+	FrameHistory = "history"
+	FrameAck     = "ack"
+	FrameError   = "error"
+	FramePong    = "pong"
+)
+
+// Error codes sent in error frames
+const (
+	ErrorInvalidFrame    = "invalid_frame"
+	ErrorUnsupportedType = "unsupported_type"
+	ErrorFeatureDisabled = "feature_disabled"
+	ErrorRateLimited     = "rate_limited"
+	ErrorInternal        = "internal_error"
+)
+
+// ClientFrame is a frame sent by a client. Fields beyond the type and client
+// ID apply to the frame types noted.
+type ClientFrame struct {
+	Type     string `json:"type"`
+	ClientID string `json:"client_id,omitempty"` // Generated by the client to correlate acks and dedupe resends
+
+	// Message frames
+	Content   string         `json:"content,omitempty"`
+	MediaURLs []string       `json:"media_urls,omitempty"`
+	Location  *FrameLocation `json:"location,omitempty"`
+	ReplyToID string         `json:"reply_to_id,omitempty"`
+
+	// Reaction frames
+	MessageID string `json:"message_id,omitempty"`
+	Reaction  string `json:"reaction,omitempty"`
+
+	// Typing frames
+	IsTyping bool `json:"is_typing,omitempty"`
+}
+
+// FrameLocation is a location as sent in frames
+type FrameLocation struct {
+	Latitude  float64   `json:"latitude"`
+	Longitude float64   `json:"longitude"`
+	Accuracy  float64   `json:"accuracy"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// WelcomeFrame is the first frame sent on a connection
+type WelcomeFrame struct {
+	Type            string                 `json:"type"`
+	ProtocolVersion int                    `json:"protocol_version"`
+	SpaceID         string                 `json:"space_id"`
+	Identity        map[string]interface{} `json:"identity"`
+	Features        []string               `json:"features"`
+	Time            time.Time              `json:"time"`
+}
+
+// HistoryFrame carries recent messages sent after the welcome
+type HistoryFrame struct {
+	Type     string         `json:"type"`
+	Messages []MessageFrame `json:"messages"`
+}
+
+// AckFrame confirms a client frame was accepted. Messages and reactions are
+// stored before they are acked.
+type AckFrame struct {
+	Type     string    `json:"type"`
+	ClientID string    `json:"client_id"`
+	ID       string    `json:"id,omitempty"` // ID the message was stored under, for messages
+	Time     time.Time `json:"time"`
+}
+
+// ErrorFrame reports why a client frame was rejected
+type ErrorFrame struct {
+	Type     string     `json:"type"`
+	ClientID string     `json:"client_id,omitempty"`
+	Code     string     `json:"code"`
+	Message  string     `json:"message"`
+	Feature  string     `json:"feature,omitempty"`
+	RetryAt  *time.Time `json:"retry_at,omitempty"`
+	Time     time.Time  `json:"time"`
+}
+
+// PongFrame answers a client ping
+type PongFrame struct {
+	Type     string    `json:"type"`
+	ClientID string    `json:"client_id,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// MessageFrame is a chat message relayed to a space
+type MessageFrame struct {
+	Type      string                 `json:"type"`
+	ID        string                 `json:"id"`
+	ClientID  string                 `json:"client_id,omitempty"`
+	Identity  map[string]interface{} `json:"identity"`
+	Content   string                 `json:"content,omitempty"`
+	MediaURLs []string               `json:"media_urls,omitempty"`
+	Location  *FrameLocation         `json:"location,omitempty"`
+	ReplyToID string                 `json:"reply_to_id,omitempty"`
+	Reactions map[string]int         `json:"reactions,omitempty"`
+	Time      time.Time              `json:"time"`
+}
+
+// ReactionFrame is a reaction relayed to a space
+type ReactionFrame struct {
+	Type      string                 `json:"type"`
+	Identity  map[string]interface{} `json:"identity"`
+	MessageID string                 `json:"message_id"`
+	Reaction  string                 `json:"reaction"`
+	Time      time.Time              `json:"time"`
+}
+
+// TypingFrame is a typing indicator relayed to a space
+type TypingFrame struct {
+	Type     string                 `json:"type"`
+	Identity map[string]interface{} `json:"identity"`
+	IsTyping bool                   `json:"is_typing"`
+	Time     time.Time              `json:"time"`
+}
+
+// negotiateProtocol picks the protocol version for a client's request, which
+// defaults to the newest version when the client doesn't ask for one
+func negotiateProtocol(requested string) (int, error) {
+	if requested == "" {
+		return ProtocolVersion, nil
+	}
+
+	version, err := strconv.Atoi(requested)
+	if err != nil {
+		return 0, fmt.Errorf("invalid protocol version %q", requested)
+	}
+	if version < MinProtocolVersion {
+		return 0, fmt.Errorf("protocol version %d is no longer supported, minimum is %d", version, MinProtocolVersion)
+	}
+	if version > ProtocolVersion {
+		return ProtocolVersion, nil
+	}
+
+	return version, nil
+}
+
+// validate checks a client frame has what its type needs
+func (f *ClientFrame) validate() error {
+	if len(f.ClientID) > maxClientIDLength {
+		return fmt.Errorf("client_id is longer than %d characters", maxClientIDLength)
+	}
+
+	switch f.Type {
+	case FrameMessage:
+		if f.Content == "" && len(f.MediaURLs) == 0 {
+			return fmt.Errorf("message needs content or media")
+		}
+	case FrameReaction:
+		if f.MessageID == "" || f.Reaction == "" {
+			return fmt.Errorf("reaction needs message_id and reaction")
+		}
+	}
+
+	return nil
+}
+
+// toLocation converts a frame location, returning nil for none
+func (l *FrameLocation) toLocation() *trend.Location {
+	if l == nil {
+		return nil
+	}
+
+	return &trend.Location{
+		Latitude:  l.Latitude,
+		Longitude: l.Longitude,
+		Accuracy:  l.Accuracy,
+		Timestamp: l.Timestamp,
+	}
+}
+
+// frameLocation converts a location for a frame, returning nil for none
+func frameLocation(l *trend.Location) *FrameLocation {
+	if l == nil {
+		return nil
+	}
+
+	return &FrameLocation{
+		Latitude:  l.Latitude,
+		Longitude: l.Longitude,
+		Accuracy:  l.Accuracy,
+		Timestamp: l.Timestamp,
+	}
+}
@@ -0,0 +1,69 @@
+// internal/server/handlers/protocol_test.go
+
+package handlers
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestNegotiateProtocol(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested string
+		want      int
+		wantErr   bool
+	}{
+		{name: "not requested", requested: "", want: ProtocolVersion},
+		{name: "oldest supported", requested: strconv.Itoa(MinProtocolVersion), want: MinProtocolVersion},
+		{name: "current", requested: strconv.Itoa(ProtocolVersion), want: ProtocolVersion},
+		{name: "newer than the server", requested: strconv.Itoa(ProtocolVersion + 1), want: ProtocolVersion},
+		{name: "older than supported", requested: strconv.Itoa(MinProtocolVersion - 1), wantErr: true},
+		{name: "negative", requested: "-1", wantErr: true},
+		{name: "not a number", requested: "v1", wantErr: true},
+		{name: "padded", requested: " 1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := negotiateProtocol(tt.requested)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("negotiateProtocol(%q) = %d, want an error", tt.requested, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("negotiateProtocol(%q): %v", tt.requested, err)
+			}
+			if got != tt.want {
+				t.Errorf("negotiateProtocol(%q) = %d, want %d", tt.requested, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientFrameValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		frame   ClientFrame
+		wantErr bool
+	}{
+		{name: "message with content", frame: ClientFrame{Type: FrameMessage, Content: "hello"}},
+		{name: "message with media", frame: ClientFrame{Type: FrameMessage, MediaURLs: []string{"https://example.com/a.png"}}},
+		{name: "empty message", frame: ClientFrame{Type: FrameMessage}, wantErr: true},
+		{name: "reaction", frame: ClientFrame{Type: FrameReaction, MessageID: "m1", Reaction: "like"}},
+		{name: "reaction without message", frame: ClientFrame{Type: FrameReaction, Reaction: "like"}, wantErr: true},
+		{name: "ping", frame: ClientFrame{Type: FramePing}},
+		{name: "long client id", frame: ClientFrame{Type: FramePing, ClientID: strings.Repeat("x", maxClientIDLength+1)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.frame.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
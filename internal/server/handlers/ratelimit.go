@@ -0,0 +1,24 @@
+// internal/server/handlers/ratelimit.go
+
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// respondWithRateLimited rejects a request over its rate limit
+func respondWithRateLimited(w http.ResponseWriter, resetAt time.Time) {
+	retryAfter := int(math.Ceil(time.Until(resetAt).Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	respondWithJSON(w, http.StatusTooManyRequests, map[string]interface{}{
+		"error":    "Rate limit exceeded",
+		"retry_at": resetAt,
+	})
+}
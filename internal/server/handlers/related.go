@@ -0,0 +1,54 @@
+// internal/server/handlers/related.go
+
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"essg/internal/domain/space"
+)
+
+// RelatedSpaceHandler handles related space HTTP requests
+type RelatedSpaceHandler struct {
+	finder space.RelationFinder
+}
+
+// NewRelatedSpaceHandler creates a new related space handler
+func NewRelatedSpaceHandler(finder space.RelationFinder) *RelatedSpaceHandler {
+	return &RelatedSpaceHandler{
+		finder: finder,
+	}
+}
+
+// GetRelatedSpaces returns spaces related to a specific space
+func (h *RelatedSpaceHandler) GetRelatedSpaces(w http.ResponseWriter, r *http.Request) {
+	// Get space ID from URL
+	spaceID := chi.URLParam(r, "id")
+	if spaceID == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing space ID", nil)
+		return
+	}
+
+	// Parse limit (defaulting to, and capped at, the finder's configured maximum)
+	limit := 0
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsedLimit, err := strconv.Atoi(limitStr)
+		if err != nil || parsedLimit <= 0 {
+			respondWithError(w, http.StatusBadRequest, "Invalid limit", err)
+			return
+		}
+		limit = parsedLimit
+	}
+
+	// Get related spaces
+	related, err := h.finder.GetRelatedSpaces(r.Context(), spaceID, limit)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to get related spaces", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, related)
+}
@@ -0,0 +1,51 @@
+// internal/server/handlers/reputation.go
+
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"essg/internal/domain/identity"
+)
+
+// ReputationHandler handles moderation of ephemeral identity reputation
+type ReputationHandler struct {
+	reputation identity.ReputationManager
+}
+
+// NewReputationHandler creates a new reputation handler
+func NewReputationHandler(reputation identity.ReputationManager) *ReputationHandler {
+	return &ReputationHandler{
+		reputation: reputation,
+	}
+}
+
+// RecordStrike records a moderation strike against an identity in a space
+func (h *ReputationHandler) RecordStrike(w http.ResponseWriter, r *http.Request) {
+	// Get space and identity IDs from URL
+	spaceID := chi.URLParam(r, "id")
+	identityID := chi.URLParam(r, "identityID")
+	if spaceID == "" || identityID == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing space or identity ID", nil)
+		return
+	}
+
+	// Record strike
+	ident, err := h.reputation.RecordStrike(r.Context(), spaceID, identityID)
+	if err != nil {
+		if errors.Is(err, identity.ErrIdentityNotFound) {
+			respondWithError(w, http.StatusNotFound, "Identity not found", nil)
+		} else {
+			respondWithError(w, http.StatusInternalServerError, "Failed to record strike", err)
+		}
+		return
+	}
+
+	response := formatIdentity(ident)
+	response["reputation"] = ident.Reputation
+
+	respondWithJSON(w, http.StatusOK, response)
+}
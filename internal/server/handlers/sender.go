@@ -0,0 +1,261 @@
+// internal/server/handlers/sender.go
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+
+	"essg/internal/domain/identity"
+	"essg/internal/domain/messaging"
+	"essg/internal/domain/space"
+)
+
+// SpaceTransportDeps are the services shared by the WebSocket and event stream
+// transports for connecting members to a space
+type SpaceTransportDeps struct {
+	Hub        *Hub
+	NatsConn   *nats.Conn
+	Manager    space.Manager
+	Membership space.MembershipManager
+	Presence   space.PresenceTracker
+	Limiter    messaging.RateLimiter
+	History    messaging.History
+	Privacy    identity.LocationPrivacyManager
+}
+
+// newSender returns a sender for a member's frames into a space
+func (d SpaceTransportDeps) newSender(spaceID, userID string, ident *identity.EphemeralIdentity) spaceSender {
+	return spaceSender{
+		spaceID:    spaceID,
+		userID:     userID,
+		identity:   ident,
+		natsConn:   d.NatsConn,
+		membership: d.Membership,
+		presence:   d.Presence,
+		limiter:    d.Limiter,
+		privacy:    d.Privacy,
+		history:    d.History,
+	}
+}
+
+// spaceSender sends client frames into a space on behalf of a member. It is
+// shared by the WebSocket and event stream transports so both apply the same
+// checks and publish the same events.
+type spaceSender struct {
+	spaceID    string
+	userID     string
+	identity   *identity.EphemeralIdentity // Who the user appears as in this space
+	natsConn   *nats.Conn
+	membership space.MembershipManager
+	presence   space.PresenceTracker
+	limiter    messaging.RateLimiter
+	privacy    identity.LocationPrivacyManager
+	history    messaging.History
+}
+
+// submit processes a message, typing or reaction frame against the space's
+// enabled features, returning the ack to answer with or why it was rejected
+func (s *spaceSender) submit(frame *ClientFrame, features map[string]bool) (*AckFrame, *ErrorFrame) {
+	switch frame.Type {
+	case FrameMessage, FrameTyping, FrameReaction:
+	default:
+		return nil, newErrorFrame(frame.ClientID, ErrorUnsupportedType, fmt.Sprintf("Unsupported frame type %q", frame.Type))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Keep the member's presence fresh while they interact
+	if err := s.membership.TouchPresence(ctx, s.spaceID, s.userID); err != nil {
+		log.Printf("Failed to touch presence: %v", err)
+	}
+
+	// Interacting brings an idle identity back; typing does this itself
+	if frame.Type != FrameTyping {
+		if err := s.presence.Activity(ctx, *s.identity); err != nil {
+			log.Printf("Failed to record activity: %v", err)
+		}
+	}
+
+	// Drop locations in spaces without geo context, and otherwise only share
+	// the sender's location at the precision they allow
+	if !features[space.FeatureGeoContext] {
+		frame.Location = nil
+	}
+	frame.Location = frameLocation(shareLocation(s.privacy, frame.Location.toLocation(), s.identity))
+
+	// Reject interactions the space does not allow
+	if feature := disabledFrameFeature(frame, features); feature != "" {
+		errFrame := newErrorFrame(frame.ClientID, ErrorFeatureDisabled, fmt.Sprintf("%s is not enabled in this space", feature))
+		errFrame.Feature = feature
+		return nil, errFrame
+	}
+
+	// Hold messages and reactions to the sender's rate limit
+	if frame.Type == messaging.ActionMessage || frame.Type == messaging.ActionReaction {
+		allowed, resetAt, err := s.limiter.AllowAction(s.userID, frame.Type, s.spaceID)
+		if err != nil {
+			log.Printf("Failed to check rate limit: %v", err)
+			return nil, newErrorFrame(frame.ClientID, ErrorInternal, "Failed to check rate limit")
+		}
+		if !allowed {
+			errFrame := newErrorFrame(frame.ClientID, ErrorRateLimited, "Rate limit exceeded")
+			errFrame.RetryAt = &resetAt
+			return nil, errFrame
+		}
+	}
+
+	// Process based on frame type
+	var id string
+	var err error
+	now := time.Now()
+	switch frame.Type {
+	case FrameMessage:
+		id, now, err = s.handleChatMessage(ctx, frame, now)
+	case FrameTyping:
+		err = s.presence.SetTyping(ctx, *s.identity, frame.IsTyping)
+	case FrameReaction:
+		err = s.handleReaction(ctx, frame, now)
+	}
+	if errors.Is(err, messaging.ErrMessageNotFound) {
+		return nil, newErrorFrame(frame.ClientID, ErrorInvalidFrame, "Message not found")
+	}
+	if err != nil {
+		log.Printf("Failed to handle %s frame: %v", frame.Type, err)
+		return nil, newErrorFrame(frame.ClientID, ErrorInternal, fmt.Sprintf("Failed to send %s", frame.Type))
+	}
+
+	return &AckFrame{Type: FrameAck, ClientID: frame.ClientID, ID: id, Time: now}, nil
+}
+
+// handleChatMessage stores and then publishes a chat message, returning the ID
+// and time it was stored with. A resent message keeps what it was first stored
+// with and is published again, in case the first publish failed; consumers
+// treat copies with the same ID as one message.
+func (s *spaceSender) handleChatMessage(ctx context.Context, frame *ClientFrame, now time.Time) (string, time.Time, error) {
+	stored, _, err := s.history.SaveMessage(ctx, messaging.Message{
+		ID:                messageID(s.identity, frame.ClientID),
+		SpaceID:           s.spaceID,
+		UserID:            s.userID,
+		EphemeralIdentity: s.identity,
+		Type:              messaging.TypeText,
+		Content:           frame.Content,
+		MediaURLs:         frame.MediaURLs,
+		Location:          frame.Location.toLocation(),
+		ReplyToID:         frame.ReplyToID,
+		Status:            messaging.StatusDelivered,
+		CreatedAt:         now,
+		IsAnonymous:       s.identity.IsAnonymous,
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	msg := MessageFrame{
+		Type:      FrameMessage,
+		ID:        stored.ID,
+		ClientID:  frame.ClientID,
+		Identity:  formatIdentity(s.identity),
+		Content:   frame.Content,
+		MediaURLs: frame.MediaURLs,
+		Location:  frame.Location,
+		ReplyToID: frame.ReplyToID,
+		Time:      stored.CreatedAt,
+	}
+
+	// Publish to NATS for all clients in this space
+	if err := s.publish("messages", msg); err != nil {
+		return "", time.Time{}, err
+	}
+
+	return msg.ID, msg.Time, nil
+}
+
+// handleReaction stores and publishes a message reaction. A reaction the
+// sender already gave is acked without being published again.
+func (s *spaceSender) handleReaction(ctx context.Context, frame *ClientFrame, now time.Time) error {
+	created, err := s.history.SaveReaction(ctx, s.spaceID, frame.MessageID, s.userID, frame.Reaction, now)
+	if err != nil || !created {
+		return err
+	}
+
+	return s.publish("reactions", ReactionFrame{
+		Type:      FrameReaction,
+		Identity:  formatIdentity(s.identity),
+		MessageID: frame.MessageID,
+		Reaction:  frame.Reaction,
+		Time:      now,
+	})
+}
+
+// publish sends a frame to everyone in the space
+func (s *spaceSender) publish(topic string, frame interface{}) error {
+	return publishFrame(s.natsConn, s.spaceID, topic, frame)
+}
+
+// publishFrame sends a frame to everyone in a space
+func publishFrame(natsConn *nats.Conn, spaceID, topic string, frame interface{}) error {
+	data, err := json.Marshal(frame)
+	if err != nil {
+		return fmt.Errorf("error marshaling %s: %w", topic, err)
+	}
+
+	if err := natsConn.Publish(fmt.Sprintf("space.%s.%s", spaceID, topic), data); err != nil {
+		return fmt.Errorf("error publishing %s: %w", topic, err)
+	}
+
+	return nil
+}
+
+// disabledFrameFeature returns a feature a frame needs that is not among the enabled features
+func disabledFrameFeature(frame *ClientFrame, features map[string]bool) string {
+	var required []string
+	switch frame.Type {
+	case FrameMessage:
+		required = messageFeatures(frame.Content != "", len(frame.MediaURLs) > 0)
+	case FrameTyping:
+		required = []string{space.FeatureMessaging}
+	case FrameReaction:
+		required = []string{space.FeatureReactions}
+	}
+
+	for _, id := range required {
+		if !features[id] {
+			return id
+		}
+	}
+	return ""
+}
+
+// newErrorFrame creates an error frame answering a client frame
+func newErrorFrame(clientID, code, message string) *ErrorFrame {
+	return &ErrorFrame{
+		Type:     FrameError,
+		ClientID: clientID,
+		Code:     code,
+		Message:  message,
+		Time:     time.Now(),
+	}
+}
+
+// messageID returns the ID for a message. Messages sent with a client ID get
+// the same ID however often they are resent, so downstream consumers and other
+// connections see duplicates as one message.
+func messageID(ident *identity.EphemeralIdentity, clientID string) string {
+	if clientID == "" {
+		return uuid.New().String()
+	}
+
+	return uuid.NewSHA1(messageIDNamespace, []byte(ident.ID+":"+clientID)).String()
+}
+
+// messageIDNamespace scopes message IDs derived from client IDs
+var messageIDNamespace = uuid.MustParse("5f1d3c2e-8a4b-4e6f-9c0d-2b7a1e3f4d5c")
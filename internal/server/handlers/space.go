@@ -10,7 +10,9 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/nats-io/nats.go"
 
+	"essg/internal/domain/identity"
 	"essg/internal/domain/messaging"
 	"essg/internal/domain/space"
 	"essg/internal/domain/trend"
@@ -18,13 +20,30 @@ import (
 
 // SpaceHandler handles space-related HTTP requests
 type SpaceHandler struct {
-	manager space.Manager
+	manager    space.Manager
+	identities identity.Service
+	limiter    messaging.RateLimiter
+	history    messaging.History
+	privacy    identity.LocationPrivacyManager
+	natsConn   *nats.Conn
 }
 
 // NewSpaceHandler creates a new space handler
-func NewSpaceHandler(manager space.Manager) *SpaceHandler {
+func NewSpaceHandler(
+	manager space.Manager,
+	identities identity.Service,
+	limiter messaging.RateLimiter,
+	history messaging.History,
+	privacy identity.LocationPrivacyManager,
+	natsConn *nats.Conn,
+) *SpaceHandler {
 	return &SpaceHandler{
-		manager: manager,
+		manager:    manager,
+		identities: identities,
+		limiter:    limiter,
+		history:    history,
+		privacy:    privacy,
+		natsConn:   natsConn,
 	}
 }
 
@@ -94,15 +113,23 @@ func (h *SpaceHandler) ListSpaces(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusOK, spaces)
 }
 
-// CreateSpace creates a new space
+// CreateSpace creates a new user-initiated space
 func (h *SpaceHandler) CreateSpace(w http.ResponseWriter, r *http.Request) {
+	// Spaces are owned by the authenticated user
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
 	// Define request body struct
 	type createSpaceRequest struct {
-		Title       string          `json:"title"`
-		Description string          `json:"description"`
-		TopicTags   []string        `json:"topic_tags"`
-		Location    *trend.Location `json:"location"`
-		IsGeoLocal  bool            `json:"is_geo_local"`
+		TemplateType   string          `json:"template_type"`
+		Title          string          `json:"title"`
+		Description    string          `json:"description"`
+		TopicTags      []string        `json:"topic_tags"`
+		Location       *trend.Location `json:"location"`
+		LocationRadius float64         `json:"location_radius"`
 	}
 
 	// Parse request body
@@ -112,25 +139,83 @@ func (h *SpaceHandler) CreateSpace(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Create a simple trend from the request
-	t := trend.Trend{
-		Topic:       req.Title,
-		Description: req.Description,
-		Keywords:    req.TopicTags,
-		Location:    req.Location,
-		IsGeoLocal:  req.IsGeoLocal,
+	if req.Title == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing title", nil)
+		return
 	}
 
-	// Create space
-	s, err := h.manager.CreateSpace(r.Context(), t)
+	// Create space owned by the requesting user
+	s, err := h.manager.CreateCustomSpace(r.Context(), space.CustomSpaceRequest{
+		TemplateType:   space.TemplateType(req.TemplateType),
+		Title:          req.Title,
+		Description:    req.Description,
+		TopicTags:      req.TopicTags,
+		Location:       req.Location,
+		LocationRadius: req.LocationRadius,
+		OwnerID:        userID,
+	})
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Failed to create space", err)
+		if errors.Is(err, space.ErrInvalidTemplate) {
+			respondWithError(w, http.StatusBadRequest, "Invalid template type", err)
+		} else if errors.Is(err, space.ErrAtCapacity) {
+			respondWithError(w, http.StatusServiceUnavailable, "Space capacity reached, try again later", err)
+		} else {
+			respondWithError(w, http.StatusInternalServerError, "Failed to create space", err)
+		}
 		return
 	}
 
 	respondWithJSON(w, http.StatusCreated, s)
 }
 
+// UpdateSpace updates the curated details of a user-initiated space
+func (h *SpaceHandler) UpdateSpace(w http.ResponseWriter, r *http.Request) {
+	// Get space ID from URL
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing space ID", nil)
+		return
+	}
+
+	// Only the authenticated owner may update a space
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	// Define request body struct
+	type updateSpaceRequest struct {
+		Title       *string  `json:"title"`
+		Description *string  `json:"description"`
+		TopicTags   []string `json:"topic_tags"`
+	}
+
+	// Parse request body
+	var req updateSpaceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	// Update space
+	s, err := h.manager.UpdateSpace(r.Context(), id, userID, space.SpaceUpdate{
+		Title:       req.Title,
+		Description: req.Description,
+		TopicTags:   req.TopicTags,
+	})
+	if err != nil {
+		if errors.Is(err, space.ErrNotOwner) {
+			respondWithError(w, http.StatusForbidden, "Only the space owner can update it", nil)
+		} else {
+			respondWithError(w, http.StatusInternalServerError, "Failed to update space", err)
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, s)
+}
+
 // GetSpace returns a specific space by ID
 func (h *SpaceHandler) GetSpace(w http.ResponseWriter, r *http.Request) {
 	// Get space ID from URL
@@ -143,7 +228,7 @@ func (h *SpaceHandler) GetSpace(w http.ResponseWriter, r *http.Request) {
 	// Get space
 	s, err := h.manager.GetSpace(r.Context(), id)
 	if err != nil {
-		if errors.Is(err, ErrNotFound) {
+		if errors.Is(err, space.ErrSpaceNotFound) {
 			respondWithError(w, http.StatusNotFound, "Space not found", nil)
 		} else {
 			respondWithError(w, http.StatusInternalServerError, "Failed to get space", err)
@@ -216,9 +301,9 @@ func (h *SpaceHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check if space exists
-	_, err := h.manager.GetSpace(r.Context(), spaceID)
+	sp, err := h.manager.GetSpace(r.Context(), spaceID)
 	if err != nil {
-		if errors.Is(err, ErrNotFound) {
+		if errors.Is(err, space.ErrSpaceNotFound) {
 			respondWithError(w, http.StatusNotFound, "Space not found", nil)
 		} else {
 			respondWithError(w, http.StatusInternalServerError, "Failed to get space", err)
@@ -226,10 +311,16 @@ func (h *SpaceHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Messages are always sent as the authenticated user
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
 	// Define request body struct
 	type sendMessageRequest struct {
 		Content     string          `json:"content"`
-		UserID      string          `json:"user_id"`
 		Location    *trend.Location `json:"location"`
 		IsAnonymous bool            `json:"is_anonymous"`
 		MediaURLs   []string        `json:"media_urls"`
@@ -242,28 +333,77 @@ func (h *SpaceHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Create message - in a real implementation, we would use the message service
-	// This is a simplified example that returns a mock response
+	// Reject anything the space's template or moderators have not enabled
+	required := messageFeatures(req.Content != "", len(req.MediaURLs) > 0)
+	if feature := firstDisabledFeature(sp, required); feature != "" {
+		respondWithFeatureDisabled(w, feature)
+		return
+	}
+
+	// Spaces without geo context keep no locations
+	if !sp.HasFeature(space.FeatureGeoContext) {
+		req.Location = nil
+	}
+
+	// Count the message against the sender's rate limit
+	allowed, resetAt, err := h.limiter.AllowAction(userID, messaging.ActionMessage, spaceID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to check rate limit", err)
+		return
+	}
+	if !allowed {
+		respondWithRateLimited(w, resetAt)
+		return
+	}
+
+	// Messages go out under the sender's identity in this space, never their user ID
+	ident, err := h.identities.GetOrCreateEphemeralIdentity(r.Context(), userID, spaceID)
+	if err != nil {
+		if errors.Is(err, space.ErrSpaceClosed) {
+			respondWithError(w, http.StatusGone, "Space has dissolved", nil)
+		} else {
+			respondWithError(w, http.StatusInternalServerError, "Failed to get identity", err)
+		}
+		return
+	}
+
+	// Store the message before anyone is told it was sent
 	message := messaging.Message{
-		SpaceID:     spaceID,
-		UserID:      req.UserID,
-		Type:        messaging.TypeText,
-		Content:     req.Content,
-		MediaURLs:   req.MediaURLs,
-		Location:    req.Location,
-		IsAnonymous: req.IsAnonymous,
-		CreatedAt:   time.Now(),
-		Status:      messaging.StatusDelivered,
+		ID:                messageID(ident, ""),
+		SpaceID:           spaceID,
+		UserID:            userID,
+		EphemeralIdentity: ident,
+		Type:              messaging.TypeText,
+		Content:           req.Content,
+		MediaURLs:         req.MediaURLs,
+		Location:          shareLocation(h.privacy, req.Location, ident),
+		IsAnonymous:       req.IsAnonymous || ident.IsAnonymous,
+		CreatedAt:         time.Now(),
+		Status:            messaging.StatusDelivered,
 	}
 
-	// In a real implementation, we would call the message service
-	// response, err := h.messageService.SendMessage(r.Context(), message)
-	// Here we're just mocking a successful response
+	stored, _, err := h.history.SaveMessage(r.Context(), message)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to store message", err)
+		return
+	}
 
-	// Add an ID to the message for the response
-	message.ID = "msg_" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	// Deliver it to the space like messages sent over a connection
+	err = publishFrame(h.natsConn, spaceID, "messages", MessageFrame{
+		Type:      FrameMessage,
+		ID:        stored.ID,
+		Identity:  formatIdentity(ident),
+		Content:   stored.Content,
+		MediaURLs: stored.MediaURLs,
+		Location:  frameLocation(stored.Location),
+		Time:      stored.CreatedAt,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to send message", err)
+		return
+	}
 
-	respondWithJSON(w, http.StatusCreated, message)
+	respondWithJSON(w, http.StatusCreated, formatMessage(*stored))
 }
 
 // GetMessages returns messages for a space
@@ -278,7 +418,7 @@ func (h *SpaceHandler) GetMessages(w http.ResponseWriter, r *http.Request) {
 	// Check if space exists
 	_, err := h.manager.GetSpace(r.Context(), spaceID)
 	if err != nil {
-		if errors.Is(err, ErrNotFound) {
+		if errors.Is(err, space.ErrSpaceNotFound) {
 			respondWithError(w, http.StatusNotFound, "Space not found", nil)
 		} else {
 			respondWithError(w, http.StatusInternalServerError, "Failed to get space", err)
@@ -286,52 +426,100 @@ func (h *SpaceHandler) GetMessages(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse query parameters for filtering
-	limitStr := r.URL.Query().Get("limit")
-	offsetStr := r.URL.Query().Get("offset")
-
-	// Default limit
-	// limit := 50
-	// offset := 0
-
-	if limitStr != "" {
+	// Parse the limit, which the history caps
+	limit := 0
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
 		parsedLimit, err := strconv.Atoi(limitStr)
 		if err == nil && parsedLimit > 0 {
-			// limit = parsedLimit
+			limit = parsedLimit
 		}
 	}
 
-	if offsetStr != "" {
-		parsedOffset, err := strconv.Atoi(offsetStr)
-		if err == nil && parsedOffset >= 0 {
-			// offset = parsedOffset
-		}
+	// Get the latest messages, oldest first
+	messages, err := h.history.GetRecentMessages(r.Context(), spaceID, limit)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to get messages", err)
+		return
+	}
+
+	response := make([]map[string]interface{}, 0, len(messages))
+	for _, message := range messages {
+		response = append(response, formatMessage(message))
 	}
 
-	// In a real implementation, we would call the message service
-	// Here we're just returning mock data
-	messages := []messaging.Message{
-		{
-			ID:        "msg_1",
-			SpaceID:   spaceID,
-			UserID:    "user_1",
-			Type:      messaging.TypeText,
-			Content:   "Hello from the space!",
-			CreatedAt: time.Now().Add(-30 * time.Minute),
-			Status:    messaging.StatusDelivered,
-			Reactions: map[string]int{"👍": 3, "❤️": 2},
-		},
-		{
-			ID:        "msg_2",
-			SpaceID:   spaceID,
-			UserID:    "user_2",
-			Type:      messaging.TypeText,
-			Content:   "This is an interesting discussion.",
-			CreatedAt: time.Now().Add(-25 * time.Minute),
-			Status:    messaging.StatusDelivered,
-			Reactions: map[string]int{"👍": 1},
-		},
-	}
-
-	respondWithJSON(w, http.StatusOK, messages)
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// GetIdentities returns the identities of a space's current members
+func (h *SpaceHandler) GetIdentities(w http.ResponseWriter, r *http.Request) {
+	// Get space ID from URL
+	spaceID := chi.URLParam(r, "id")
+	if spaceID == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing space ID", nil)
+		return
+	}
+
+	// Get identities
+	identities, err := h.identities.GetIdentitiesInSpace(r.Context(), spaceID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to get identities", err)
+		return
+	}
+
+	response := make([]map[string]interface{}, 0, len(identities))
+	for i := range identities {
+		response = append(response, formatIdentity(&identities[i]))
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// shareLocation reduces a location sent under an identity to the precision the
+// identity shares, which drops it entirely when sharing is disabled
+func shareLocation(
+	privacy identity.LocationPrivacyManager,
+	location *trend.Location,
+	ident *identity.EphemeralIdentity,
+) *trend.Location {
+	if location == nil || ident == nil {
+		return nil
+	}
+
+	return privacy.ApplyPrivacySettings(*location, ident.LocationShareLevel, identity.LocationScope{
+		UserID:  ident.UserID,
+		SpaceID: ident.SpaceID,
+	})
+}
+
+// formatMessage formats a message for a response. Senders are only shown by
+// their ephemeral identity so they can't be followed from space to space.
+func formatMessage(message messaging.Message) map[string]interface{} {
+	return map[string]interface{}{
+		"id":           message.ID,
+		"space_id":     message.SpaceID,
+		"identity":     formatIdentity(message.EphemeralIdentity),
+		"type":         message.Type,
+		"content":      message.Content,
+		"media_urls":   message.MediaURLs,
+		"reply_to_id":  message.ReplyToID,
+		"status":       message.Status,
+		"created_at":   message.CreatedAt,
+		"reactions":    message.Reactions,
+		"location":     message.Location,
+		"is_anonymous": message.IsAnonymous,
+	}
+}
+
+// formatIdentity formats the public parts of an ephemeral identity
+func formatIdentity(ident *identity.EphemeralIdentity) map[string]interface{} {
+	if ident == nil {
+		return nil
+	}
+
+	return map[string]interface{}{
+		"id":           ident.ID,
+		"nickname":     ident.Nickname,
+		"avatar":       ident.Avatar,
+		"is_anonymous": ident.IsAnonymous,
+	}
 }
@@ -0,0 +1,454 @@
+// internal/server/handlers/stream.go
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"essg/internal/domain/space"
+)
+
+// StreamConfig contains configuration for Server-Sent Events streams
+type StreamConfig struct {
+	// Time allowed to write an event to the client
+	WriteWait time.Duration
+
+	// Send a comment to keep idle connections and proxies open with this period
+	HeartbeatInterval time.Duration
+
+	// Events buffered for a client before it counts as too slow
+	BufferSize int
+
+	// Events read from the log per query when resuming
+	ReplayBatch int
+}
+
+// DefaultStreamConfig returns the default stream configuration
+func DefaultStreamConfig() StreamConfig {
+	return StreamConfig{
+		WriteWait:         10 * time.Second,
+		HeartbeatInterval: 15 * time.Second,
+		BufferSize:        256,
+		ReplayBatch:       500,
+	}
+}
+
+// StreamHandler serves a space's events as Server-Sent Events, for clients
+// that can't hold a WebSocket open, and accepts the frames they send back.
+// Events come from the same hub as WebSocket connections. Logged events are
+// taken as the event log republishes them, with their position in the log as
+// their id, so a reconnecting client resumes from there via Last-Event-ID.
+type StreamHandler struct {
+	deps   SpaceTransportDeps
+	events space.EventLog
+	config StreamConfig
+}
+
+// NewStreamHandler creates a new stream handler
+func NewStreamHandler(deps SpaceTransportDeps, events space.EventLog, config StreamConfig) *StreamHandler {
+	return &StreamHandler{
+		deps:   deps,
+		events: events,
+		config: config,
+	}
+}
+
+// Stream streams a space's events until the client goes away
+func (h *StreamHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	// Get space ID from URL
+	spaceID := chi.URLParam(r, "id")
+	if spaceID == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing space ID", nil)
+		return
+	}
+
+	// Get user ID from the authentication token
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	// Browsers send Last-Event-ID when reconnecting; first connections may pass it as a parameter
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	var resumeAfter int64
+	if lastEventID != "" {
+		seq, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || seq < 0 {
+			respondWithError(w, http.StatusBadRequest, "Invalid Last-Event-ID", nil)
+			return
+		}
+		resumeAfter = seq
+	}
+
+	// Connecting joins the space, creating the user's ephemeral identity if needed
+	ident, err := h.deps.Membership.JoinSpace(r.Context(), spaceID, userID)
+	if err != nil {
+		respondWithMembershipError(w, "Failed to join space", err)
+		return
+	}
+
+	// Load the space for its enabled features
+	sp, err := h.deps.Manager.GetSpace(r.Context(), spaceID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to get space", err)
+		return
+	}
+
+	// The stream outlives the server's write timeout, so each write gets its own deadline
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Streaming unsupported", err)
+		return
+	}
+
+	// Follow the space's events through the hub before replaying, so nothing
+	// published in between is missed
+	client := &streamClient{
+		userID:  userID,
+		events:  make(chan streamEvent, h.config.BufferSize),
+		done:    make(chan struct{}),
+		metrics: r.URL.Query().Get("metrics") == "true",
+	}
+	if err := h.deps.Hub.register(spaceID, client); err != nil {
+		respondWithError(w, http.StatusServiceUnavailable, "Streaming unavailable", err)
+		return
+	}
+	defer h.deps.Hub.unregister(spaceID, client)
+
+	// Announce the identity to the space once it is connected anywhere
+	if err := h.deps.Presence.Connect(r.Context(), *ident); err != nil {
+		log.Printf("Failed to record presence: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := h.deps.Presence.Disconnect(ctx, *ident); err != nil {
+			log.Printf("Failed to remove presence: %v", err)
+		}
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	stream := &eventWriter{w: w, rc: rc, writeWait: h.config.WriteWait}
+
+	// Send welcome message
+	welcome, _ := json.Marshal(WelcomeFrame{
+		Type:            FrameWelcome,
+		ProtocolVersion: ProtocolVersion,
+		SpaceID:         spaceID,
+		Identity:        formatIdentity(ident),
+		Features:        sp.EnabledFeatures(),
+		Time:            time.Now(),
+	})
+	if err := stream.write("", welcome); err != nil {
+		return
+	}
+
+	// Replay what the client missed; live events already replayed are skipped below
+	var replayedUntil int64
+	if lastEventID != "" {
+		replayedUntil, err = h.replay(r.Context(), stream, spaceID, resumeAfter)
+		if err != nil {
+			log.Printf("Failed to replay events for space %s: %v", spaceID, err)
+			return
+		}
+	}
+
+	log.Printf("New event stream for space %s from user %s", spaceID, userID)
+
+	heartbeat := time.NewTicker(h.config.HeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			log.Printf("Event stream closed for space %s, user %s", spaceID, userID)
+			return
+
+		case <-client.done:
+			// Send what was queued first, such as a space's dissolved event;
+			// otherwise the client reconnects and resumes from its last event id
+			for n := len(client.events); n > 0; n-- {
+				id, data, ok := liveEvent(<-client.events, replayedUntil)
+				if !ok {
+					continue
+				}
+				if err := stream.write(id, data); err != nil {
+					return
+				}
+			}
+			stream.comment("closing: " + client.reason)
+			return
+
+		case event := <-client.events:
+			id, data, ok := liveEvent(event, replayedUntil)
+			if !ok {
+				continue
+			}
+			if err := stream.write(id, data); err != nil {
+				return
+			}
+
+		case <-heartbeat.C:
+			if err := stream.comment("heartbeat"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// replay sends the logged events after a position, returning the position of the last one sent
+func (h *StreamHandler) replay(ctx context.Context, stream *eventWriter, spaceID string, afterSeq int64) (int64, error) {
+	after := afterSeq
+	for {
+		events, err := h.events.EventsSince(ctx, spaceID, after, h.config.ReplayBatch)
+		if err != nil {
+			return after, fmt.Errorf("error getting events: %w", err)
+		}
+
+		for _, event := range events {
+			if err := stream.write(eventID(event.Seq), event.Data); err != nil {
+				return after, err
+			}
+			after = event.Seq
+		}
+
+		if len(events) < h.config.ReplayBatch {
+			return after, nil
+		}
+	}
+}
+
+// Send accepts a message, typing or reaction frame from a streaming client,
+// answering with the same ack or error frame a WebSocket client would get
+func (h *StreamHandler) Send(w http.ResponseWriter, r *http.Request) {
+	// Get space ID from URL
+	spaceID := chi.URLParam(r, "id")
+	if spaceID == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing space ID", nil)
+		return
+	}
+
+	// Get user ID from the authentication token
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "Authentication required", nil)
+		return
+	}
+
+	// Parse frame
+	var frame ClientFrame
+	if err := json.NewDecoder(r.Body).Decode(&frame); err != nil {
+		respondWithJSON(w, http.StatusBadRequest, newErrorFrame("", ErrorInvalidFrame, "Frame is not valid JSON"))
+		return
+	}
+	if err := frame.validate(); err != nil {
+		respondWithJSON(w, http.StatusBadRequest, newErrorFrame(frame.ClientID, ErrorInvalidFrame, err.Error()))
+		return
+	}
+
+	if frame.Type == FramePing {
+		respondWithJSON(w, http.StatusOK, PongFrame{Type: FramePong, ClientID: frame.ClientID, Time: time.Now()})
+		return
+	}
+
+	// Sending as a member uses the user's identity in the space, joining only
+	// if the user isn't a member yet
+	ident, err := h.deps.Membership.GetMember(r.Context(), spaceID, userID)
+	if errors.Is(err, space.ErrNotMember) {
+		ident, err = h.deps.Membership.JoinSpace(r.Context(), spaceID, userID)
+	}
+	if err != nil {
+		respondWithMembershipError(w, "Failed to join space", err)
+		return
+	}
+
+	sp, err := h.deps.Manager.GetSpace(r.Context(), spaceID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to get space", err)
+		return
+	}
+
+	features := make(map[string]bool)
+	for _, id := range sp.EnabledFeatures() {
+		features[id] = true
+	}
+
+	sender := h.deps.newSender(spaceID, userID, ident)
+
+	ack, errFrame := sender.submit(&frame, features)
+	if errFrame != nil {
+		respondWithJSON(w, errorFrameStatus(errFrame.Code), errFrame)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, ack)
+}
+
+// errorFrameStatus returns the HTTP status matching an error frame code
+func errorFrameStatus(code string) int {
+	switch code {
+	case ErrorInvalidFrame, ErrorUnsupportedType:
+		return http.StatusBadRequest
+	case ErrorFeatureDisabled:
+		return http.StatusForbidden
+	case ErrorRateLimited:
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// streamEvent is a space event waiting to be written to a stream
+type streamEvent struct {
+	topic string
+	data  []byte
+}
+
+// streamClient receives a stream's events from the hub
+type streamClient struct {
+	userID   string
+	events   chan streamEvent
+	done     chan struct{} // Closed when the stream should end
+	metrics  bool          // Opted in to engagement metrics
+	stopOnce sync.Once
+	reason   string
+}
+
+// deliver queues an event from the hub without blocking, returning false if the buffer is full
+func (c *streamClient) deliver(topic string, data []byte) bool {
+	if topic == "metrics" && !c.metrics {
+		return true
+	}
+
+	select {
+	case c.events <- streamEvent{topic: topic, data: data}:
+		return true
+	default:
+		return false
+	}
+}
+
+// disconnect asks the stream to end. Streams have no close codes, so only the
+// first reason is kept, for the comment sent before closing.
+func (c *streamClient) disconnect(code int, reason string) {
+	c.stopOnce.Do(func() {
+		c.reason = reason
+		close(c.done)
+	})
+}
+
+// user returns the ID of the user who opened the stream
+func (c *streamClient) user() string {
+	return c.userID
+}
+
+// eventWriter writes Server-Sent Events, flushing each one
+type eventWriter struct {
+	w         http.ResponseWriter
+	rc        *http.ResponseController
+	writeWait time.Duration
+}
+
+// write sends an event with an optional id
+func (s *eventWriter) write(id string, data []byte) error {
+	var b strings.Builder
+	if id != "" {
+		b.WriteString("id: " + id + "\n")
+	}
+	// Events are single-line JSON, but guard against embedded newlines all the same
+	for _, line := range strings.Split(string(data), "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteString("\n")
+
+	return s.send(b.String())
+}
+
+// comment sends a comment line, which clients ignore
+func (s *eventWriter) comment(text string) error {
+	return s.send(": " + text + "\n\n")
+}
+
+// send writes and flushes raw stream content within the write deadline
+func (s *eventWriter) send(content string) error {
+	if err := s.rc.SetWriteDeadline(time.Now().Add(s.writeWait)); err != nil {
+		return err
+	}
+	if _, err := s.w.Write([]byte(content)); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// loggedTopics are the topics streams take from the event log rather than directly
+var loggedTopics = func() map[string]bool {
+	topics := make(map[string]bool, len(space.LoggedEventTopics))
+	for _, topic := range space.LoggedEventTopics {
+		topics[topic] = true
+	}
+	return topics
+}()
+
+// liveEvent returns the id and data to stream for an event from the hub, or
+// false if it should be skipped. Logged events are streamed from the event
+// log's copy, with their position as their id, unless they were already
+// replayed; events the log couldn't store and unlogged ones such as typing
+// go out without an id, leaving the client's resume position where it was.
+// A space's dissolved event is the exception: the hub ends the stream as soon
+// as it is published, so it is streamed as published and its copy skipped.
+func liveEvent(event streamEvent, replayedUntil int64) (string, []byte, bool) {
+	if loggedTopics[event.topic] {
+		if event.topic == "lifecycle" && isDissolvedEvent(event.data) {
+			return "", event.data, true
+		}
+		return "", nil, false
+	}
+	if event.topic != space.EventLogTopic {
+		return "", event.data, true
+	}
+
+	var logged struct {
+		Seq   int64           `json:"seq"`
+		Topic string          `json:"topic"`
+		Event json.RawMessage `json:"event"`
+	}
+	if err := json.Unmarshal(event.data, &logged); err != nil || len(logged.Event) == 0 {
+		log.Printf("Failed to parse logged event: %v", err)
+		return "", nil, false
+	}
+	if logged.Topic == "lifecycle" && isDissolvedEvent(logged.Event) {
+		return "", nil, false
+	}
+	if logged.Seq == 0 {
+		return "", logged.Event, true
+	}
+	if logged.Seq <= replayedUntil {
+		return "", nil, false
+	}
+
+	return eventID(logged.Seq), logged.Event, true
+}
+
+// eventID returns the stream id for an event at a position in the log
+func eventID(seq int64) string {
+	return strconv.FormatInt(seq, 10)
+}
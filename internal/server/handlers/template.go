@@ -0,0 +1,136 @@
+// internal/server/handlers/template.go
+
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"essg/internal/domain/space"
+	"essg/internal/domain/trend"
+)
+
+// maxExplainSources caps how many sources a hypothetical trend can claim, since
+// template rules only compare the count against small thresholds
+const maxExplainSources = 1000
+
+// TemplateHandler handles template selection HTTP requests
+type TemplateHandler struct {
+	detector trend.Detector
+	selector space.TemplateSelector
+}
+
+// NewTemplateHandler creates a new template handler
+func NewTemplateHandler(detector trend.Detector, selector space.TemplateSelector) *TemplateHandler {
+	return &TemplateHandler{
+		detector: detector,
+		selector: selector,
+	}
+}
+
+// ExplainTrendTemplate explains which template a detected trend gets and why
+func (h *TemplateHandler) ExplainTrendTemplate(w http.ResponseWriter, r *http.Request) {
+	// Get trend ID from URL
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing trend ID", nil)
+		return
+	}
+
+	// Get trend
+	t, err := h.detector.GetTrendByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			respondWithError(w, http.StatusNotFound, "Trend not found", nil)
+		} else {
+			respondWithError(w, http.StatusInternalServerError, "Failed to get trend", err)
+		}
+		return
+	}
+
+	h.respondWithExplanation(w, r, *t)
+}
+
+// ExplainTemplate explains which template a hypothetical trend would get, for
+// trying out template selection rules
+func (h *TemplateHandler) ExplainTemplate(w http.ResponseWriter, r *http.Request) {
+	// Define request body struct
+	type explainRequest struct {
+		Topic       string             `json:"topic"`
+		Keywords    []string           `json:"keywords"`
+		Score       float64            `json:"score"`
+		Velocity    float64            `json:"velocity"`
+		SourceCount int                `json:"source_count"`
+		IsGeoLocal  bool               `json:"is_geo_local"`
+		EntityTypes map[string]float64 `json:"entity_types"`
+	}
+
+	// Parse request body
+	var req explainRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if req.SourceCount < 0 || req.SourceCount > maxExplainSources {
+		respondWithError(w, http.StatusBadRequest, "Invalid source_count", nil)
+		return
+	}
+
+	t := trend.Trend{
+		Topic:       req.Topic,
+		Keywords:    req.Keywords,
+		Score:       req.Score,
+		Velocity:    req.Velocity,
+		Sources:     make([]trend.Source, req.SourceCount),
+		IsGeoLocal:  req.IsGeoLocal,
+		EntityTypes: req.EntityTypes,
+	}
+
+	h.respondWithExplanation(w, r, t)
+}
+
+// respondWithExplanation scores the templates for a trend and writes the result
+func (h *TemplateHandler) respondWithExplanation(w http.ResponseWriter, r *http.Request, t trend.Trend) {
+	explanation, err := h.selector.ExplainTemplateSelection(r.Context(), t)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to explain template selection", err)
+		return
+	}
+
+	// Format candidates for response
+	candidates := make([]map[string]interface{}, 0, len(explanation.Candidates))
+	for _, candidate := range explanation.Candidates {
+		rules := make([]map[string]interface{}, 0, len(candidate.Rules))
+		for _, rule := range candidate.Rules {
+			rules = append(rules, map[string]interface{}{
+				"field":    rule.Field,
+				"op":       rule.Op,
+				"expected": rule.Expected,
+				"actual":   rule.Actual,
+				"weight":   rule.Weight,
+				"required": rule.Required,
+				"matched":  rule.Matched,
+			})
+		}
+
+		candidates = append(candidates, map[string]interface{}{
+			"template_type": candidate.TemplateType,
+			"score":         candidate.Score,
+			"min_score":     candidate.MinScore,
+			"eligible":      candidate.Eligible,
+			"reason":        candidate.Reason,
+			"rules":         rules,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"trend_id":   explanation.TrendID,
+		"selected":   explanation.Selected,
+		"fallback":   explanation.Fallback,
+		"candidates": candidates,
+	})
+}
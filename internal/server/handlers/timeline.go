@@ -0,0 +1,248 @@
+// internal/server/handlers/timeline.go
+
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"essg/internal/domain/space"
+)
+
+// TimelineHandler handles space timeline HTTP requests. Its write endpoints are
+// mounted both publicly, for trusted identities, and under the admin API, for
+// moderators acting on behalf of the user named in the request body.
+type TimelineHandler struct {
+	timeline space.Timeline
+}
+
+// NewTimelineHandler creates a new timeline handler
+func NewTimelineHandler(timeline space.Timeline) *TimelineHandler {
+	return &TimelineHandler{
+		timeline: timeline,
+	}
+}
+
+// GetTimeline returns a space's pinned updates so latecomers can catch up
+func (h *TimelineHandler) GetTimeline(w http.ResponseWriter, r *http.Request) {
+	// Get space ID from URL
+	spaceID := chi.URLParam(r, "id")
+	if spaceID == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing space ID", nil)
+		return
+	}
+
+	// Parse since, to fetch only updates pinned after what the client has seen
+	var since time.Time
+	if sinceStr := r.URL.Query().Get("since"); sinceStr != "" {
+		parsed, err := time.Parse(time.RFC3339, sinceStr)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid since timestamp", err)
+			return
+		}
+		since = parsed
+	}
+
+	// Get timeline
+	entries, err := h.timeline.GetTimeline(r.Context(), spaceID, since)
+	if err != nil {
+		respondWithTimelineError(w, "Failed to get timeline", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, formatTimelineEntries(entries))
+}
+
+// ListSuggestions returns updates suggested from the space's trend sources
+func (h *TimelineHandler) ListSuggestions(w http.ResponseWriter, r *http.Request) {
+	// Get space ID from URL
+	spaceID := chi.URLParam(r, "id")
+	if spaceID == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing space ID", nil)
+		return
+	}
+
+	// Get suggestions
+	entries, err := h.timeline.ListSuggestions(r.Context(), spaceID)
+	if err != nil {
+		respondWithTimelineError(w, "Failed to list suggestions", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, formatTimelineEntries(entries))
+}
+
+// PinUpdate adds an update to a space's timeline
+func (h *TimelineHandler) PinUpdate(w http.ResponseWriter, r *http.Request) {
+	// Get space ID from URL
+	spaceID := chi.URLParam(r, "id")
+	if spaceID == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing space ID", nil)
+		return
+	}
+
+	// Define request body struct
+	type pinUpdateRequest struct {
+		UserID         string    `json:"user_id"`
+		Content        string    `json:"content"`
+		SourceURL      string    `json:"source_url"`
+		SourcePlatform string    `json:"source_platform"`
+		OccurredAt     time.Time `json:"occurred_at"`
+	}
+
+	// Parse request body
+	var req pinUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	// Pin update
+	entry, err := h.timeline.PinUpdate(
+		r.Context(),
+		spaceID,
+		curatorFor(r, req.UserID),
+		space.TimelineUpdate{
+			Content:        req.Content,
+			SourceURL:      req.SourceURL,
+			SourcePlatform: req.SourcePlatform,
+			OccurredAt:     req.OccurredAt,
+		},
+	)
+	if err != nil {
+		respondWithTimelineError(w, "Failed to pin update", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, formatTimelineEntry(*entry))
+}
+
+// AcceptSuggestion pins a suggested update
+func (h *TimelineHandler) AcceptSuggestion(w http.ResponseWriter, r *http.Request) {
+	// Get space and entry IDs from URL
+	spaceID := chi.URLParam(r, "id")
+	entryID := chi.URLParam(r, "entryID")
+	if spaceID == "" || entryID == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing space or entry ID", nil)
+		return
+	}
+
+	// Define request body struct; content optionally rewords the suggestion
+	type acceptRequest struct {
+		UserID  string `json:"user_id"`
+		Content string `json:"content"`
+	}
+
+	// Parse request body
+	var req acceptRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	// Accept suggestion
+	curator := curatorFor(r, req.UserID)
+	entry, err := h.timeline.AcceptSuggestion(r.Context(), spaceID, entryID, curator, req.Content)
+	if err != nil {
+		respondWithTimelineError(w, "Failed to accept suggestion", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, formatTimelineEntry(*entry))
+}
+
+// DismissSuggestion discards a suggested update
+func (h *TimelineHandler) DismissSuggestion(w http.ResponseWriter, r *http.Request) {
+	// Get space and entry IDs from URL
+	spaceID := chi.URLParam(r, "id")
+	entryID := chi.URLParam(r, "entryID")
+	if spaceID == "" || entryID == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing space or entry ID", nil)
+		return
+	}
+
+	// Define request body struct; only moderators name a user
+	type dismissRequest struct {
+		UserID string `json:"user_id"`
+	}
+
+	// Parse request body
+	var req dismissRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	// Dismiss suggestion
+	curator := curatorFor(r, req.UserID)
+	if err := h.timeline.DismissSuggestion(r.Context(), spaceID, entryID, curator); err != nil {
+		respondWithTimelineError(w, "Failed to dismiss suggestion", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"space_id":  spaceID,
+		"entry_id":  entryID,
+		"dismissed": true,
+	})
+}
+
+// curatorFor returns who is curating a timeline: moderators name a user in the
+// request body, everyone else curates as the authenticated user
+func curatorFor(r *http.Request, bodyUserID string) space.Curator {
+	if isModerator(r) {
+		return space.Curator{UserID: bodyUserID, Moderator: true}
+	}
+
+	userID, _ := UserIDFromContext(r.Context())
+	return space.Curator{UserID: userID}
+}
+
+// formatTimelineEntries formats timeline entries for a response
+func formatTimelineEntries(entries []space.TimelineEntry) []map[string]interface{} {
+	response := make([]map[string]interface{}, 0, len(entries))
+	for _, entry := range entries {
+		response = append(response, formatTimelineEntry(entry))
+	}
+	return response
+}
+
+// formatTimelineEntry formats a timeline entry for a response
+func formatTimelineEntry(entry space.TimelineEntry) map[string]interface{} {
+	return map[string]interface{}{
+		"id":                 entry.ID,
+		"space_id":           entry.SpaceID,
+		"status":             entry.Status,
+		"content":            entry.Content,
+		"source_url":         entry.SourceURL,
+		"source_platform":    entry.SourcePlatform,
+		"author_identity_id": entry.AuthorIdentityID,
+		"occurred_at":        entry.OccurredAt,
+		"created_at":         entry.CreatedAt,
+		"pinned_at":          entry.PinnedAt,
+	}
+}
+
+// respondWithTimelineError maps timeline errors to HTTP statuses
+func respondWithTimelineError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, space.ErrFeatureDisabled):
+		respondWithFeatureDisabled(w, space.FeatureTimeline)
+	case errors.Is(err, space.ErrSpaceNotFound):
+		respondWithError(w, http.StatusNotFound, "Space not found", nil)
+	case errors.Is(err, space.ErrEntryNotFound):
+		respondWithError(w, http.StatusNotFound, "Suggestion not found", nil)
+	case errors.Is(err, space.ErrSpaceClosed):
+		respondWithError(w, http.StatusGone, "Space has dissolved", nil)
+	case errors.Is(err, space.ErrInvalidEntry):
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+	case errors.Is(err, space.ErrNotCurator), errors.Is(err, space.ErrNotMember):
+		respondWithError(w, http.StatusForbidden, "Not allowed to curate this space's timeline", nil)
+	default:
+		respondWithError(w, http.StatusInternalServerError, message, err)
+	}
+}
@@ -3,26 +3,38 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"log"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/gorilla/websocket"
-	"github.com/nats-io/nats.go"
+
+	"essg/internal/domain/messaging"
+	"essg/internal/domain/space"
 )
 
 // WebSocketClient represents a connected WebSocket client
 type WebSocketClient struct {
-	conn              *websocket.Conn
-	send              chan []byte
-	spaceID           string
-	userID            string
-	natsConn          *nats.Conn
-	subIDs            []string // Subscription IDs
-	natsSubscriptions []*nats.Subscription
+	spaceSender
+	conn        *websocket.Conn
+	send        chan []byte
+	features    map[string]bool // Enabled features, kept current by feature events
+	metrics     bool            // Opted in to engagement metrics
+	featuresMu  sync.RWMutex
+	acks        *recentAcks // Acks for recent client frames, to answer resends
+	historyMu   sync.Mutex
+	live        bool        // History has been sent, so events go straight out
+	held        []heldEvent // Events from the hub that arrived before the history
+	hub         *Hub
+	done        chan struct{} // Closed when the connection should close
+	stopOnce    sync.Once
+	closeCode   int // Close frame sent when done is closed
+	closeReason string
 }
 
 // WebSocketConfig contains configuration for WebSocket connections
@@ -61,7 +73,7 @@ var upgrader = websocket.Upgrader{
 }
 
 // SpaceWebSocketHandler handles WebSocket connections for real-time space interaction
-func SpaceWebSocketHandler(natsConn *nats.Conn) http.HandlerFunc {
+func SpaceWebSocketHandler(deps SpaceTransportDeps) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Get space ID from URL
 		spaceID := chi.URLParam(r, "id")
@@ -70,11 +82,43 @@ func SpaceWebSocketHandler(natsConn *nats.Conn) http.HandlerFunc {
 			return
 		}
 
-		// Get user ID from query parameters or authentication
-		userID := r.URL.Query().Get("user_id")
-		if userID == "" {
-			// In a real implementation, we would get this from the authentication token
-			http.Error(w, "Missing user ID", http.StatusBadRequest)
+		// Agree on a protocol version before joining
+		protocol, err := negotiateProtocol(r.URL.Query().Get("protocol"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Engagement metrics are only sent to clients that ask for them, such as dashboards
+		metrics := r.URL.Query().Get("metrics") == "true"
+
+		// Get user ID from the authentication token
+		userID, ok := UserIDFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Authentication required", http.StatusUnauthorized)
+			return
+		}
+
+		// Connecting joins the space, creating the user's ephemeral identity if needed
+		ident, err := deps.Membership.JoinSpace(r.Context(), spaceID, userID)
+		if err != nil {
+			switch {
+			case errors.Is(err, space.ErrSpaceNotFound):
+				http.Error(w, "Space not found", http.StatusNotFound)
+			case errors.Is(err, space.ErrSpaceClosed):
+				http.Error(w, "Space has dissolved", http.StatusGone)
+			default:
+				log.Printf("Failed to join space: %v", err)
+				http.Error(w, "Failed to join space", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		// Load the space for its enabled features
+		sp, err := deps.Manager.GetSpace(r.Context(), spaceID)
+		if err != nil {
+			log.Printf("Failed to get space: %v", err)
+			http.Error(w, "Failed to get space", http.StatusInternalServerError)
 			return
 		}
 
@@ -87,40 +131,53 @@ func SpaceWebSocketHandler(natsConn *nats.Conn) http.HandlerFunc {
 
 		// Create new client
 		client := &WebSocketClient{
-			conn:     conn,
-			send:     make(chan []byte, 256),
-			spaceID:  spaceID,
-			userID:   userID,
-			natsConn: natsConn,
-			subIDs:   []string{},
+			spaceSender: deps.newSender(spaceID, userID, ident),
+			conn:        conn,
+			send:        make(chan []byte, 256),
+			metrics:     metrics,
+			acks:        newRecentAcks(256),
+			hub:         deps.Hub,
+			done:        make(chan struct{}),
 		}
+		client.setFeatures(sp.EnabledFeatures())
 
-		// Start client
-		go client.writePump()
-		go client.readPump()
-
-		// Subscribe to space-related topics
-		if err := client.subscribeToSpace(); err != nil {
-			log.Printf("Failed to subscribe to space topics: %v", err)
-			client.closeConnection()
+		// Send welcome message
+		client.sendFrame(WelcomeFrame{
+			Type:            FrameWelcome,
+			ProtocolVersion: protocol,
+			SpaceID:         spaceID,
+			Identity:        formatIdentity(ident),
+			Features:        sp.EnabledFeatures(),
+			Time:            time.Now(),
+		})
+
+		// Follow the space's events through the hub before loading history, so
+		// nothing published in between is missed
+		if err := deps.Hub.register(spaceID, client); err != nil {
+			log.Printf("Failed to register with hub: %v", err)
+			conn.WriteControl(
+				websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "unavailable"),
+				time.Now().Add(DefaultWebSocketConfig().WriteWait),
+			)
+			conn.Close()
 			return
 		}
 
-		// Send welcome message
-		welcomeMsg := map[string]interface{}{
-			"type":     "welcome",
-			"space_id": spaceID,
-			"time":     time.Now(),
+		// Send recent messages, then the events held back while loading them
+		client.sendRecentMessages(r.Context())
+
+		// Announce the identity to the space once it is connected anywhere
+		if err := deps.Presence.Connect(r.Context(), *ident); err != nil {
+			log.Printf("Failed to record presence: %v", err)
 		}
 
-		welcomeJSON, _ := json.Marshal(welcomeMsg)
-		client.send <- welcomeJSON
+		// Start client
+		go client.writePump()
+		go client.readPump()
 
 		// Log connection
 		log.Printf("New WebSocket connection for space %s from user %s", spaceID, userID)
-
-		// Send recent messages
-		client.sendRecentMessages()
 	}
 }
 
@@ -128,9 +185,7 @@ func SpaceWebSocketHandler(natsConn *nats.Conn) http.HandlerFunc {
 func (c *WebSocketClient) readPump() {
 	config := DefaultWebSocketConfig()
 
-	defer func() {
-		c.closeConnection()
-	}()
+	defer c.disconnect(websocket.CloseNormalClosure, "")
 
 	c.conn.SetReadLimit(config.MaxMessageSize)
 	c.conn.SetReadDeadline(time.Now().Add(config.PongWait))
@@ -153,7 +208,8 @@ func (c *WebSocketClient) readPump() {
 	}
 }
 
-// writePump pumps messages from the hub to the WebSocket connection
+// writePump pumps messages from the hub to the WebSocket connection. It owns
+// the connection's shutdown, so it is the only goroutine that closes it.
 func (c *WebSocketClient) writePump() {
 	config := DefaultWebSocketConfig()
 	ticker := time.NewTicker(config.PingPeriod)
@@ -164,28 +220,20 @@ func (c *WebSocketClient) writePump() {
 
 	for {
 		select {
-		case message, ok := <-c.send:
-			c.conn.SetWriteDeadline(time.Now().Add(config.WriteWait))
-			if !ok {
-				// The hub closed the channel
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
-				return
-			}
-
-			w, err := c.conn.NextWriter(websocket.TextMessage)
-			if err != nil {
-				return
-			}
-			w.Write(message)
-
-			// Add queued messages to the current WebSocket message
-			n := len(c.send)
-			for i := 0; i < n; i++ {
-				w.Write([]byte{'\n'})
-				w.Write(<-c.send)
-			}
+		case <-c.done:
+			// Send what was queued before closing, such as a space's dissolved event
+			c.flushQueued(config.WriteWait)
+			c.conn.WriteControl(
+				websocket.CloseMessage,
+				websocket.FormatCloseMessage(c.closeCode, c.closeReason),
+				time.Now().Add(config.WriteWait),
+			)
+			return
 
-			if err := w.Close(); err != nil {
+		case message := <-c.send:
+			// Each frame is its own WebSocket message
+			c.conn.SetWriteDeadline(time.Now().Add(config.WriteWait))
+			if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
 				return
 			}
 
@@ -198,195 +246,267 @@ func (c *WebSocketClient) writePump() {
 	}
 }
 
-// processIncomingMessage processes an incoming WebSocket message
+// flushQueued writes the frames still queued for the client, one message each
+func (c *WebSocketClient) flushQueued(writeWait time.Duration) {
+	n := len(c.send)
+	if n == 0 {
+		return
+	}
+
+	c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	for i := 0; i < n; i++ {
+		if err := c.conn.WriteMessage(websocket.TextMessage, <-c.send); err != nil {
+			return
+		}
+	}
+}
+
+// processIncomingMessage processes an incoming WebSocket frame, answering
+// every frame that carries a client ID with an ack or an error
 func (c *WebSocketClient) processIncomingMessage(message []byte) {
-	// Parse message
-	var msg map[string]interface{}
-	if err := json.Unmarshal(message, &msg); err != nil {
-		log.Printf("Failed to parse WebSocket message: %v", err)
+	// Parse frame
+	var frame ClientFrame
+	if err := json.Unmarshal(message, &frame); err != nil {
+		c.sendFrame(newErrorFrame("", ErrorInvalidFrame, "Frame is not valid JSON"))
+		return
+	}
+	if err := frame.validate(); err != nil {
+		c.sendFrame(newErrorFrame(frame.ClientID, ErrorInvalidFrame, err.Error()))
 		return
 	}
 
-	// Get message type
-	msgType, ok := msg["type"].(string)
-	if !ok {
-		log.Printf("Missing message type")
+	// Answer resends of a frame already accepted with the original ack
+	if ack, ok := c.acks.get(frame.ClientID); ok {
+		c.queue(ack)
 		return
 	}
 
-	// Add sender information
-	msg["user_id"] = c.userID
-	msg["time"] = time.Now()
+	if frame.Type == FramePing {
+		c.sendFrame(PongFrame{Type: FramePong, ClientID: frame.ClientID, Time: time.Now()})
+		return
+	}
 
-	// Process based on message type
-	switch msgType {
-	case "message":
-		// In a real implementation, we would validate and process the message
-		c.handleChatMessage(msg)
+	c.featuresMu.RLock()
+	features := c.features
+	c.featuresMu.RUnlock()
 
-	case "typing":
-		// Handle typing indicator
-		c.handleTypingIndicator(msg)
+	ack, errFrame := c.submit(&frame, features)
+	if errFrame != nil {
+		c.sendFrame(errFrame)
+		return
+	}
 
-	case "reaction":
-		// Handle reaction
-		c.handleReaction(msg)
+	// Acknowledge frames the client can correlate
+	if frame.ClientID != "" {
+		data, _ := json.Marshal(ack)
+		c.acks.add(frame.ClientID, data)
+		c.queue(data)
+	}
+}
 
-	default:
-		log.Printf("Unknown message type: %s", msgType)
+// setFeatures replaces the client's view of the space's enabled features
+func (c *WebSocketClient) setFeatures(ids []string) {
+	features := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		features[id] = true
 	}
+
+	c.featuresMu.Lock()
+	c.features = features
+	c.featuresMu.Unlock()
 }
 
-// handleChatMessage handles a chat message
-func (c *WebSocketClient) handleChatMessage(msg map[string]interface{}) {
-	// Add message ID
-	msg["id"] = fmt.Sprintf("msg_%d", time.Now().UnixNano())
+// sendFrame queues a frame for the client
+func (c *WebSocketClient) sendFrame(frame interface{}) {
+	data, err := json.Marshal(frame)
+	if err != nil {
+		log.Printf("Failed to marshal frame: %v", err)
+		return
+	}
 
-	// In a real implementation, we would store the message in the database
+	c.queue(data)
+}
 
-	// Publish to NATS for all clients in this space
-	msgJSON, _ := json.Marshal(msg)
-	topic := fmt.Sprintf("space.%s.messages", c.spaceID)
+// heldEvent is an event from the hub waiting for the history to be sent
+type heldEvent struct {
+	topic string
+	data  []byte
+}
 
-	if err := c.natsConn.Publish(topic, msgJSON); err != nil {
-		log.Printf("Failed to publish message to NATS: %v", err)
+// sendRecentMessages sends the space's recent messages to the client, followed
+// by the events that arrived while they were loading, leaving out messages
+// already in the history. No history frame is sent if they can't be loaded.
+func (c *WebSocketClient) sendRecentMessages(ctx context.Context) {
+	messages, err := c.history.GetRecentMessages(ctx, c.spaceID, 0)
+	if err != nil {
+		log.Printf("Failed to load recent messages: %v", err)
 	}
-}
 
-// handleTypingIndicator handles a typing indicator
-func (c *WebSocketClient) handleTypingIndicator(msg map[string]interface{}) {
-	// Publish typing indicator to NATS
-	msgJSON, _ := json.Marshal(msg)
-	topic := fmt.Sprintf("space.%s.typing", c.spaceID)
+	c.historyMu.Lock()
+	defer c.historyMu.Unlock()
 
-	if err := c.natsConn.Publish(topic, msgJSON); err != nil {
-		log.Printf("Failed to publish typing indicator to NATS: %v", err)
+	if err == nil {
+		c.sendFrame(historyFrame(messages))
 	}
-}
 
-// handleReaction handles a message reaction
-func (c *WebSocketClient) handleReaction(msg map[string]interface{}) {
-	// Get message ID
-	messageID, ok := msg["message_id"].(string)
-	if !ok {
-		log.Printf("Missing message ID in reaction")
-		return
+	sent := make(map[string]bool, len(messages))
+	for _, message := range messages {
+		sent[message.ID] = true
 	}
 
-	// Get reaction
-	reaction, ok := msg["reaction"].(string)
-	if !ok {
-		log.Printf("Missing reaction")
-		return
+	for _, event := range c.held {
+		if event.topic == "messages" {
+			var frame struct {
+				Type string `json:"type"`
+				ID   string `json:"id"`
+			}
+			if json.Unmarshal(event.data, &frame) == nil && frame.Type == FrameMessage && sent[frame.ID] {
+				continue
+			}
+		}
+		c.queue(event.data)
 	}
 
-	// In a real implementation, we would update the reaction in the database
+	c.held = nil
+	c.live = true
+}
 
-	// Publish reaction to NATS
-	reactionMsg := map[string]interface{}{
-		"type":       "reaction",
-		"user_id":    c.userID,
-		"message_id": messageID,
-		"reaction":   reaction,
-		"time":       time.Now(),
+// historyFrame formats recent messages as a history frame
+func historyFrame(messages []messaging.Message) HistoryFrame {
+	frames := make([]MessageFrame, 0, len(messages))
+	for _, message := range messages {
+		frames = append(frames, MessageFrame{
+			Type:      FrameMessage,
+			ID:        message.ID,
+			Identity:  formatIdentity(message.EphemeralIdentity),
+			Content:   message.Content,
+			MediaURLs: message.MediaURLs,
+			Location:  frameLocation(message.Location),
+			ReplyToID: message.ReplyToID,
+			Reactions: message.Reactions,
+			Time:      message.CreatedAt,
+		})
 	}
 
-	msgJSON, _ := json.Marshal(reactionMsg)
-	topic := fmt.Sprintf("space.%s.reactions", c.spaceID)
+	return HistoryFrame{Type: FrameHistory, Messages: frames}
+}
 
-	if err := c.natsConn.Publish(topic, msgJSON); err != nil {
-		log.Printf("Failed to publish reaction to NATS: %v", err)
+// deliver queues an event from the hub, keeping feature enforcement current
+func (c *WebSocketClient) deliver(topic string, data []byte) bool {
+	if topic == "metrics" && !c.metrics {
+		return true
 	}
-}
 
-// subscribeToSpace subscribes to space-related NATS topics
-func (c *WebSocketClient) subscribeToSpace() error {
-	// Subscribe to messages
-	msgSub, err := c.natsConn.Subscribe(fmt.Sprintf("space.%s.messages", c.spaceID), func(msg *nats.Msg) {
-		c.send <- msg.Data
-	})
-	if err != nil {
-		return fmt.Errorf("failed to subscribe to messages: %w", err)
+	// Connections get events as published; only streams resume from the log
+	if topic == space.EventLogTopic {
+		return true
 	}
-	c.natsSubscriptions = append(c.natsSubscriptions, msgSub)
 
-	// Subscribe to typing indicators
-	typingSub, err := c.natsConn.Subscribe(fmt.Sprintf("space.%s.typing", c.spaceID), func(msg *nats.Msg) {
-		c.send <- msg.Data
-	})
-	if err != nil {
-		return fmt.Errorf("failed to subscribe to typing indicators: %w", err)
+	if topic == "features" {
+		var event struct {
+			Features []string `json:"features"`
+		}
+		if err := json.Unmarshal(data, &event); err != nil {
+			log.Printf("Failed to parse features event: %v", err)
+		} else {
+			c.setFeatures(event.Features)
+		}
 	}
-	c.natsSubscriptions = append(c.natsSubscriptions, typingSub)
 
-	// Subscribe to reactions
-	reactionSub, err := c.natsConn.Subscribe(fmt.Sprintf("space.%s.reactions", c.spaceID), func(msg *nats.Msg) {
-		c.send <- msg.Data
-	})
-	if err != nil {
-		return fmt.Errorf("failed to subscribe to reactions: %w", err)
+	// Hold events back until the history has been sent, as if the buffer
+	// filled when too many arrive in the meantime
+	c.historyMu.Lock()
+	if !c.live {
+		defer c.historyMu.Unlock()
+		if len(c.held) >= cap(c.send) {
+			return false
+		}
+		c.held = append(c.held, heldEvent{topic: topic, data: data})
+		return true
 	}
-	c.natsSubscriptions = append(c.natsSubscriptions, reactionSub)
+	c.historyMu.Unlock()
 
-	// Subscribe to lifecycle events
-	lifecycleSub, err := c.natsConn.Subscribe(fmt.Sprintf("space.%s.lifecycle", c.spaceID), func(msg *nats.Msg) {
-		c.send <- msg.Data
-	})
-	if err != nil {
-		return fmt.Errorf("failed to subscribe to lifecycle events: %w", err)
+	return c.queue(data)
+}
+
+// queue adds a frame to the send buffer without blocking, returning false if it is full
+func (c *WebSocketClient) queue(data []byte) bool {
+	select {
+	case c.send <- data:
+		return true
+	default:
+		return false
 	}
-	c.natsSubscriptions = append(c.natsSubscriptions, lifecycleSub)
+}
+
+// user returns the ID of the user who opened the connection
+func (c *WebSocketClient) user() string {
+	return c.userID
+}
+
+// disconnect asks the write pump to close the connection. Only the first
+// reason given is sent to the client.
+func (c *WebSocketClient) disconnect(code int, reason string) {
+	c.stopOnce.Do(func() {
+		c.closeCode = code
+		c.closeReason = reason
+		close(c.done)
+	})
+}
 
-	return nil
+// recentAcks remembers the acks sent for a connection's latest client frames.
+// It is only used from the read pump, so it needs no locking.
+type recentAcks struct {
+	acks  map[string][]byte
+	order []string
+	size  int
 }
 
-// sendRecentMessages sends recent messages to the client
-func (c *WebSocketClient) sendRecentMessages() {
-	// In a real implementation, we would fetch recent messages from the database
-	// This is a mock implementation
-
-	// Create sample messages
-	messages := []map[string]interface{}{
-		{
-			"type":      "message",
-			"id":        "msg_1",
-			"user_id":   "user_1",
-			"content":   "Welcome to the space!",
-			"time":      time.Now().Add(-5 * time.Minute),
-			"reactions": map[string]int{"👍": 2},
-		},
-		{
-			"type":    "message",
-			"id":      "msg_2",
-			"user_id": "user_2",
-			"content": "Hello everyone!",
-			"time":    time.Now().Add(-3 * time.Minute),
-		},
+// newRecentAcks creates an ack cache holding up to size acks
+func newRecentAcks(size int) *recentAcks {
+	return &recentAcks{
+		acks: make(map[string][]byte, size),
+		size: size,
+	}
+}
+
+// get returns the ack sent for a client ID
+func (r *recentAcks) get(clientID string) ([]byte, bool) {
+	if clientID == "" {
+		return nil, false
 	}
 
-	// Send messages
-	historyMsg := map[string]interface{}{
-		"type":     "history",
-		"messages": messages,
+	ack, ok := r.acks[clientID]
+	return ack, ok
+}
+
+// add remembers an ack, forgetting the oldest once full
+func (r *recentAcks) add(clientID string, ack []byte) {
+	if len(r.order) >= r.size {
+		delete(r.acks, r.order[0])
+		r.order = r.order[1:]
 	}
 
-	historyJSON, _ := json.Marshal(historyMsg)
-	c.send <- historyJSON
+	r.acks[clientID] = ack
+	r.order = append(r.order, clientID)
 }
 
 // closeConnection closes the WebSocket connection and cleans up resources
 func (c *WebSocketClient) closeConnection() {
-	// Unsubscribe from all NATS topics
-	for _, sub := range c.natsSubscriptions {
-		sub.Unsubscribe()
+	// Stop receiving space events
+	c.hub.unregister(c.spaceID, c)
+	c.disconnect(websocket.CloseNormalClosure, "")
+
+	// Announce the identity's departure once its last connection closes
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := c.presence.Disconnect(ctx, *c.identity); err != nil {
+		log.Printf("Failed to remove presence: %v", err)
 	}
+	cancel()
 
-	// Close connection
+	// Close connection, which also ends the read pump
 	c.conn.Close()
 
-	// Close send channel
-	close(c.send)
-
 	// Log disconnection
 	log.Printf("WebSocket connection closed for space %s, user %s", c.spaceID, c.userID)
 }
@@ -16,6 +16,8 @@ import (
 
 	"essg/internal/config"
 	"essg/internal/domain/geo"
+	"essg/internal/domain/identity"
+	"essg/internal/domain/messaging"
 	"essg/internal/domain/space"
 	"essg/internal/domain/trend"
 	"essg/internal/server/handlers"
@@ -25,81 +27,200 @@ import (
 type Server struct {
 	server *http.Server
 	router *chi.Mux
+	hub    *handlers.Hub
+}
+
+// Deps contains the services the server's handlers are built from
+type Deps struct {
+	DB                *pgxpool.Pool
+	NatsConn          *nats.Conn
+	TrendDetector     trend.Detector
+	SpaceManager      space.Manager
+	RelationFinder    space.RelationFinder
+	Archiver          space.Archiver
+	AdmissionReporter space.AdmissionReporter
+	Analytics         space.AnalyticsReporter
+	Membership        space.MembershipManager
+	Presence          space.PresenceTracker
+	Events            space.EventLog
+	TemplateSelector  space.TemplateSelector
+	Polls             space.PollManager
+	Timeline          space.Timeline
+	Tokens            identity.TokenManager
+	Identities        identity.Service
+	Guests            identity.GuestManager
+	Limiter           messaging.RateLimiter
+	History           messaging.History
+	Reputation        identity.ReputationManager
+	Privacy           identity.LocationPrivacyManager
+	PrivacyConfig     identity.PrivacyConfig
+	GeoService        geo.Service
 }
 
 // NewServer creates a new HTTP server
-func NewServer(
-	cfg config.ServerConfig,
-	db *pgxpool.Pool,
-	natsConn *nats.Conn,
-	trendDetector trend.Detector,
-	spaceManager space.Manager,
-	geoService geo.Service,
-) *Server {
+func NewServer(cfg config.ServerConfig, deps Deps) *Server {
 	router := chi.NewRouter()
 
 	// Middleware
 	router.Use(middleware.RequestID)
-	router.Use(middleware.RealIP)
+	router.Use(handlers.TrustedRealIP(cfg.TrustedProxies))
 	router.Use(middleware.Logger)
 	router.Use(middleware.Recoverer)
-	router.Use(middleware.Timeout(60 * time.Second))
 
 	// CORS configuration
 	router.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   cfg.CorsOrigins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-Admin-Token"},
 		ExposedHeaders:   []string{"Link"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
 
+	// Requests time out, except for event streams
+	timeout := middleware.Timeout(60 * time.Second)
+
 	// Create handler dependencies
-	trendHandler := handlers.NewTrendHandler(trendDetector)
-	spaceHandler := handlers.NewSpaceHandler(spaceManager)
-	geoHandler := handlers.NewGeoHandler(geoService)
+	trendHandler := handlers.NewTrendHandler(deps.TrendDetector)
+	templateHandler := handlers.NewTemplateHandler(deps.TrendDetector, deps.TemplateSelector)
+	spaceHandler := handlers.NewSpaceHandler(deps.SpaceManager, deps.Identities, deps.Limiter, deps.History, deps.Privacy, deps.NatsConn)
+	relatedHandler := handlers.NewRelatedSpaceHandler(deps.RelationFinder)
+	archiveHandler := handlers.NewArchiveHandler(deps.Archiver)
+	membershipHandler := handlers.NewMembershipHandler(deps.Membership)
+	presenceHandler := handlers.NewPresenceHandler(deps.Presence)
+	analyticsHandler := handlers.NewAnalyticsHandler(deps.Analytics)
+	pollHandler := handlers.NewPollHandler(deps.Polls, deps.Limiter)
+	timelineHandler := handlers.NewTimelineHandler(deps.Timeline)
+	authHandler := handlers.NewAuthHandler(deps.Tokens, deps.Guests)
+	requireAuth := handlers.RequireAuth(deps.Tokens)
+	reputationHandler := handlers.NewReputationHandler(deps.Reputation)
+	privacyHandler := handlers.NewPrivacyHandler(deps.PrivacyConfig)
+	adminHandler := handlers.NewAdminHandler(deps.SpaceManager, deps.AdmissionReporter)
+	geoHandler := handlers.NewGeoHandler(deps.GeoService)
+	hub := handlers.NewHub(deps.NatsConn, handlers.DefaultHubConfig())
+
+	// The WebSocket and event stream transports share how members connect and send
+	transportDeps := handlers.SpaceTransportDeps{
+		Hub:        hub,
+		NatsConn:   deps.NatsConn,
+		Manager:    deps.SpaceManager,
+		Membership: deps.Membership,
+		Presence:   deps.Presence,
+		Limiter:    deps.Limiter,
+		History:    deps.History,
+		Privacy:    deps.Privacy,
+	}
+	streamHandler := handlers.NewStreamHandler(transportDeps, deps.Events, handlers.DefaultStreamConfig())
 
 	// Routes
 	router.Route("/api", func(r chi.Router) {
-		// Health check
-		r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
-			w.Write([]byte("OK"))
-		})
+		// Event stream, for clients without WebSockets. It stays open
+		// indefinitely, so it is the one route without a timeout.
+		r.With(requireAuth).Get("/v1/spaces/{id}/stream", streamHandler.Stream)
 
-		// API version
-		r.Route("/v1", func(r chi.Router) {
-			// Trends API
-			r.Route("/trends", func(r chi.Router) {
-				r.Get("/", trendHandler.GetTrends)
-				r.Get("/{id}", trendHandler.GetTrend)
-				r.Get("/geo", trendHandler.GetGeoTrends)
+		r.Group(func(r chi.Router) {
+			r.Use(timeout)
+
+			// Health check
+			r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("OK"))
 			})
 
-			// Spaces API
-			r.Route("/spaces", func(r chi.Router) {
-				r.Get("/", spaceHandler.ListSpaces)
-				r.Post("/", spaceHandler.CreateSpace)
-				r.Get("/{id}", spaceHandler.GetSpace)
-				r.Get("/nearby", spaceHandler.GetNearbySpaces)
-
-				// Space messages
-				r.Route("/{id}/messages", func(r chi.Router) {
-					r.Get("/", spaceHandler.GetMessages)
-					r.Post("/", spaceHandler.SendMessage)
+			// API version
+			r.Route("/v1", func(r chi.Router) {
+				// Trends API
+				r.Route("/trends", func(r chi.Router) {
+					r.Get("/", trendHandler.GetTrends)
+					r.Get("/{id}", trendHandler.GetTrend)
+					r.Get("/{id}/template", templateHandler.ExplainTrendTemplate)
+					r.Get("/geo", trendHandler.GetGeoTrends)
+				})
+
+				// Spaces API
+				r.Route("/spaces", func(r chi.Router) {
+					r.Get("/", spaceHandler.ListSpaces)
+					r.With(requireAuth).Post("/", spaceHandler.CreateSpace)
+					r.Get("/{id}", spaceHandler.GetSpace)
+					r.With(requireAuth).Put("/{id}", spaceHandler.UpdateSpace)
+					r.Get("/nearby", spaceHandler.GetNearbySpaces)
+					r.Get("/{id}/related", relatedHandler.GetRelatedSpaces)
+					r.Get("/{id}/archive", archiveHandler.GetArchive)
+					r.With(requireAuth).Post("/{id}/join", membershipHandler.JoinSpace)
+					r.With(requireAuth).Post("/{id}/leave", membershipHandler.LeaveSpace)
+					r.Get("/{id}/occupancy", membershipHandler.GetOccupancy)
+					r.Get("/{id}/presence", presenceHandler.GetPresence)
+					r.Get("/{id}/analytics", analyticsHandler.GetAnalytics)
+					r.Get("/{id}/identities", spaceHandler.GetIdentities)
+
+					// Sending over the event stream, for clients without WebSockets
+					r.With(requireAuth).Post("/{id}/stream", streamHandler.Send)
+
+					// Polls
+					r.Route("/{id}/polls", func(r chi.Router) {
+						r.Get("/", pollHandler.ListPolls)
+						r.With(requireAuth).Post("/", pollHandler.CreatePoll)
+						r.Get("/{pollID}", pollHandler.GetPoll)
+						r.With(requireAuth).Post("/{pollID}/votes", pollHandler.Vote)
+						r.With(requireAuth).Post("/{pollID}/close", pollHandler.ClosePoll)
+					})
+
+					// Timeline
+					r.Route("/{id}/timeline", func(r chi.Router) {
+						r.Get("/", timelineHandler.GetTimeline)
+						r.With(requireAuth).Post("/", timelineHandler.PinUpdate)
+						r.Get("/suggestions", timelineHandler.ListSuggestions)
+						r.With(requireAuth).Post("/suggestions/{entryID}/accept", timelineHandler.AcceptSuggestion)
+						r.With(requireAuth).Post("/suggestions/{entryID}/dismiss", timelineHandler.DismissSuggestion)
+					})
+
+					// Space messages
+					r.Route("/{id}/messages", func(r chi.Router) {
+						r.Get("/", spaceHandler.GetMessages)
+						r.With(requireAuth).Post("/", spaceHandler.SendMessage)
+					})
 				})
-			})
 
-			// Geo API
-			r.Route("/geo", func(r chi.Router) {
-				r.Get("/context", geoHandler.GetLocationContext)
-				r.Get("/trends", geoHandler.GetLocalTrends)
+				// Privacy API
+				r.Get("/privacy", privacyHandler.GetPolicy)
+
+				// Auth API
+				r.Route("/auth", func(r chi.Router) {
+					r.Post("/guest", authHandler.CreateGuestSession)
+					r.With(requireAuth).Post("/logout", authHandler.Logout)
+				})
+
+				// Template API
+				r.With(requireAuth).Post("/templates/explain", templateHandler.ExplainTemplate)
+
+				// Geo API
+				r.Route("/geo", func(r chi.Router) {
+					r.Get("/context", geoHandler.GetLocationContext)
+					r.Get("/trends", geoHandler.GetLocalTrends)
+				})
+
+				// Admin API
+				r.Route("/admin", func(r chi.Router) {
+					r.Use(handlers.RequireAdminToken(cfg.AdminToken))
+
+					r.Put("/spaces/{id}/pin", adminHandler.PinSpace)
+					r.Put("/spaces/{id}/expiry", adminHandler.OverrideExpiry)
+					r.Put("/spaces/{id}/features/{feature}", adminHandler.SetFeature)
+					r.Post("/spaces/{id}/timeline", timelineHandler.PinUpdate)
+					r.Post("/spaces/{id}/timeline/suggestions/{entryID}/accept", timelineHandler.AcceptSuggestion)
+					r.Post("/spaces/{id}/timeline/suggestions/{entryID}/dismiss", timelineHandler.DismissSuggestion)
+					r.Post("/spaces/{id}/identities/{identityID}/strikes", reputationHandler.RecordStrike)
+					r.Post("/tokens", authHandler.IssueToken)
+					r.Delete("/users/{id}/tokens", authHandler.RevokeUserTokens)
+					r.Post("/users/{id}/upgrade", authHandler.UpgradeGuest)
+					r.Get("/admission/decisions", adminHandler.ListAdmissionDecisions)
+					r.Get("/admission/queue", adminHandler.GetAdmissionQueue)
+				})
 			})
 		})
 	})
 
 	// WebSocket endpoint for real-time communications
-	router.Get("/ws/spaces/{id}", handlers.SpaceWebSocketHandler(natsConn))
+	router.With(timeout, requireAuth).Get("/ws/spaces/{id}", handlers.SpaceWebSocketHandler(transportDeps))
 
 	// Create HTTP server
 	httpServer := &http.Server{
@@ -112,6 +233,7 @@ func NewServer(
 	return &Server{
 		server: httpServer,
 		router: router,
+		hub:    hub,
 	}
 }
 
@@ -122,5 +244,10 @@ func (s *Server) ListenAndServe() error {
 
 // Shutdown gracefully shuts down the HTTP server
 func (s *Server) Shutdown(ctx context.Context) error {
+	// Close WebSocket connections, which the HTTP server no longer tracks once upgraded
+	if err := s.hub.Stop(ctx); err != nil {
+		return fmt.Errorf("error stopping hub: %w", err)
+	}
+
 	return s.server.Shutdown(ctx)
 }
@@ -0,0 +1,189 @@
+// internal/service/geo/population.go
+
+package geo
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"essg/internal/domain/trend"
+)
+
+// kmPerDegree is the length of a degree of latitude in kilometers
+const kmPerDegree = 111.32
+
+// PopulationGrid is an offline gridded population dataset, such as GPW or
+// WorldPop population counts exported as an ESRI ASCII grid. Each cell holds
+// the number of people living in it.
+type PopulationGrid struct {
+	cols, rows int
+	west       float64 // Longitude of the grid's western edge
+	north      float64 // Latitude of the grid's northern edge
+	cellSize   float64 // Cell size in degrees
+	counts     []float32
+	noData     float32
+}
+
+// LoadPopulationGrid reads a population grid from an ESRI ASCII grid file
+func LoadPopulationGrid(path string) (*PopulationGrid, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening population grid: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+	scanner.Split(bufio.ScanWords)
+
+	// Read the header
+	header := make(map[string]float64)
+	var first string
+	for scanner.Scan() {
+		key := strings.ToLower(scanner.Text())
+		if _, err := strconv.ParseFloat(key, 64); err == nil {
+			first = key // Header ended; this is the first cell
+			break
+		}
+
+		if !scanner.Scan() {
+			break
+		}
+		value, err := strconv.ParseFloat(scanner.Text(), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s in population grid header: %w", key, err)
+		}
+		header[key] = value
+	}
+
+	grid := &PopulationGrid{
+		cols:     int(header["ncols"]),
+		rows:     int(header["nrows"]),
+		cellSize: header["cellsize"],
+		noData:   -9999,
+	}
+	if grid.cols <= 0 || grid.rows <= 0 || grid.cellSize <= 0 {
+		return nil, fmt.Errorf("population grid header needs ncols, nrows and cellsize")
+	}
+	if noData, ok := header["nodata_value"]; ok {
+		grid.noData = float32(noData)
+	}
+
+	// Corners may be given as cell centers instead
+	west, south := header["xllcorner"], header["yllcorner"]
+	if _, ok := header["xllcenter"]; ok {
+		west = header["xllcenter"] - grid.cellSize/2
+	}
+	if _, ok := header["yllcenter"]; ok {
+		south = header["yllcenter"] - grid.cellSize/2
+	}
+	grid.west = west
+	grid.north = south + float64(grid.rows)*grid.cellSize
+
+	// Read cells, row by row from the north
+	grid.counts = make([]float32, 0, grid.cols*grid.rows)
+	if first != "" {
+		if err := grid.appendCell(first); err != nil {
+			return nil, err
+		}
+	}
+	for len(grid.counts) < grid.cols*grid.rows && scanner.Scan() {
+		if err := grid.appendCell(scanner.Text()); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading population grid: %w", err)
+	}
+	if len(grid.counts) != grid.cols*grid.rows {
+		return nil, fmt.Errorf("population grid has %d cells, expected %d", len(grid.counts), grid.cols*grid.rows)
+	}
+
+	return grid, nil
+}
+
+// appendCell parses and appends a cell value
+func (g *PopulationGrid) appendCell(text string) error {
+	value, err := strconv.ParseFloat(text, 32)
+	if err != nil {
+		return fmt.Errorf("invalid population grid cell %q: %w", text, err)
+	}
+
+	g.counts = append(g.counts, float32(value))
+	return nil
+}
+
+// PopulationWithin estimates how many people live within a radius of a
+// location, reporting false when the grid has no data for the area. Cells the
+// circle only partly covers count for the share of them inside it, sampled
+// over just the part of the cell the circle can reach so that circles much
+// smaller than a cell still get their share.
+func (g *PopulationGrid) PopulationWithin(center trend.Location, radiusKm float64) (float64, bool) {
+	if radiusKm <= 0 {
+		return 0, false
+	}
+
+	radiusDeg := radiusKm / kmPerDegree
+	cosLat := math.Max(math.Cos(center.Latitude*math.Pi/180), 0.01)
+	lngRadius := radiusDeg / cosLat
+
+	// Cells overlapping the circle's bounding box
+	firstRow := max(0, int(math.Floor((g.north-center.Latitude-radiusDeg)/g.cellSize)))
+	lastRow := min(g.rows-1, int(math.Floor((g.north-center.Latitude+radiusDeg)/g.cellSize)))
+	firstCol := max(0, int(math.Floor((center.Longitude-lngRadius-g.west)/g.cellSize)))
+	lastCol := min(g.cols-1, int(math.Floor((center.Longitude+lngRadius-g.west)/g.cellSize)))
+
+	// Sample at least 16 times across the radius and across each cell
+	latStep := math.Min(g.cellSize, radiusDeg) / 16
+	lngStep := math.Min(g.cellSize, lngRadius) / 16
+
+	var population float64
+	covered := false
+	for row := firstRow; row <= lastRow; row++ {
+		for col := firstCol; col <= lastCol; col++ {
+			count := g.counts[row*g.cols+col]
+			if count == g.noData || count < 0 {
+				continue
+			}
+
+			// Part of the cell within the circle's bounding box
+			cellNorth := g.north - float64(row)*g.cellSize
+			cellWest := g.west + float64(col)*g.cellSize
+			north := math.Min(cellNorth, center.Latitude+radiusDeg)
+			south := math.Max(cellNorth-g.cellSize, center.Latitude-radiusDeg)
+			west := math.Max(cellWest, center.Longitude-lngRadius)
+			east := math.Min(cellWest+g.cellSize, center.Longitude+lngRadius)
+			if north <= south || east <= west {
+				continue
+			}
+
+			// Share of the cell inside the circle
+			latSamples := int(math.Ceil((north - south) / latStep))
+			lngSamples := int(math.Ceil((east - west) / lngStep))
+			inside := 0
+			for i := 0; i < latSamples; i++ {
+				dy := north - (float64(i)+0.5)*(north-south)/float64(latSamples) - center.Latitude
+				for j := 0; j < lngSamples; j++ {
+					dx := (west + (float64(j)+0.5)*(east-west)/float64(lngSamples) - center.Longitude) * cosLat
+					if dx*dx+dy*dy <= radiusDeg*radiusDeg {
+						inside++
+					}
+				}
+			}
+			if inside == 0 {
+				continue
+			}
+
+			covered = true
+			share := float64(inside) / float64(latSamples*lngSamples) * (north - south) * (east - west) / (g.cellSize * g.cellSize)
+			population += float64(count) * share
+		}
+	}
+
+	return population, covered
+}
@@ -0,0 +1,133 @@
+// internal/service/geo/population_test.go
+
+package geo
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"essg/internal/domain/trend"
+)
+
+// writeGrid writes an ESRI ASCII grid to a temporary file
+func writeGrid(t *testing.T, contents string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "population.asc")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("error writing grid: %v", err)
+	}
+
+	return path
+}
+
+func TestPopulationWithin(t *testing.T) {
+	// Two by two one-degree cells on the equator, starting at the prime meridian
+	grid, err := LoadPopulationGrid(writeGrid(t, `ncols 2
+nrows 2
+xllcorner 0
+yllcorner 0
+cellsize 1
+NODATA_value -9999
+10000 20000
+40000 -9999
+`))
+	if err != nil {
+		t.Fatalf("LoadPopulationGrid: %v", err)
+	}
+
+	// Share of a one-degree cell on the equator covered by a 10km circle
+	smallShare := math.Pi * math.Pow(10/kmPerDegree, 2) / math.Cos(0.5*math.Pi/180)
+
+	tests := []struct {
+		name       string
+		center     trend.Location
+		radiusKm   float64
+		population float64
+		covered    bool
+		tolerance  float64
+	}{
+		{
+			name:       "whole grid",
+			center:     trend.Location{Latitude: 1, Longitude: 1},
+			radiusKm:   1000,
+			population: 70000,
+			covered:    true,
+		},
+		{
+			name:       "circle inside one cell",
+			center:     trend.Location{Latitude: 0.5, Longitude: 0.5},
+			radiusKm:   10,
+			population: 40000 * smallShare,
+			covered:    true,
+			tolerance:  0.01,
+		},
+		{
+			name:     "circle inside a cell without data",
+			center:   trend.Location{Latitude: 0.5, Longitude: 1.5},
+			radiusKm: 10,
+		},
+		{
+			name:     "outside the grid",
+			center:   trend.Location{Latitude: 45, Longitude: 45},
+			radiusKm: 10,
+		},
+		{
+			name:     "no radius",
+			center:   trend.Location{Latitude: 0.5, Longitude: 0.5},
+			radiusKm: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			population, covered := grid.PopulationWithin(tt.center, tt.radiusKm)
+			if covered != tt.covered {
+				t.Fatalf("covered = %v, want %v", covered, tt.covered)
+			}
+			if math.Abs(population-tt.population) > tt.tolerance*tt.population+1e-6 {
+				t.Errorf("population = %v, want %v", population, tt.population)
+			}
+		})
+	}
+}
+
+func TestPopulationWithinCountsEveryCell(t *testing.T) {
+	grid, err := LoadPopulationGrid(writeGrid(t, `ncols 2
+nrows 2
+xllcenter 0.5
+yllcenter 0.5
+cellsize 1
+10000 20000
+40000 100000
+`))
+	if err != nil {
+		t.Fatalf("LoadPopulationGrid: %v", err)
+	}
+
+	population, covered := grid.PopulationWithin(trend.Location{Latitude: 1, Longitude: 1}, 1000)
+	if !covered || population != 170000 {
+		t.Errorf("got %v, %v, want 170000, true", population, covered)
+	}
+}
+
+func TestLoadPopulationGridRejectsInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+	}{
+		{name: "missing header", contents: "1 2 3 4"},
+		{name: "too few cells", contents: "ncols 2\nnrows 2\nxllcorner 0\nyllcorner 0\ncellsize 1\n1 2 3\n"},
+		{name: "bad cell", contents: "ncols 1\nnrows 1\nxllcorner 0\nyllcorner 0\ncellsize 1\nmany\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadPopulationGrid(writeGrid(t, tt.contents)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
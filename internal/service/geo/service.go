@@ -6,7 +6,6 @@ import (
 	"context"
 	"fmt"
 	"math"
-	"math/rand"
 	"sort"
 	"sync"
 
@@ -82,6 +81,7 @@ type GeoSpatialConfig struct {
 	MaxRadius                   float64
 	ClusterThreshold            float64
 	PopulationDensityThresholds map[string]float64
+	Population                  *PopulationGrid // Offline population dataset; densities are unavailable when nil
 }
 
 // GeoSpatialService implements the geo.Service interface
@@ -125,7 +125,7 @@ func (s *GeoSpatialService) FindNearbySpaces(
 	// Use PostGIS ST_DWithin for efficient spatial query
 	query := `
 		SELECT id, title, description, created_at, user_count, topic_tags, 
-		       trend_id, template_type, lifecycle_stage, is_geo_local,
+		       COALESCE(trend_id, ''), template_type, lifecycle_stage, is_geo_local,
 		       ST_X(location::geometry) as lat, ST_Y(location::geometry) as lng, 
 		       location_radius
 		FROM spaces 
@@ -351,40 +351,6 @@ func (s *GeoSpatialService) GetLocationContext(
 	return s.geocoder.ReverseGeocode(ctx, location.Latitude, location.Longitude)
 }
 
-// FuzzLocation reduces the precision of a location for privacy
-func (s *GeoSpatialService) FuzzLocation(location trend.Location, precisionLevel string) trend.Location {
-	fuzzed := location
-
-	switch precisionLevel {
-	case "precise":
-		// No fuzzing
-		return location
-
-	case "neighborhood":
-		// Fuzz within ~500m
-		fuzzed.Latitude += (rand.Float64() - 0.5) * 0.01
-		fuzzed.Longitude += (rand.Float64() - 0.5) * 0.01
-
-	case "approximate":
-		// Fuzz within ~2km
-		fuzzed.Latitude += (rand.Float64() - 0.5) * 0.04
-		fuzzed.Longitude += (rand.Float64() - 0.5) * 0.04
-
-	case "disabled":
-		// Return a nil location or an error in a real implementation
-		// Here we'll just reset coordinates to zero
-		fuzzed.Latitude = 0
-		fuzzed.Longitude = 0
-
-	default:
-		// Default to neighborhood level
-		fuzzed.Latitude += (rand.Float64() - 0.5) * 0.01
-		fuzzed.Longitude += (rand.Float64() - 0.5) * 0.01
-	}
-
-	return fuzzed
-}
-
 // CalculateDistance calculates the distance between two locations in kilometers
 func (s *GeoSpatialService) CalculateDistance(a, b trend.Location) float64 {
 	// Implementation of the Haversine formula for distance on a sphere
@@ -411,53 +377,31 @@ func (s *GeoSpatialService) CalculateDistance(a, b trend.Location) float64 {
 	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
 }
 
-// GetPopulationDensity returns population density for an area
+// GetPopulationDensity returns population density for an area from the
+// offline population dataset, or ErrNoPopulationData when it does not cover
+// the area
 func (s *GeoSpatialService) GetPopulationDensity(
 	ctx context.Context,
 	location trend.Location,
 	radiusKm float64,
 ) (*geo.PopulationDensity, error) {
-	// In a production implementation, this would query a real population database
-	// This is a simplified version that uses the location context
-
-	// First get location context
-	locationContext, err := s.GetLocationContext(ctx, location)
-	if err != nil {
-		return nil, fmt.Errorf("error getting location context: %w", err)
+	if s.config.Population == nil {
+		return nil, geo.ErrNoPopulationData
 	}
 
-	// Get population from location context
-	population := locationContext.Population
-
-	// If population data is missing, estimate based on location type
-	if population <= 0 {
-		// Rough estimates based on location types
-		if contains(locationContext.Types, "locality") {
-			// City - rough estimate
-			population = 100000
-		} else if contains(locationContext.Types, "neighborhood") {
-			// Neighborhood
-			population = 10000
-		} else if contains(locationContext.Types, "administrative_area_level_1") {
-			// State/Province
-			population = 1000000
-		} else {
-			// Default value
-			population = 5000
-		}
+	population, ok := s.config.Population.PopulationWithin(location, radiusKm)
+	if !ok {
+		return nil, geo.ErrNoPopulationData
 	}
 
 	// Calculate area in square kilometers
 	areaKm2 := math.Pi * radiusKm * radiusKm
 
-	// Calculate density
-	densityPerKm2 := float64(population) / areaKm2
-
 	return &geo.PopulationDensity{
 		Location:      location,
 		RadiusKm:      radiusKm,
-		Population:    population,
-		DensityPerKm2: densityPerKm2,
+		Population:    int64(math.Round(population)),
+		DensityPerKm2: population / areaKm2,
 	}, nil
 }
 
@@ -542,90 +486,3 @@ func (s *GeoSpatialService) AddLocalSource(source geo.LocalSource) error {
 	s.localSources.AddSource(source)
 	return nil
 }
-
-// Helper function to check if a slice contains a string
-func contains(slice []string, item string) bool {
-	for _, s := range slice {
-		if s == item {
-			return true
-		}
-	}
-	return false
-}
-
-// GeoPrivacyManagerImpl implements the GeoPrivacyManager interface
-type GeoPrivacyManagerImpl struct {
-	privacyLevels map[string]float64
-}
-
-// NewGeoPrivacyManager creates a new geo privacy manager
-func NewGeoPrivacyManager() *GeoPrivacyManagerImpl {
-	return &GeoPrivacyManagerImpl{
-		privacyLevels: map[string]float64{
-			"disabled":     0.0,    // No location sharing
-			"approximate":  0.05,   // ~5km precision
-			"neighborhood": 0.01,   // ~1km precision
-			"precise":      0.0001, // ~10m precision
-		},
-	}
-}
-
-// ApplyPrivacySettings applies privacy settings to a location
-func (g *GeoPrivacyManagerImpl) ApplyPrivacySettings(
-	location trend.Location,
-	privacySetting string,
-	contextRadius float64,
-) trend.Location {
-	// If disabled, return a nil location or an error in a real implementation
-	// Here we'll just return a location with zeroed coordinates
-	if privacySetting == "disabled" {
-		return trend.Location{
-			Latitude:  0,
-			Longitude: 0,
-			Timestamp: location.Timestamp,
-		}
-	}
-
-	// Get precision level for this setting
-	precision, ok := g.privacyLevels[privacySetting]
-	if !ok {
-		// Default to neighborhood if setting not found
-		precision = g.privacyLevels["neighborhood"]
-	}
-
-	// Apply fuzzing based on precision
-	fuzzedLat := location.Latitude
-	fuzzedLng := location.Longitude
-
-	// Add random noise based on precision level
-	if precision > 0 {
-		fuzzedLat = math.Round(fuzzedLat/precision) * precision
-		fuzzedLng = math.Round(fuzzedLng/precision) * precision
-
-		// Add small random offset for additional privacy
-		fuzzedLat += (rand.Float64() - 0.5) * precision * 0.5
-		fuzzedLng += (rand.Float64() - 0.5) * precision * 0.5
-	}
-
-	return trend.Location{
-		Latitude:  fuzzedLat,
-		Longitude: fuzzedLng,
-		Timestamp: location.Timestamp,
-		Accuracy:  precision * 111000, // Convert degrees to meters (roughly)
-	}
-}
-
-// GetPrivacyLevels returns available privacy levels
-func (g *GeoPrivacyManagerImpl) GetPrivacyLevels() []string {
-	levels := make([]string, 0, len(g.privacyLevels))
-	for level := range g.privacyLevels {
-		levels = append(levels, level)
-	}
-	return levels
-}
-
-// ValidatePrivacySetting checks if a privacy setting is valid
-func (g *GeoPrivacyManagerImpl) ValidatePrivacySetting(setting string) bool {
-	_, valid := g.privacyLevels[setting]
-	return valid
-}
@@ -0,0 +1,77 @@
+// internal/service/identity/persona.go
+
+package identity
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+
+	"essg/internal/domain/identity"
+)
+
+// Word lists for ephemeral nicknames
+var (
+	nicknameAdjectives = []string{
+		"Amber", "Brisk", "Calm", "Dusky", "Eager", "Fleet", "Gentle", "Hazy",
+		"Idle", "Jolly", "Keen", "Lucky", "Mellow", "Nimble", "Quiet", "Rapid",
+		"Silver", "Tidy", "Vivid", "Witty",
+	}
+	nicknameNouns = []string{
+		"Badger", "Comet", "Dune", "Falcon", "Grove", "Harbor", "Heron", "Lantern",
+		"Maple", "Meadow", "Otter", "Pebble", "Raven", "Ridge", "Sparrow", "Tide",
+		"Willow", "Wren",
+	}
+)
+
+// PersonaService implements the identity.PersonaGenerator interface. Personas
+// are derived from a keyed hash of the user and space, so a user always gets the
+// same persona in a space while personas in different spaces share nothing that
+// links them without the server's secret.
+type PersonaService struct {
+	secret []byte
+}
+
+// NewPersonaService creates a new persona service
+func NewPersonaService(secret string) *PersonaService {
+	return &PersonaService{
+		secret: []byte(secret),
+	}
+}
+
+// GeneratePersona returns the persona for a user in a space. Retries after a
+// nickname collision salt the hash with the attempt, so they stay stable too.
+func (p *PersonaService) GeneratePersona(userID, spaceID string, attempt int) identity.Persona {
+	// Length-prefix the inputs so different pairs can't produce the same message
+	h := hmac.New(sha256.New, p.secret)
+	fmt.Fprintf(h, "persona:%d:%s:%d:%s", len(spaceID), spaceID, len(userID), userID)
+	if attempt > 0 {
+		fmt.Fprintf(h, ":%d", attempt)
+	}
+	sum := h.Sum(nil)
+
+	adjective := nicknameAdjectives[binary.BigEndian.Uint16(sum[0:2])%uint16(len(nicknameAdjectives))]
+	noun := nicknameNouns[binary.BigEndian.Uint16(sum[2:4])%uint16(len(nicknameNouns))]
+	number := int(sum[4]) % 100
+	hue := int(binary.BigEndian.Uint16(sum[5:7]) % 360)
+
+	return identity.Persona{
+		Nickname: fmt.Sprintf("%s %s %d", adjective, noun, number),
+		Avatar:   avatarDataURI(adjective[:1]+noun[:1], hue),
+	}
+}
+
+// avatarDataURI renders initials on a colored disc as an SVG data URI
+func avatarDataURI(initials string, hue int) string {
+	svg := fmt.Sprintf(
+		`<svg xmlns="http://www.w3.org/2000/svg" width="64" height="64" viewBox="0 0 64 64">`+
+			`<circle cx="32" cy="32" r="32" fill="hsl(%d,55%%,50%%)"/>`+
+			`<text x="32" y="41" font-family="sans-serif" font-size="24" font-weight="bold" text-anchor="middle" fill="#fff">%s</text>`+
+			`</svg>`,
+		hue, initials,
+	)
+
+	return "data:image/svg+xml;base64," + base64.StdEncoding.EncodeToString([]byte(svg))
+}
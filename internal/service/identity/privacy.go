@@ -0,0 +1,148 @@
+// internal/service/identity/privacy.go
+
+package identity
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+
+	"essg/internal/domain/identity"
+	"essg/internal/domain/trend"
+)
+
+// metersPerDegree is the length of a degree of latitude
+const metersPerDegree = 111320.0
+
+// PrivacySettings contains configuration for privacy
+type PrivacySettings struct {
+	Secret                 string
+	Precision              map[identity.LocationSharingLevel]float64 // Grid size in meters per sharing level
+	DefaultLocationSharing identity.LocationSharingLevel
+	DefaultAnonymity       bool
+	MessageRetention       time.Duration
+	TokenLifetime          time.Duration
+	GuestTokenLifetime     time.Duration
+}
+
+// PrivacyService implements the identity.LocationPrivacyManager and
+// identity.PrivacyConfig interfaces.
+//
+// Locations are snapped to the center of a grid cell sized for the sharing
+// level. Each user gets their own grid in each space, offset by a keyed hash
+// of the user and space, so repeated reads return the same point instead of
+// fresh noise that could be averaged away, and the same user's points in
+// different spaces don't line up.
+type PrivacyService struct {
+	settings PrivacySettings
+}
+
+// NewPrivacyService creates a new privacy service
+func NewPrivacyService(settings PrivacySettings) *PrivacyService {
+	return &PrivacyService{
+		settings: settings,
+	}
+}
+
+// ApplyPrivacySettings reduces a location to the precision a sharing preference allows
+func (p *PrivacyService) ApplyPrivacySettings(
+	location trend.Location,
+	preference identity.LocationSharingLevel,
+	scope identity.LocationScope,
+) *trend.Location {
+	precision := p.GetLocationPrecisionLevel(preference)
+	if precision <= 0 {
+		return nil
+	}
+
+	latOffset, lngOffset := p.gridOffset(scope, preference)
+
+	// Size cells in degrees, widening longitude toward the poles. Longitude
+	// cells are sized at the snapped latitude so they don't shift within a row.
+	latCell := precision / metersPerDegree
+	lat := math.Max(-90, math.Min(90, snap(location.Latitude, latCell, latOffset)))
+	lngCell := latCell / math.Max(math.Cos(lat*math.Pi/180.0), 0.01)
+
+	return &trend.Location{
+		Latitude:  lat,
+		Longitude: wrapLongitude(snap(location.Longitude, lngCell, lngOffset)),
+		Accuracy:  math.Max(precision, location.Accuracy),
+		Timestamp: location.Timestamp.Truncate(time.Minute),
+	}
+}
+
+// GetLocationPrecisionLevel returns the precision in meters for a sharing
+// preference, which is zero when sharing is disabled. Unknown preferences get
+// the coarsest precision.
+func (p *PrivacyService) GetLocationPrecisionLevel(preference identity.LocationSharingLevel) float64 {
+	if preference == identity.LocationSharingDisabled {
+		return 0
+	}
+
+	if precision, ok := p.settings.Precision[preference]; ok {
+		return precision
+	}
+
+	return p.settings.Precision[identity.LocationSharingApproximate]
+}
+
+// GetLocationSharingOptions returns available location sharing options
+func (p *PrivacyService) GetLocationSharingOptions() []identity.LocationSharingLevel {
+	return []identity.LocationSharingLevel{
+		identity.LocationSharingDisabled,
+		identity.LocationSharingApproximate,
+		identity.LocationSharingNeighborhood,
+		identity.LocationSharingPrecise,
+	}
+}
+
+// GetDefaultLocationSharing returns the default location sharing level
+func (p *PrivacyService) GetDefaultLocationSharing() identity.LocationSharingLevel {
+	return p.settings.DefaultLocationSharing
+}
+
+// GetAnonymityOptions returns anonymity options
+func (p *PrivacyService) GetAnonymityOptions() map[string]interface{} {
+	return map[string]interface{}{
+		"default_anonymous":  p.settings.DefaultAnonymity,
+		"per_space_personas": true,
+		"guest_sessions":     true,
+	}
+}
+
+// GetDataRetentionPolicy returns the data retention policy
+func (p *PrivacyService) GetDataRetentionPolicy() map[string]interface{} {
+	return map[string]interface{}{
+		"messages":     p.settings.MessageRetention.String(),
+		"tokens":       p.settings.TokenLifetime.String(),
+		"guest_tokens": p.settings.GuestTokenLifetime.String(),
+	}
+}
+
+// gridOffset returns a user's grid offset in a space as fractions of a cell
+func (p *PrivacyService) gridOffset(scope identity.LocationScope, preference identity.LocationSharingLevel) (float64, float64) {
+	h := hmac.New(sha256.New, []byte(p.settings.Secret))
+	fmt.Fprintf(h, "location:%d:%s:%d:%s:%s", len(scope.SpaceID), scope.SpaceID, len(scope.UserID), scope.UserID, preference)
+	sum := h.Sum(nil)
+
+	return unitFloat(sum[0:8]), unitFloat(sum[8:16])
+}
+
+// snap moves a coordinate to the center of its cell in a grid shifted by offset cells
+func snap(value, cell, offset float64) float64 {
+	shift := offset * cell
+	return math.Floor((value-shift)/cell)*cell + shift + cell/2
+}
+
+// wrapLongitude keeps a longitude within [-180, 180)
+func wrapLongitude(lng float64) float64 {
+	return math.Mod(math.Mod(lng+180, 360)+360, 360) - 180
+}
+
+// unitFloat maps eight bytes to [0, 1)
+func unitFloat(b []byte) float64 {
+	return float64(binary.BigEndian.Uint64(b)>>11) / float64(1<<53)
+}
@@ -0,0 +1,175 @@
+// internal/service/identity/privacy_test.go
+
+package identity
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"essg/internal/domain/identity"
+	"essg/internal/domain/trend"
+)
+
+func newTestPrivacyService() *PrivacyService {
+	return NewPrivacyService(PrivacySettings{
+		Secret: "test-secret",
+		Precision: map[identity.LocationSharingLevel]float64{
+			identity.LocationSharingPrecise:      100,
+			identity.LocationSharingNeighborhood: 1000,
+			identity.LocationSharingApproximate:  10000,
+		},
+	})
+}
+
+func TestApplyPrivacySettings(t *testing.T) {
+	p := newTestPrivacyService()
+	scope := identity.LocationScope{UserID: "user-1", SpaceID: "space-1"}
+	at := time.Date(2026, 10, 18, 12, 34, 56, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		location   trend.Location
+		preference identity.LocationSharingLevel
+		disabled   bool
+	}{
+		{
+			name:       "sharing disabled",
+			location:   trend.Location{Latitude: 51.5, Longitude: -0.12},
+			preference: identity.LocationSharingDisabled,
+			disabled:   true,
+		},
+		{
+			name:       "precise",
+			location:   trend.Location{Latitude: 51.5, Longitude: -0.12},
+			preference: identity.LocationSharingPrecise,
+		},
+		{
+			name:       "neighborhood",
+			location:   trend.Location{Latitude: -33.87, Longitude: 151.21},
+			preference: identity.LocationSharingNeighborhood,
+		},
+		{
+			name:       "approximate near the antimeridian",
+			location:   trend.Location{Latitude: 0, Longitude: 179.99},
+			preference: identity.LocationSharingApproximate,
+		},
+		{
+			name:       "approximate west of the antimeridian",
+			location:   trend.Location{Latitude: 0, Longitude: -179.99},
+			preference: identity.LocationSharingApproximate,
+		},
+		{
+			name:       "north pole",
+			location:   trend.Location{Latitude: 90, Longitude: 45},
+			preference: identity.LocationSharingApproximate,
+		},
+		{
+			name:       "south pole",
+			location:   trend.Location{Latitude: -90, Longitude: -45},
+			preference: identity.LocationSharingNeighborhood,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.location.Timestamp = at
+			got := p.ApplyPrivacySettings(tt.location, tt.preference, scope)
+			if tt.disabled {
+				if got != nil {
+					t.Fatalf("got %+v, want nil", *got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatal("got nil location")
+			}
+
+			// Repeated reads must not return fresh noise
+			again := p.ApplyPrivacySettings(tt.location, tt.preference, scope)
+			if *again != *got {
+				t.Errorf("second read %+v differs from first %+v", *again, *got)
+			}
+
+			if got.Latitude < -90 || got.Latitude > 90 {
+				t.Errorf("latitude %v out of range", got.Latitude)
+			}
+			if got.Longitude < -180 || got.Longitude >= 180 {
+				t.Errorf("longitude %v out of range", got.Longitude)
+			}
+
+			precision := p.GetLocationPrecisionLevel(tt.preference)
+			if got.Accuracy != precision {
+				t.Errorf("accuracy = %v, want %v", got.Accuracy, precision)
+			}
+			if !got.Timestamp.Equal(at.Truncate(time.Minute)) {
+				t.Errorf("timestamp = %v, want it truncated to the minute", got.Timestamp)
+			}
+
+			// The snapped point stays within a cell of the original
+			latCell := precision / metersPerDegree
+			if math.Abs(got.Latitude-tt.location.Latitude) > latCell {
+				t.Errorf("latitude moved %v, more than a cell of %v", math.Abs(got.Latitude-tt.location.Latitude), latCell)
+			}
+		})
+	}
+}
+
+func TestApplyPrivacySettingsDiffersAcrossSpaces(t *testing.T) {
+	p := newTestPrivacyService()
+	location := trend.Location{Latitude: 40.7128, Longitude: -74.006}
+
+	first := p.ApplyPrivacySettings(location, identity.LocationSharingNeighborhood, identity.LocationScope{UserID: "user-1", SpaceID: "space-1"})
+	second := p.ApplyPrivacySettings(location, identity.LocationSharingNeighborhood, identity.LocationScope{UserID: "user-1", SpaceID: "space-2"})
+
+	if *first == *second {
+		t.Errorf("same point %+v in different spaces", *first)
+	}
+}
+
+func TestSnap(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  float64
+		cell   float64
+		offset float64
+		want   float64
+	}{
+		{name: "cell center", value: 0.3, cell: 1, offset: 0, want: 0.5},
+		{name: "negative value", value: -0.3, cell: 1, offset: 0, want: -0.5},
+		{name: "on the edge", value: 1, cell: 1, offset: 0, want: 1.5},
+		{name: "shifted grid", value: 0.3, cell: 1, offset: 0.5, want: 0},
+		{name: "shifted grid above shift", value: 0.7, cell: 1, offset: 0.5, want: 1},
+		{name: "small cells", value: 10.04, cell: 0.1, offset: 0, want: 10.05},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := snap(tt.value, tt.cell, tt.offset); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("snap(%v, %v, %v) = %v, want %v", tt.value, tt.cell, tt.offset, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrapLongitude(t *testing.T) {
+	tests := []struct {
+		lng  float64
+		want float64
+	}{
+		{lng: 0, want: 0},
+		{lng: 179.5, want: 179.5},
+		{lng: 180, want: -180},
+		{lng: 180.5, want: -179.5},
+		{lng: -180, want: -180},
+		{lng: -180.5, want: 179.5},
+		{lng: 540, want: -180},
+		{lng: -541, want: 179},
+	}
+
+	for _, tt := range tests {
+		if got := wrapLongitude(tt.lng); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("wrapLongitude(%v) = %v, want %v", tt.lng, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+// internal/service/identity/reputation.go
+
+package identity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	"essg/internal/domain/identity"
+)
+
+// ReputationStore defines the storage interface for identity reputation
+type ReputationStore interface {
+	// SaveMessageAuthor records which identity wrote a message and applies credits queued for it, returning the author if any were
+	SaveMessageAuthor(ctx context.Context, messageID, spaceID, identityID string, createdAt time.Time) (*identity.EphemeralIdentity, error)
+
+	// CreditAuthor adds to a dimension of a message author's reputation once per giver, returning nil if nothing was credited.
+	// Credits for messages whose author isn't recorded yet are queued.
+	CreditAuthor(ctx context.Context, messageID, giverIdentityID, dimension string, delta float64) (*identity.EphemeralIdentity, error)
+
+	// AddStrike records a moderation strike against an identity in a space
+	AddStrike(ctx context.Context, spaceID, identityID string) (*identity.EphemeralIdentity, error)
+
+	// GetTrustInputs returns a user's reputation in a space and the trust tier carried over to them
+	GetTrustInputs(ctx context.Context, userID, spaceID string) (map[string]float64, identity.TrustTier, error)
+
+	// SetCarriedTrust sets the trust tier carried into the identity owner's future identities
+	SetCarriedTrust(ctx context.Context, identityID string, tier identity.TrustTier) error
+
+	// GetGiverStanding returns an identity's reputation, the trust tier carried over to its owner and whether its owner is a guest
+	GetGiverStanding(ctx context.Context, identityID string) (map[string]float64, identity.TrustTier, bool, error)
+}
+
+// ReputationConfig contains configuration for identity reputation
+type ReputationConfig struct {
+	HelpfulnessCredit float64 // Added per identity that reacts to a message
+	ReplyCredit       float64 // Added per identity that replies to a message
+	NewGiverWeight    float64 // Share of a credit given by identities that are still new
+	StrikePenalty     float64 // Subtracted from the score per strike
+	StrikeLimit       float64 // Strikes at which an identity is restricted
+	EstablishedScore  float64
+	TrustedScore      float64
+	CarryOver         bool // Carry a coarse tier into the user's new identities
+	QueueGroup        string
+	StoreTimeout      time.Duration
+}
+
+// ReputationService implements the identity.ReputationManager interface. It
+// follows every space's messages and reactions on the event bus, sharing the
+// work with other replicas through a queue group, and credits the authors that
+// others react and reply to. Credit from guests and restricted identities is
+// ignored, and credit from new identities counts for little, so throwaway
+// identities can't farm reputation for each other.
+//
+// Only a coarse tier is ever carried between spaces, and never above
+// established, so standing in one space can't be used to link identities or to
+// skip earning trust where privileges depend on it.
+type ReputationService struct {
+	store    ReputationStore
+	eventBus *nats.Conn
+	config   ReputationConfig
+	subs     []*nats.Subscription
+}
+
+// NewReputationService creates a new reputation service
+func NewReputationService(store ReputationStore, eventBus *nats.Conn, config ReputationConfig) *ReputationService {
+	return &ReputationService{
+		store:    store,
+		eventBus: eventBus,
+		config:   config,
+	}
+}
+
+// Start begins following message and reaction events
+func (r *ReputationService) Start() error {
+	msgSub, err := r.eventBus.QueueSubscribe("space.*.messages", r.config.QueueGroup, r.handleMessageEvent)
+	if err != nil {
+		return fmt.Errorf("error subscribing to messages: %w", err)
+	}
+	r.subs = append(r.subs, msgSub)
+
+	reactionSub, err := r.eventBus.QueueSubscribe("space.*.reactions", r.config.QueueGroup, r.handleReactionEvent)
+	if err != nil {
+		return fmt.Errorf("error subscribing to reactions: %w", err)
+	}
+	r.subs = append(r.subs, reactionSub)
+
+	return nil
+}
+
+// Stop stops following events, letting in-flight ones finish
+func (r *ReputationService) Stop(ctx context.Context) error {
+	for _, sub := range r.subs {
+		if err := sub.Drain(); err != nil {
+			return fmt.Errorf("error draining subscription: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// RecordStrike records a moderation strike against an identity in a space
+func (r *ReputationService) RecordStrike(
+	ctx context.Context,
+	spaceID, identityID string,
+) (*identity.EphemeralIdentity, error) {
+	ident, err := r.store.AddStrike(ctx, spaceID, identityID)
+	if err != nil {
+		return nil, fmt.Errorf("error adding strike: %w", err)
+	}
+
+	r.carryTrust(ctx, *ident)
+
+	return ident, nil
+}
+
+// GetTrustTier returns the trust tier a user holds in a space
+func (r *ReputationService) GetTrustTier(ctx context.Context, userID, spaceID string) (identity.TrustTier, error) {
+	reputation, carried, err := r.store.GetTrustInputs(ctx, userID, spaceID)
+	if err != nil {
+		return "", err
+	}
+
+	return r.effectiveTier(reputation, carried), nil
+}
+
+// effectiveTier derives a trust tier from reputation in a space and the tier
+// carried over from elsewhere
+func (r *ReputationService) effectiveTier(reputation map[string]float64, carried identity.TrustTier) identity.TrustTier {
+	tier := r.tierFor(reputation)
+
+	// Good standing elsewhere spares newcomers the new-user friction
+	if tier == identity.TrustNew && r.config.CarryOver && carried == identity.TrustEstablished {
+		return identity.TrustEstablished
+	}
+
+	return tier
+}
+
+// reputationEvent holds the fields of message and reaction events used for reputation
+type reputationEvent struct {
+	ID        string `json:"id"`
+	MessageID string `json:"message_id"`
+	ReplyToID string `json:"reply_to_id"`
+	Identity  struct {
+		ID string `json:"id"`
+	} `json:"identity"`
+	Time time.Time `json:"time"`
+}
+
+// handleMessageEvent records a message's author and credits the author of any message it replies to
+func (r *ReputationService) handleMessageEvent(msg *nats.Msg) {
+	event, spaceID, ok := r.parseEvent(msg)
+	if !ok || event.ID == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.config.StoreTimeout)
+	defer cancel()
+
+	createdAt := event.Time
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	// Reactions and replies handled by other replicas may have beaten the
+	// message here; their credits are applied now
+	author, err := r.store.SaveMessageAuthor(ctx, event.ID, spaceID, event.Identity.ID, createdAt)
+	if err != nil {
+		// Log error but continue
+		fmt.Printf("Error saving message author: %v\n", err)
+		return
+	}
+	if author != nil {
+		r.carryTrust(ctx, *author)
+	}
+
+	if event.ReplyToID != "" {
+		r.credit(ctx, event.ReplyToID, event.Identity.ID, identity.ReputationReplies, r.config.ReplyCredit)
+	}
+}
+
+// handleReactionEvent credits the author of the message reacted to
+func (r *ReputationService) handleReactionEvent(msg *nats.Msg) {
+	event, _, ok := r.parseEvent(msg)
+	if !ok || event.MessageID == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.config.StoreTimeout)
+	defer cancel()
+
+	r.credit(ctx, event.MessageID, event.Identity.ID, identity.ReputationHelpfulness, r.config.HelpfulnessCredit)
+}
+
+// parseEvent decodes an event and the space it was published to
+func (r *ReputationService) parseEvent(msg *nats.Msg) (*reputationEvent, string, bool) {
+	parts := strings.Split(msg.Subject, ".")
+	if len(parts) != 3 {
+		return nil, "", false
+	}
+
+	var event reputationEvent
+	if err := json.Unmarshal(msg.Data, &event); err != nil {
+		// Log error but continue
+		fmt.Printf("Error parsing reputation event: %v\n", err)
+		return nil, "", false
+	}
+
+	// Only events stamped with the sender's identity count
+	if event.Identity.ID == "" {
+		return nil, "", false
+	}
+
+	return &event, parts[1], true
+}
+
+// credit adds to a dimension of a message author's reputation, weighted by the giver's standing
+func (r *ReputationService) credit(ctx context.Context, messageID, giverID, dimension string, delta float64) {
+	weight, err := r.giverWeight(ctx, giverID)
+	if err != nil {
+		// Log error but continue
+		fmt.Printf("Error getting giver standing: %v\n", err)
+		return
+	}
+	if weight <= 0 {
+		return
+	}
+
+	author, err := r.store.CreditAuthor(ctx, messageID, giverID, dimension, delta*weight)
+	if err != nil {
+		// Log error but continue
+		fmt.Printf("Error crediting %s: %v\n", dimension, err)
+		return
+	}
+
+	if author != nil {
+		r.carryTrust(ctx, *author)
+	}
+}
+
+// giverWeight returns the share of a credit an identity's giving counts for
+func (r *ReputationService) giverWeight(ctx context.Context, giverID string) (float64, error) {
+	reputation, carried, isGuest, err := r.store.GetGiverStanding(ctx, giverID)
+	if err != nil {
+		return 0, err
+	}
+
+	if isGuest {
+		return 0, nil
+	}
+
+	switch r.effectiveTier(reputation, carried) {
+	case identity.TrustRestricted:
+		return 0, nil
+	case identity.TrustNew:
+		return r.config.NewGiverWeight, nil
+	default:
+		return 1, nil
+	}
+}
+
+// carryTrust updates the coarse tier carried into the identity owner's future
+// identities. Restriction drops it back to new rather than carrying the mark.
+func (r *ReputationService) carryTrust(ctx context.Context, ident identity.EphemeralIdentity) {
+	if !r.config.CarryOver {
+		return
+	}
+
+	var carried identity.TrustTier
+	switch r.tierFor(ident.Reputation) {
+	case identity.TrustRestricted:
+		carried = identity.TrustNew
+	case identity.TrustEstablished, identity.TrustTrusted:
+		carried = identity.TrustEstablished
+	default:
+		return
+	}
+
+	if err := r.store.SetCarriedTrust(ctx, ident.ID, carried); err != nil {
+		// Log error but continue
+		fmt.Printf("Error carrying trust: %v\n", err)
+	}
+}
+
+// tierFor derives a trust tier from reputation dimensions
+func (r *ReputationService) tierFor(reputation map[string]float64) identity.TrustTier {
+	strikes := reputation[identity.ReputationStrikes]
+	if strikes >= r.config.StrikeLimit {
+		return identity.TrustRestricted
+	}
+
+	score := reputation[identity.ReputationHelpfulness] +
+		reputation[identity.ReputationReplies] -
+		strikes*r.config.StrikePenalty
+
+	switch {
+	case score >= r.config.TrustedScore:
+		return identity.TrustTrusted
+	case score >= r.config.EstablishedScore:
+		return identity.TrustEstablished
+	default:
+		return identity.TrustNew
+	}
+}
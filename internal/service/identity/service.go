@@ -0,0 +1,286 @@
+// internal/service/identity/service.go
+
+package identity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"essg/internal/domain/identity"
+	"essg/internal/domain/space"
+	"essg/internal/domain/trend"
+)
+
+// IdentityStore defines the storage interface for users and ephemeral identities
+type IdentityStore interface {
+	// GetOrCreateUser returns the user linked to a platform account, creating it from defaults if needed
+	GetOrCreateUser(ctx context.Context, platform, platformID string, defaults identity.User) (*identity.User, error)
+
+	// CreateGuest creates a guest user with no linked accounts
+	CreateGuest(ctx context.Context, guest identity.User) (*identity.User, error)
+
+	// LinkGuest links a platform account to a guest, returning the account's user
+	LinkGuest(ctx context.Context, guestID, platform, platformID string) (*identity.User, error)
+
+	// GetUser retrieves a user by ID
+	GetUser(ctx context.Context, id string) (*identity.User, error)
+
+	// UpdateUserLocation updates a user's last known location
+	UpdateUserLocation(ctx context.Context, userID string, location trend.Location) error
+
+	// UpdateLocationSharing updates a user's location sharing preference
+	UpdateLocationSharing(ctx context.Context, userID string, level identity.LocationSharingLevel) error
+
+	// GetEphemeralIdentity retrieves the identity a user has in a space
+	GetEphemeralIdentity(ctx context.Context, userID, spaceID string) (*identity.EphemeralIdentity, error)
+
+	// UpdateEphemeralIdentity updates an identity's settings and activity
+	UpdateEphemeralIdentity(ctx context.Context, ident identity.EphemeralIdentity) error
+
+	// FindSpaceIdentities returns the identities of a space's current members
+	FindSpaceIdentities(ctx context.Context, spaceID string) ([]identity.EphemeralIdentity, error)
+
+	// RecordGuestIssued counts a guest session issued to a client in a window,
+	// returning the window's count including it
+	RecordGuestIssued(ctx context.Context, clientKey string, windowStart time.Time) (int, error)
+
+	// DeleteExpiredGuests removes guests created before a time that were never
+	// upgraded, hold no live token and have no identity, message or reaction
+	DeleteExpiredGuests(ctx context.Context, before time.Time) (int64, error)
+}
+
+// IdentityConfig contains configuration for users and their identities
+type IdentityConfig struct {
+	DefaultAnonymity       bool
+	DefaultLocationSharing identity.LocationSharingLevel
+	GuestTTL               time.Duration
+	GuestLimit             int           // Guest sessions a client may start per window
+	GuestLimitWindow       time.Duration // Window guest sessions are counted over
+	GuestCleanupInterval   time.Duration // How often expired guests are removed
+	StoreTimeout           time.Duration
+}
+
+// IdentityService implements the identity.Service and identity.GuestManager
+// interfaces. Ephemeral identities are created through the membership manager,
+// so a user's first identity in a space is also their join.
+type IdentityService struct {
+	store      IdentityStore
+	membership space.MembershipManager
+	tokens     identity.TokenManager
+	config     IdentityConfig
+	ctx        context.Context
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+// NewIdentityService creates a new identity service
+func NewIdentityService(
+	store IdentityStore,
+	membership space.MembershipManager,
+	tokens identity.TokenManager,
+	config IdentityConfig,
+) *IdentityService {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &IdentityService{
+		store:      store,
+		membership: membership,
+		tokens:     tokens,
+		config:     config,
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// Start begins periodically removing guests that expired without upgrading
+func (s *IdentityService) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.config.GuestCleanupInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-ticker.C:
+				s.expireGuests()
+			}
+		}
+	}()
+}
+
+// Stop stops the cleanup loop
+func (s *IdentityService) Stop(ctx context.Context) error {
+	s.cancel()
+
+	c := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(c)
+	}()
+
+	select {
+	case <-c:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return nil
+}
+
+// expireGuests removes guests whose sessions ran out without them being
+// upgraded or taking part in any space
+func (s *IdentityService) expireGuests() {
+	ctx, cancel := context.WithTimeout(s.ctx, s.config.StoreTimeout)
+	defer cancel()
+
+	if _, err := s.store.DeleteExpiredGuests(ctx, time.Now().Add(-s.config.GuestTTL)); err != nil {
+		fmt.Printf("Error removing expired guests: %v\n", err)
+	}
+}
+
+// GetOrCreateUser gets the user linked to a platform account or creates a new one
+func (s *IdentityService) GetOrCreateUser(ctx context.Context, platformID, platform string) (*identity.User, error) {
+	if platformID == "" || platform == "" {
+		return nil, fmt.Errorf("platform and platform ID are required")
+	}
+
+	user, err := s.store.GetOrCreateUser(ctx, platform, platformID, identity.User{
+		ID:                        uuid.New().String(),
+		CreatedAt:                 time.Now(),
+		LocationSharingPreference: s.config.DefaultLocationSharing,
+		DefaultAnonymity:          s.config.DefaultAnonymity,
+		NotificationPreferences:   map[string]bool{},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error getting or creating user: %w", err)
+	}
+
+	return user, nil
+}
+
+// CreateGuestSession creates a guest user and issues them a short-lived token.
+// Guests always start anonymous, whatever the configured default. Each client
+// may only start so many per window, so rate limits can't be escaped by
+// starting over as a new guest.
+func (s *IdentityService) CreateGuestSession(ctx context.Context, clientKey string) (*identity.Session, error) {
+	if clientKey == "" {
+		return nil, fmt.Errorf("client key is required")
+	}
+
+	windowStart := time.Now().Truncate(s.config.GuestLimitWindow)
+	issued, err := s.store.RecordGuestIssued(ctx, clientKey, windowStart)
+	if err != nil {
+		return nil, fmt.Errorf("error recording guest session: %w", err)
+	}
+	if issued > s.config.GuestLimit {
+		return nil, identity.ErrTooManyGuests
+	}
+
+	guest, err := s.store.CreateGuest(ctx, identity.User{
+		ID:                        uuid.New().String(),
+		CreatedAt:                 time.Now(),
+		LocationSharingPreference: s.config.DefaultLocationSharing,
+		DefaultAnonymity:          true,
+		NotificationPreferences:   map[string]bool{},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating guest: %w", err)
+	}
+
+	token, err := s.tokens.GenerateToken(guest.ID, s.config.GuestTTL)
+	if err != nil {
+		return nil, fmt.Errorf("error generating guest token: %w", err)
+	}
+
+	return &identity.Session{User: guest, Token: token}, nil
+}
+
+// UpgradeGuest links a platform account to a guest and replaces their guest
+// tokens with a full session for the account's user
+func (s *IdentityService) UpgradeGuest(
+	ctx context.Context,
+	guestID, platformID, platform string,
+) (*identity.Session, error) {
+	if platformID == "" || platform == "" {
+		return nil, fmt.Errorf("platform and platform ID are required")
+	}
+
+	user, err := s.store.LinkGuest(ctx, guestID, platform, platformID)
+	if err != nil {
+		return nil, fmt.Errorf("error linking guest: %w", err)
+	}
+
+	// Guest tokens carry guest limits, so they stop working once upgraded
+	if err := s.tokens.RevokeAllForUser(guestID); err != nil {
+		return nil, fmt.Errorf("error revoking guest tokens: %w", err)
+	}
+
+	token, err := s.tokens.GenerateToken(user.ID, 0)
+	if err != nil {
+		return nil, fmt.Errorf("error generating token: %w", err)
+	}
+
+	return &identity.Session{User: user, Token: token}, nil
+}
+
+// GetUser retrieves a user by ID
+func (s *IdentityService) GetUser(ctx context.Context, id string) (*identity.User, error) {
+	return s.store.GetUser(ctx, id)
+}
+
+// UpdateUserLocation updates a user's location
+func (s *IdentityService) UpdateUserLocation(ctx context.Context, userID string, location trend.Location) error {
+	return s.store.UpdateUserLocation(ctx, userID, location)
+}
+
+// UpdateLocationSharing updates a user's location sharing preferences
+func (s *IdentityService) UpdateLocationSharing(
+	ctx context.Context,
+	userID string,
+	level identity.LocationSharingLevel,
+) error {
+	switch level {
+	case identity.LocationSharingDisabled, identity.LocationSharingApproximate,
+		identity.LocationSharingNeighborhood, identity.LocationSharingPrecise:
+	default:
+		return fmt.Errorf("unknown location sharing level %q", level)
+	}
+
+	return s.store.UpdateLocationSharing(ctx, userID, level)
+}
+
+// GetOrCreateEphemeralIdentity gets a user's identity in a space, joining them
+// to the space if they have never been a member
+func (s *IdentityService) GetOrCreateEphemeralIdentity(
+	ctx context.Context,
+	userID, spaceID string,
+) (*identity.EphemeralIdentity, error) {
+	ident, err := s.store.GetEphemeralIdentity(ctx, userID, spaceID)
+	if err == nil {
+		return ident, nil
+	}
+	if !errors.Is(err, identity.ErrIdentityNotFound) {
+		return nil, fmt.Errorf("error getting ephemeral identity: %w", err)
+	}
+
+	return s.membership.JoinSpace(ctx, spaceID, userID)
+}
+
+// UpdateEphemeralIdentity updates an ephemeral identity
+func (s *IdentityService) UpdateEphemeralIdentity(ctx context.Context, ident identity.EphemeralIdentity) error {
+	return s.store.UpdateEphemeralIdentity(ctx, ident)
+}
+
+// GetIdentitiesInSpace retrieves the identities of a space's current members
+func (s *IdentityService) GetIdentitiesInSpace(ctx context.Context, spaceID string) ([]identity.EphemeralIdentity, error) {
+	return s.store.FindSpaceIdentities(ctx, spaceID)
+}
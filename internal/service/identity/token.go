@@ -0,0 +1,268 @@
+// internal/service/identity/token.go
+
+package identity
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+
+	"essg/internal/domain/identity"
+)
+
+// TokenStore defines the storage interface for issued tokens
+type TokenStore interface {
+	// SaveToken records an issued token, creating its user if needed
+	SaveToken(ctx context.Context, tokenID, userID string, createdAt, expiresAt time.Time) error
+
+	// GetTokenUser returns the user an unexpired token was issued to, reporting false if it is gone
+	GetTokenUser(ctx context.Context, tokenID string) (string, bool, error)
+
+	// DeleteToken removes an issued token
+	DeleteToken(ctx context.Context, tokenID string) error
+
+	// DeleteUserTokens removes every token issued to a user
+	DeleteUserTokens(ctx context.Context, userID string) error
+
+	// DeleteExpiredTokens removes tokens past their expiry
+	DeleteExpiredTokens(ctx context.Context) error
+}
+
+// TokenConfig contains configuration for authentication tokens
+type TokenConfig struct {
+	Secret          string
+	DefaultTTL      time.Duration
+	StoreTimeout    time.Duration
+	CleanupInterval time.Duration
+}
+
+// tokenClaims is the signed payload of a token
+type tokenClaims struct {
+	TokenID   string `json:"jti"`
+	UserID    string `json:"sub"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// TokenService implements the identity.TokenManager interface. Tokens are an
+// HMAC-signed payload naming the user; each is also recorded in storage so it
+// can be revoked before it expires.
+type TokenService struct {
+	store    TokenStore
+	eventBus *nats.Conn
+	config   TokenConfig
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// NewTokenService creates a new token service
+func NewTokenService(store TokenStore, eventBus *nats.Conn, config TokenConfig) *TokenService {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &TokenService{
+		store:    store,
+		eventBus: eventBus,
+		config:   config,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Start begins periodically removing expired tokens
+func (t *TokenService) Start() {
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+
+		ticker := time.NewTicker(t.config.CleanupInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-t.ctx.Done():
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(t.ctx, t.config.StoreTimeout)
+				if err := t.store.DeleteExpiredTokens(ctx); err != nil {
+					fmt.Printf("Error removing expired tokens: %v\n", err)
+				}
+				cancel()
+			}
+		}
+	}()
+}
+
+// Stop stops the cleanup loop
+func (t *TokenService) Stop(ctx context.Context) error {
+	t.cancel()
+
+	c := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(c)
+	}()
+
+	select {
+	case <-c:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return nil
+}
+
+// GenerateToken generates a token for a user, using the default lifetime when ttl is zero
+func (t *TokenService) GenerateToken(userID string, ttl time.Duration) (string, error) {
+	if userID == "" {
+		return "", fmt.Errorf("user ID is required")
+	}
+	if ttl <= 0 {
+		ttl = t.config.DefaultTTL
+	}
+
+	now := time.Now()
+	claims := tokenClaims{
+		TokenID:   uuid.New().String(),
+		UserID:    userID,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	}
+
+	token, err := t.sign(claims)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(t.ctx, t.config.StoreTimeout)
+	defer cancel()
+
+	if err := t.store.SaveToken(ctx, claims.TokenID, userID, now, time.Unix(claims.ExpiresAt, 0)); err != nil {
+		return "", fmt.Errorf("error saving token: %w", err)
+	}
+
+	return token, nil
+}
+
+// ValidateToken validates a token and returns the user ID
+func (t *TokenService) ValidateToken(token string) (string, error) {
+	claims, err := t.verify(token)
+	if err != nil {
+		return "", err
+	}
+
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return "", identity.ErrTokenExpired
+	}
+
+	ctx, cancel := context.WithTimeout(t.ctx, t.config.StoreTimeout)
+	defer cancel()
+
+	// A signed token is only good while its record exists
+	userID, ok, err := t.store.GetTokenUser(ctx, claims.TokenID)
+	if err != nil {
+		return "", fmt.Errorf("error checking token: %w", err)
+	}
+	if !ok || userID != claims.UserID {
+		return "", identity.ErrTokenRevoked
+	}
+
+	return userID, nil
+}
+
+// RevokeToken revokes a token
+func (t *TokenService) RevokeToken(token string) error {
+	claims, err := t.verify(token)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(t.ctx, t.config.StoreTimeout)
+	defer cancel()
+
+	return t.store.DeleteToken(ctx, claims.TokenID)
+}
+
+// RevokeAllForUser revokes all tokens for a user. Tokens are only checked when
+// a connection opens, so the revocation is announced for every replica to
+// close the user's open connections.
+func (t *TokenService) RevokeAllForUser(userID string) error {
+	ctx, cancel := context.WithTimeout(t.ctx, t.config.StoreTimeout)
+	defer cancel()
+
+	if err := t.store.DeleteUserTokens(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := t.publishRevocation(userID); err != nil {
+		// Log error but continue; the tokens no longer open new connections
+		fmt.Printf("Error publishing token revocation: %v\n", err)
+	}
+
+	return nil
+}
+
+// publishRevocation announces that a user's tokens were revoked
+func (t *TokenService) publishRevocation(userID string) error {
+	data, err := json.Marshal(identity.TokensRevokedEvent{
+		UserID:    userID,
+		RevokedAt: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("error marshaling revocation: %w", err)
+	}
+
+	return t.eventBus.Publish(identity.TokensRevokedSubject, data)
+}
+
+// sign encodes claims and appends their signature
+func (t *TokenService) sign(claims tokenClaims) (string, error) {
+	data, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("error marshaling token claims: %w", err)
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(t.mac(payload)), nil
+}
+
+// verify checks a token's signature and decodes its claims
+func (t *TokenService) verify(token string) (*tokenClaims, error) {
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, identity.ErrInvalidToken
+	}
+
+	provided, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(provided, t.mac(payload)) {
+		return nil, identity.ErrInvalidToken
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, identity.ErrInvalidToken
+	}
+
+	var claims tokenClaims
+	if err := json.Unmarshal(data, &claims); err != nil || claims.TokenID == "" || claims.UserID == "" {
+		return nil, identity.ErrInvalidToken
+	}
+
+	return &claims, nil
+}
+
+// mac computes the signature of a token payload
+func (t *TokenService) mac(payload string) []byte {
+	h := hmac.New(sha256.New, []byte(t.config.Secret))
+	h.Write([]byte(payload))
+	return h.Sum(nil)
+}
@@ -0,0 +1,83 @@
+// internal/service/messaging/history.go
+
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"essg/internal/domain/messaging"
+)
+
+// HistoryStore defines the storage interface for messages and reactions
+type HistoryStore interface {
+	// SaveMessage stores a message, returning the message first stored under its ID and whether it was newly stored
+	SaveMessage(ctx context.Context, message messaging.Message) (*messaging.Message, bool, error)
+
+	// SaveReaction stores a reaction to a message in a space, reporting false if the user had already given it
+	SaveReaction(ctx context.Context, spaceID, messageID, userID, reaction string, at time.Time) (bool, error)
+
+	// GetRecentMessages returns a space's latest messages, oldest first
+	GetRecentMessages(ctx context.Context, spaceID string, limit int) ([]messaging.Message, error)
+}
+
+// HistoryConfig contains configuration for message history
+type HistoryConfig struct {
+	MaxRecentMessages int // Most messages returned for a space at once
+}
+
+// HistoryService implements the messaging.History interface
+type HistoryService struct {
+	store  HistoryStore
+	config HistoryConfig
+}
+
+// NewHistoryService creates a new history service
+func NewHistoryService(store HistoryStore, config HistoryConfig) *HistoryService {
+	return &HistoryService{
+		store:  store,
+		config: config,
+	}
+}
+
+// SaveMessage stores a message before it is delivered
+func (h *HistoryService) SaveMessage(ctx context.Context, message messaging.Message) (*messaging.Message, bool, error) {
+	if message.ID == "" || message.SpaceID == "" {
+		return nil, false, fmt.Errorf("message needs an ID and a space")
+	}
+	if message.Type == "" {
+		message.Type = messaging.TypeText
+	}
+	if message.Status == "" {
+		message.Status = messaging.StatusDelivered
+	}
+	if message.CreatedAt.IsZero() {
+		message.CreatedAt = time.Now()
+	}
+
+	stored, created, err := h.store.SaveMessage(ctx, message)
+	if err != nil {
+		return nil, false, fmt.Errorf("error saving message: %w", err)
+	}
+
+	return stored, created, nil
+}
+
+// SaveReaction stores a reaction before it is delivered
+func (h *HistoryService) SaveReaction(
+	ctx context.Context,
+	spaceID, messageID, userID, reaction string,
+	at time.Time,
+) (bool, error) {
+	return h.store.SaveReaction(ctx, spaceID, messageID, userID, reaction, at)
+}
+
+// GetRecentMessages returns a space's latest messages, oldest first
+func (h *HistoryService) GetRecentMessages(ctx context.Context, spaceID string, limit int) ([]messaging.Message, error) {
+	if limit <= 0 || limit > h.config.MaxRecentMessages {
+		limit = h.config.MaxRecentMessages
+	}
+
+	return h.store.GetRecentMessages(ctx, spaceID, limit)
+}
@@ -0,0 +1,154 @@
+// internal/service/messaging/ratelimit.go
+
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"essg/internal/domain/identity"
+	"essg/internal/domain/messaging"
+)
+
+// RateLimitStore defines the storage interface for action counts
+type RateLimitStore interface {
+	// CountActions returns how many times a user took an action on a resource in a window
+	CountActions(ctx context.Context, userID, actionType, resourceID string, windowStart time.Time) (int, error)
+
+	// RecordAction counts an action against a user's window, returning the window's count including it
+	RecordAction(ctx context.Context, userID, actionType, resourceID string, windowStart time.Time) (int, error)
+}
+
+// RateLimitConfig contains configuration for rate limiting
+type RateLimitConfig struct {
+	Window       time.Duration
+	Limits       map[messaging.RateTier]int // Actions allowed per window for each tier
+	StoreTimeout time.Duration
+}
+
+// RateLimitService implements the messaging.RateLimiter interface with fixed
+// windows counted in storage, so limits hold across replicas. Each action type
+// is limited separately per resource, by the tier of the acting user.
+type RateLimitService struct {
+	store  RateLimitStore
+	users  identity.Service
+	trust  identity.ReputationManager
+	config RateLimitConfig
+}
+
+// NewRateLimitService creates a new rate limit service
+func NewRateLimitService(
+	store RateLimitStore,
+	users identity.Service,
+	trust identity.ReputationManager,
+	config RateLimitConfig,
+) *RateLimitService {
+	return &RateLimitService{
+		store:  store,
+		users:  users,
+		trust:  trust,
+		config: config,
+	}
+}
+
+// CheckLimit reports whether a user may take an action on a resource now
+func (r *RateLimitService) CheckLimit(userID, actionType, resourceID string) (bool, error) {
+	remaining, _, err := r.GetRemainingLimit(userID, actionType, resourceID)
+	if err != nil {
+		return false, err
+	}
+
+	return remaining > 0, nil
+}
+
+// RecordAction records an action for rate limiting
+func (r *RateLimitService) RecordAction(userID, actionType, resourceID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), r.config.StoreTimeout)
+	defer cancel()
+
+	_, err := r.store.RecordAction(ctx, userID, actionType, resourceID, r.windowStart(time.Now()))
+	return err
+}
+
+// AllowAction counts an action and reports whether it was within the user's
+// limit. Counting first and comparing the count it returns means concurrent
+// requests can't all pass a check before any of them is recorded.
+func (r *RateLimitService) AllowAction(userID, actionType, resourceID string) (bool, time.Time, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.config.StoreTimeout)
+	defer cancel()
+
+	tier, err := r.tier(ctx, userID, resourceID)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+
+	windowStart := r.windowStart(time.Now())
+	count, err := r.store.RecordAction(ctx, userID, actionType, resourceID, windowStart)
+	if err != nil {
+		return false, time.Time{}, fmt.Errorf("error recording action: %w", err)
+	}
+
+	return count <= r.config.Limits[tier], windowStart.Add(r.config.Window), nil
+}
+
+// GetRemainingLimit returns how many more actions are allowed and when the window resets
+func (r *RateLimitService) GetRemainingLimit(userID, actionType, resourceID string) (int, time.Time, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.config.StoreTimeout)
+	defer cancel()
+
+	tier, err := r.tier(ctx, userID, resourceID)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	windowStart := r.windowStart(time.Now())
+	count, err := r.store.CountActions(ctx, userID, actionType, resourceID, windowStart)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("error counting actions: %w", err)
+	}
+
+	remaining := r.config.Limits[tier] - count
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return remaining, windowStart.Add(r.config.Window), nil
+}
+
+// tier returns the rate tier a user acts under in a space. Unknown users and
+// restricted identities get the strictest tier; guests stay there however they
+// behave.
+func (r *RateLimitService) tier(ctx context.Context, userID, spaceID string) (messaging.RateTier, error) {
+	user, err := r.users.GetUser(ctx, userID)
+	if errors.Is(err, identity.ErrUserNotFound) {
+		return messaging.TierGuest, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error getting user: %w", err)
+	}
+
+	if user.IsGuest {
+		return messaging.TierGuest, nil
+	}
+
+	trust, err := r.trust.GetTrustTier(ctx, userID, spaceID)
+	if err != nil {
+		return "", fmt.Errorf("error getting trust tier: %w", err)
+	}
+
+	switch trust {
+	case identity.TrustRestricted:
+		return messaging.TierGuest, nil
+	case identity.TrustTrusted:
+		return messaging.TierTrusted, nil
+	default:
+		return messaging.TierMember, nil
+	}
+}
+
+// windowStart returns the start of the window containing a time
+func (r *RateLimitService) windowStart(at time.Time) time.Time {
+	return at.Truncate(r.config.Window)
+}
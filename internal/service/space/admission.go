@@ -0,0 +1,329 @@
+// internal/service/space/admission.go
+
+package space
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"essg/internal/domain/space"
+	"essg/internal/domain/trend"
+)
+
+// AdmissionStore defines the storage interface for space admission control
+type AdmissionStore interface {
+	// CountLiveSpaces counts the spaces that have not yet dissolved
+	CountLiveSpaces(ctx context.Context) (int, error)
+
+	// FindEvictionCandidates finds waning spaces below an engagement score, least engaged first
+	FindEvictionCandidates(ctx context.Context, maxScore float64, limit int) ([]string, error)
+
+	// SaveAdmissionDecision records an admission decision
+	SaveAdmissionDecision(ctx context.Context, d space.AdmissionDecision) error
+
+	// FindAdmissionDecisions finds admission decisions matching the filter, newest first
+	FindAdmissionDecisions(ctx context.Context, filter space.AdmissionFilter) ([]space.AdmissionDecision, error)
+}
+
+// AdmissionPolicy determines how trends are handled when spaces are at capacity
+type AdmissionPolicy string
+
+const (
+	// AdmissionPolicyQueue holds trends until existing spaces dissolve
+	AdmissionPolicyQueue AdmissionPolicy = "queue"
+
+	// AdmissionPolicyEvict also accelerates dissolution of the least engaged waning spaces
+	AdmissionPolicyEvict AdmissionPolicy = "evict"
+)
+
+// AdmissionConfig contains configuration for space admission control
+type AdmissionConfig struct {
+	Policy              AdmissionPolicy
+	MaxQueuedTrends     int
+	QueuedTrendTTL      time.Duration
+	EvictionGracePeriod time.Duration
+	EvictionMaxScore    float64
+}
+
+// queuedTrend is a trend waiting in the admission queue
+type queuedTrend struct {
+	trend    trend.Trend
+	queuedAt time.Time
+}
+
+// ListAdmissionDecisions returns recorded admission decisions, newest first
+func (sm *SpaceManager) ListAdmissionDecisions(
+	ctx context.Context,
+	filter space.AdmissionFilter,
+) ([]space.AdmissionDecision, error) {
+	return sm.admissionStore.FindAdmissionDecisions(ctx, filter)
+}
+
+// GetAdmissionQueue returns the trends waiting for capacity, best first
+func (sm *SpaceManager) GetAdmissionQueue(ctx context.Context) ([]space.QueuedTrend, error) {
+	sm.admissionMu.Lock()
+	defer sm.admissionMu.Unlock()
+
+	queue := make([]space.QueuedTrend, 0, len(sm.admissionQueue))
+	for _, q := range sm.admissionQueue {
+		queue = append(queue, space.QueuedTrend{
+			TrendID:  q.trend.ID,
+			Topic:    q.trend.Topic,
+			Score:    q.trend.Score,
+			QueuedAt: q.queuedAt,
+		})
+	}
+
+	return queue, nil
+}
+
+// admitTrend creates a space for a trend if capacity allows, otherwise queues it
+// and, under the evict policy, chooses a space to make room. The eviction is
+// returned for the caller to carry out once it releases admissionMu, which
+// callers must hold.
+func (sm *SpaceManager) admitTrend(ctx context.Context, t trend.Trend) (*space.Space, *space.AdmissionDecision, error) {
+	live, err := sm.admissionStore.CountLiveSpaces(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error counting live spaces: %w", err)
+	}
+
+	decision := sm.newDecision(t, live)
+
+	// Admit straight away while there is room
+	if !sm.atCapacity(live) {
+		s, err := sm.createFromTrend(ctx, t)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		decision.Outcome = space.AdmissionAdmitted
+		decision.Reason = "capacity available"
+		decision.SpaceID = s.ID
+		sm.recordDecision(ctx, decision)
+
+		return s, nil, nil
+	}
+
+	// Queue the trend until a slot frees up
+	if sm.enqueueTrend(ctx, t, live) {
+		decision.Outcome = space.AdmissionQueued
+		decision.Reason = fmt.Sprintf("at capacity, queued at position %d", sm.queuePosition(t.ID)+1)
+		sm.recordDecision(ctx, decision)
+	}
+
+	// Make room for queued trends by accelerating dissolution of idle spaces
+	var eviction *space.AdmissionDecision
+	if sm.config.Admission.Policy == AdmissionPolicyEvict {
+		eviction = sm.evictForQueue(ctx, t, live)
+	}
+
+	return nil, eviction, fmt.Errorf("%w: trend %s not admitted", space.ErrAtCapacity, t.ID)
+}
+
+// drainAdmissionQueue admits queued trends while capacity is available
+func (sm *SpaceManager) drainAdmissionQueue(ctx context.Context) {
+	sm.admissionMu.Lock()
+	defer sm.admissionMu.Unlock()
+
+	if len(sm.admissionQueue) == 0 {
+		return
+	}
+
+	live, err := sm.admissionStore.CountLiveSpaces(ctx)
+	if err != nil {
+		fmt.Printf("Error counting live spaces: %v\n", err)
+		return
+	}
+
+	sm.expireQueuedTrends(ctx, live)
+
+	for len(sm.admissionQueue) > 0 && !sm.atCapacity(live) {
+		// Take the best scoring trend
+		next := sm.admissionQueue[0]
+		sm.admissionQueue = sm.admissionQueue[1:]
+
+		s, err := sm.createFromTrend(ctx, next.trend)
+		if err != nil {
+			fmt.Printf("Error creating space for queued trend %s: %v\n", next.trend.ID, err)
+			continue
+		}
+
+		decision := sm.newDecision(next.trend, live)
+		decision.Outcome = space.AdmissionAdmitted
+		decision.Reason = fmt.Sprintf("admitted from queue after %s", time.Since(next.queuedAt).Round(time.Second))
+		decision.SpaceID = s.ID
+		sm.recordDecision(ctx, decision)
+
+		live++
+	}
+}
+
+// enqueueTrend adds a trend to the score-ordered queue, reporting whether it
+// was newly queued. Re-detected trends have their score refreshed in place.
+func (sm *SpaceManager) enqueueTrend(ctx context.Context, t trend.Trend, live int) bool {
+	defer sm.sortAdmissionQueue()
+
+	// Refresh trends that are already waiting
+	for i := range sm.admissionQueue {
+		if sm.admissionQueue[i].trend.ID == t.ID {
+			sm.admissionQueue[i].trend = t
+			return false
+		}
+	}
+
+	// Displace the weakest trend when the queue is full
+	if sm.config.Admission.MaxQueuedTrends > 0 && len(sm.admissionQueue) >= sm.config.Admission.MaxQueuedTrends {
+		weakest := sm.admissionQueue[len(sm.admissionQueue)-1]
+
+		if weakest.trend.Score >= t.Score {
+			decision := sm.newDecision(t, live)
+			decision.Outcome = space.AdmissionRejected
+			decision.Reason = "at capacity and admission queue full"
+			sm.recordDecision(ctx, decision)
+			return false
+		}
+
+		sm.admissionQueue = sm.admissionQueue[:len(sm.admissionQueue)-1]
+
+		decision := sm.newDecision(weakest.trend, live)
+		decision.Outcome = space.AdmissionRejected
+		decision.Reason = fmt.Sprintf("displaced from queue by trend %s", t.ID)
+		sm.recordDecision(ctx, decision)
+	}
+
+	sm.admissionQueue = append(sm.admissionQueue, queuedTrend{
+		trend:    t,
+		queuedAt: time.Now(),
+	})
+
+	return true
+}
+
+// expireQueuedTrends drops trends that have waited longer than the queue TTL
+func (sm *SpaceManager) expireQueuedTrends(ctx context.Context, live int) {
+	if sm.config.Admission.QueuedTrendTTL <= 0 {
+		return
+	}
+
+	kept := sm.admissionQueue[:0]
+	for _, q := range sm.admissionQueue {
+		if time.Since(q.queuedAt) < sm.config.Admission.QueuedTrendTTL {
+			kept = append(kept, q)
+			continue
+		}
+
+		decision := sm.newDecision(q.trend, live)
+		decision.Outcome = space.AdmissionExpired
+		decision.Reason = fmt.Sprintf("waited longer than %s for capacity", sm.config.Admission.QueuedTrendTTL)
+		sm.recordDecision(ctx, decision)
+	}
+	sm.admissionQueue = kept
+}
+
+// evictForQueue chooses the least engaged waning space to dissolve early when
+// queued trends outnumber the evictions already in progress. The space is
+// reserved as an eviction so concurrent admissions don't choose it too, and
+// the returned decision is passed to evictSpace once admissionMu is released.
+func (sm *SpaceManager) evictForQueue(ctx context.Context, t trend.Trend, live int) *space.AdmissionDecision {
+	if len(sm.evictions) >= len(sm.admissionQueue) {
+		return nil
+	}
+
+	candidates, err := sm.admissionStore.FindEvictionCandidates(ctx, sm.config.Admission.EvictionMaxScore, len(sm.evictions)+1)
+	if err != nil {
+		fmt.Printf("Error finding eviction candidates: %v\n", err)
+		return nil
+	}
+
+	// Skip spaces whose eviction is still being started
+	for _, spaceID := range candidates {
+		if sm.evictions[spaceID] {
+			continue
+		}
+		sm.evictions[spaceID] = true
+
+		decision := sm.newDecision(t, live)
+		decision.Outcome = space.AdmissionEvicted
+		decision.Reason = fmt.Sprintf(
+			"accelerated dissolution of least engaged waning space to %s",
+			sm.config.Admission.EvictionGracePeriod,
+		)
+		decision.EvictedSpaceID = spaceID
+
+		return &decision
+	}
+
+	// Nothing idle enough to evict; the trend keeps waiting in the queue
+	return nil
+}
+
+// evictSpace accelerates dissolution of a space chosen by evictForQueue.
+// Callers must not hold admissionMu.
+func (sm *SpaceManager) evictSpace(ctx context.Context, decision space.AdmissionDecision) {
+	if err := sm.InitiateDissolution(ctx, decision.EvictedSpaceID, sm.config.Admission.EvictionGracePeriod); err != nil {
+		fmt.Printf("Error evicting space %s: %v\n", decision.EvictedSpaceID, err)
+		sm.releaseEviction(decision.EvictedSpaceID)
+		return
+	}
+
+	sm.recordDecision(ctx, decision)
+}
+
+// releaseEviction forgets a pending eviction once its space has dissolved
+func (sm *SpaceManager) releaseEviction(spaceID string) {
+	sm.admissionMu.Lock()
+	defer sm.admissionMu.Unlock()
+
+	delete(sm.evictions, spaceID)
+}
+
+// atCapacity reports whether no further spaces may be created
+func (sm *SpaceManager) atCapacity(live int) bool {
+	return sm.config.MaxConcurrentSpaces > 0 && live >= sm.config.MaxConcurrentSpaces
+}
+
+// queuePosition returns the index of a trend in the admission queue
+func (sm *SpaceManager) queuePosition(trendID string) int {
+	for i, q := range sm.admissionQueue {
+		if q.trend.ID == trendID {
+			return i
+		}
+	}
+	return -1
+}
+
+// sortAdmissionQueue orders queued trends by score, oldest first on ties
+func (sm *SpaceManager) sortAdmissionQueue() {
+	sort.SliceStable(sm.admissionQueue, func(i, j int) bool {
+		a, b := sm.admissionQueue[i], sm.admissionQueue[j]
+		if a.trend.Score != b.trend.Score {
+			return a.trend.Score > b.trend.Score
+		}
+		return a.queuedAt.Before(b.queuedAt)
+	})
+}
+
+// newDecision starts an admission decision for a trend
+func (sm *SpaceManager) newDecision(t trend.Trend, live int) space.AdmissionDecision {
+	return space.AdmissionDecision{
+		ID:           uuid.New().String(),
+		TrendID:      t.ID,
+		TrendTopic:   t.Topic,
+		TrendScore:   t.Score,
+		ActiveSpaces: live,
+		Capacity:     sm.config.MaxConcurrentSpaces,
+		DecidedAt:    time.Now(),
+	}
+}
+
+// recordDecision persists an admission decision
+func (sm *SpaceManager) recordDecision(ctx context.Context, d space.AdmissionDecision) {
+	if err := sm.admissionStore.SaveAdmissionDecision(ctx, d); err != nil {
+		// Log error but continue
+		fmt.Printf("Error saving admission decision: %v\n", err)
+	}
+}
@@ -0,0 +1,82 @@
+// internal/service/space/analytics.go
+
+package space
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"essg/internal/domain/space"
+)
+
+// AnalyticsStore defines the storage interface for space engagement analytics
+type AnalyticsStore interface {
+	// FindEngagementCurve retrieves a space's engagement between two times, aggregated into time buckets
+	FindEngagementCurve(ctx context.Context, spaceID string, from, to time.Time, bucket time.Duration) ([]space.EngagementPoint, error)
+}
+
+// AnalyticsConfig contains configuration for space analytics reports
+type AnalyticsConfig struct {
+	DefaultRange  time.Duration // Range reported when no start is given
+	DefaultBucket time.Duration
+	MaxBuckets    int // Most buckets a single report may span
+}
+
+// GetAnalytics returns a space's engagement between two times, aggregated into
+// buckets, with the thresholds its template applies to move it between stages.
+// A zero to means now, a zero from means the default range before to, and a
+// zero bucket means the default bucket.
+func (sm *SpaceManager) GetAnalytics(
+	ctx context.Context,
+	spaceID string,
+	from, to time.Time,
+	bucket time.Duration,
+) (*space.SpaceAnalytics, error) {
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		from = to.Add(-sm.config.Analytics.DefaultRange)
+	}
+	if bucket == 0 {
+		bucket = sm.config.Analytics.DefaultBucket
+	}
+
+	if !from.Before(to) {
+		return nil, fmt.Errorf("%w: from must be before to", space.ErrInvalidRange)
+	}
+	if bucket < time.Second {
+		return nil, fmt.Errorf("%w: bucket must be at least a second", space.ErrInvalidRange)
+	}
+	if to.Sub(from)/bucket > time.Duration(sm.config.Analytics.MaxBuckets) {
+		return nil, fmt.Errorf("%w: range spans more than %d buckets", space.ErrInvalidRange, sm.config.Analytics.MaxBuckets)
+	}
+
+	s, err := sm.spaceStore.GetSpace(ctx, spaceID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting space: %w", err)
+	}
+
+	curve, err := sm.analyticsStore.FindEngagementCurve(ctx, spaceID, from, to, bucket)
+	if err != nil {
+		return nil, fmt.Errorf("error getting engagement curve: %w", err)
+	}
+
+	// Report the thresholds the space is evaluated against
+	sm.applyTemplateSettings(s)
+	thresholds := DefaultLifecycleThresholds
+	if s.Lifecycle != nil {
+		thresholds = *s.Lifecycle
+	}
+
+	return &space.SpaceAnalytics{
+		SpaceID:    spaceID,
+		Stage:      s.LifecycleStage,
+		Thresholds: thresholds,
+		From:       from,
+		To:         to,
+		Bucket:     bucket,
+		Curve:      curve,
+	}, nil
+}
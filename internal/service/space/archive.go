@@ -0,0 +1,232 @@
+// internal/service/space/archive.go
+
+package space
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"essg/internal/domain/space"
+)
+
+// ArchiveStore defines the storage interface for space archives
+type ArchiveStore interface {
+	// SaveArchive saves an archive to storage
+	SaveArchive(ctx context.Context, archive space.Archive) error
+
+	// GetArchive retrieves an unexpired archive by space ID
+	GetArchive(ctx context.Context, spaceID string) (*space.Archive, error)
+
+	// DeleteExpiredArchives removes archives past their retention period
+	DeleteExpiredArchives(ctx context.Context) error
+
+	// GetTrendTopic retrieves the topic of a trend
+	GetTrendTopic(ctx context.Context, trendID string) (string, error)
+
+	// GetPeakUsers retrieves the highest user count recorded for a space
+	GetPeakUsers(ctx context.Context, spaceID string) (int, error)
+
+	// GetTopReactedMessages retrieves the most reacted-to messages of a space
+	GetTopReactedMessages(ctx context.Context, spaceID string, limit int) ([]space.ArchivedMessage, error)
+
+	// GetNicknames retrieves the ephemeral nicknames used in a space
+	GetNicknames(ctx context.Context, spaceID string) ([]string, error)
+
+	// GetEngagementCurve retrieves a space's engagement aggregated into time buckets
+	GetEngagementCurve(ctx context.Context, spaceID string, bucket time.Duration) ([]space.EngagementPoint, error)
+}
+
+// ArchiverConfig contains configuration for the space archiver
+type ArchiverConfig struct {
+	Retention       time.Duration
+	TopMessages     int
+	CurveBucket     time.Duration
+	CleanupInterval time.Duration
+}
+
+// SpaceArchiver implements the space.Archiver interface
+type SpaceArchiver struct {
+	store  ArchiveStore
+	config ArchiverConfig
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewSpaceArchiver creates a new space archiver
+func NewSpaceArchiver(store ArchiveStore, config ArchiverConfig) *SpaceArchiver {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &SpaceArchiver{
+		store:  store,
+		config: config,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Start begins periodically removing archives past their retention period
+func (a *SpaceArchiver) Start() {
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+
+		ticker := time.NewTicker(a.config.CleanupInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-a.ctx.Done():
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(a.ctx, time.Minute)
+				if err := a.store.DeleteExpiredArchives(ctx); err != nil {
+					fmt.Printf("Error removing expired archives: %v\n", err)
+				}
+				cancel()
+			}
+		}
+	}()
+}
+
+// Stop stops the cleanup loop
+func (a *SpaceArchiver) Stop(ctx context.Context) error {
+	a.cancel()
+
+	c := make(chan struct{})
+	go func() {
+		a.wg.Wait()
+		close(c)
+	}()
+
+	select {
+	case <-c:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return nil
+}
+
+// ArchiveSpace produces and stores an archive for a dissolved space
+func (a *SpaceArchiver) ArchiveSpace(ctx context.Context, s space.Space) (*space.Archive, error) {
+	now := time.Now()
+
+	archive := space.Archive{
+		SpaceID:         s.ID,
+		Title:           s.Title,
+		TrendID:         s.TrendID,
+		TemplateType:    s.TemplateType,
+		CreatedAt:       s.CreatedAt,
+		DissolvedAt:     now,
+		DurationSeconds: now.Sub(s.CreatedAt).Seconds(),
+		MessageCount:    s.MessageCount,
+		ArchivedAt:      now,
+		ExpiresAt:       now.Add(a.config.Retention),
+	}
+
+	// Resolve the originating trend
+	if s.TrendID != "" {
+		topic, err := a.store.GetTrendTopic(ctx, s.TrendID)
+		if err != nil {
+			return nil, fmt.Errorf("error getting trend topic: %w", err)
+		}
+		archive.TrendTopic = topic
+	}
+
+	// Find the peak audience, falling back to the final count
+	peakUsers, err := a.store.GetPeakUsers(ctx, s.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting peak users: %w", err)
+	}
+	if peakUsers < s.UserCount {
+		peakUsers = s.UserCount
+	}
+	archive.PeakUsers = peakUsers
+
+	// Collect top messages with participant nicknames removed
+	messages, err := a.store.GetTopReactedMessages(ctx, s.ID, a.config.TopMessages)
+	if err != nil {
+		return nil, fmt.Errorf("error getting top messages: %w", err)
+	}
+
+	nicknames, err := a.store.GetNicknames(ctx, s.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting nicknames: %w", err)
+	}
+
+	redactor := newNicknameRedactor(nicknames)
+	for i := range messages {
+		messages[i].Content = redactor.redact(messages[i].Content)
+	}
+	archive.TopMessages = messages
+
+	// Build the engagement curve
+	curve, err := a.store.GetEngagementCurve(ctx, s.ID, a.config.CurveBucket)
+	if err != nil {
+		return nil, fmt.Errorf("error getting engagement curve: %w", err)
+	}
+	archive.EngagementCurve = curve
+
+	// Save archive
+	if err := a.store.SaveArchive(ctx, archive); err != nil {
+		return nil, fmt.Errorf("error saving archive: %w", err)
+	}
+
+	return &archive, nil
+}
+
+// GetArchive returns the archive for a space if it is still retained
+func (a *SpaceArchiver) GetArchive(ctx context.Context, spaceID string) (*space.Archive, error) {
+	return a.store.GetArchive(ctx, spaceID)
+}
+
+// HandleLifecycleChange archives a space once it reaches its final stage
+func (a *SpaceArchiver) HandleLifecycleChange(s space.Space, stage space.LifecycleStage) error {
+	if stage != space.StageDissolved {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if _, err := a.ArchiveSpace(ctx, s); err != nil {
+		return fmt.Errorf("error archiving space %s: %w", s.ID, err)
+	}
+
+	return nil
+}
+
+// nicknameRedactor strips ephemeral nicknames from message content
+type nicknameRedactor struct {
+	pattern *regexp.Regexp
+}
+
+// newNicknameRedactor builds a redactor matching any of the given nicknames
+func newNicknameRedactor(nicknames []string) *nicknameRedactor {
+	if len(nicknames) == 0 {
+		return &nicknameRedactor{}
+	}
+
+	quoted := make([]string, 0, len(nicknames))
+	for _, nickname := range nicknames {
+		quoted = append(quoted, regexp.QuoteMeta(nickname))
+	}
+
+	// Match mentions with or without a leading @
+	pattern := regexp.MustCompile(`(?i)@?\b(?:` + strings.Join(quoted, "|") + `)\b`)
+
+	return &nicknameRedactor{pattern: pattern}
+}
+
+// redact replaces nickname mentions with a neutral placeholder
+func (r *nicknameRedactor) redact(content string) string {
+	if r.pattern == nil {
+		return content
+	}
+	return r.pattern.ReplaceAllString(content, "[participant]")
+}
@@ -0,0 +1,273 @@
+// internal/service/space/backtest.go
+
+package space
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"essg/internal/domain/space"
+)
+
+// BacktestStore defines the storage interface for replaying a space's history
+type BacktestStore interface {
+	// FindAnalyticsSamples returns every analytics sample recorded for a space, oldest first
+	FindAnalyticsSamples(ctx context.Context, spaceID string) ([]space.EngagementPoint, error)
+
+	// FindMessageActivity returns when each of a space's messages was sent, oldest first
+	FindMessageActivity(ctx context.Context, spaceID string) ([]space.MessageActivity, error)
+
+	// FindReactionActivity returns each reaction made in a space, oldest first
+	FindReactionActivity(ctx context.Context, spaceID string) ([]space.ReactionActivity, error)
+}
+
+// BacktestConfig contains configuration for the backtester
+type BacktestConfig struct {
+	ActivityLimit int // Messages and reactions scored per sample, matching what the analyzer fetches
+	Signals       SignalConfig
+}
+
+// StageTransition is a space moving into a lifecycle stage
+type StageTransition struct {
+	Time  time.Time
+	Stage space.LifecycleStage
+}
+
+// BacktestOutcome is how a space's lifecycle played out, either as recorded
+// or as replayed with an engagement model
+type BacktestOutcome struct {
+	Model       string
+	Transitions []StageTransition
+	DissolvedAt time.Time // Zero if the space never began dissolving
+	PeakScore   float64
+	MeanScore   float64
+}
+
+// BacktestResult compares a space's recorded lifecycle with replays of it
+type BacktestResult struct {
+	SpaceID      string
+	TemplateType space.TemplateType
+	Samples      int
+	Recorded     BacktestOutcome
+	Replays      []BacktestOutcome
+}
+
+// Backtester replays spaces' recorded analytics and message history through
+// engagement models, so models can be compared on the lifecycle outcomes
+// they would have produced. Geo factors are not replayed.
+type Backtester struct {
+	store      BacktestStore
+	spaceStore SpaceStore
+	config     BacktestConfig
+	templates  map[space.TemplateType]space.Template
+	mu         sync.RWMutex
+}
+
+// NewBacktester creates a new backtester
+func NewBacktester(store BacktestStore, spaceStore SpaceStore, config BacktestConfig) *Backtester {
+	return &Backtester{
+		store:      store,
+		spaceStore: spaceStore,
+		config:     config,
+		templates:  make(map[space.TemplateType]space.Template),
+	}
+}
+
+// RegisterTemplate registers a template whose lifecycle thresholds replays use
+func (b *Backtester) RegisterTemplate(template space.Template) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.templates[template.GetType()] = template
+}
+
+// Run replays each space with each model
+func (b *Backtester) Run(ctx context.Context, spaceIDs []string, models []space.EngagementModel) ([]BacktestResult, error) {
+	results := make([]BacktestResult, 0, len(spaceIDs))
+	for _, spaceID := range spaceIDs {
+		result, err := b.runSpace(ctx, spaceID, models)
+		if err != nil {
+			return nil, fmt.Errorf("error backtesting space %s: %w", spaceID, err)
+		}
+		results = append(results, *result)
+	}
+
+	return results, nil
+}
+
+// runSpace replays a single space
+func (b *Backtester) runSpace(ctx context.Context, spaceID string, models []space.EngagementModel) (*BacktestResult, error) {
+	s, err := b.spaceStore.GetSpace(ctx, spaceID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting space: %w", err)
+	}
+
+	samples, err := b.store.FindAnalyticsSamples(ctx, spaceID)
+	if err != nil {
+		return nil, fmt.Errorf("error finding analytics samples: %w", err)
+	}
+
+	messages, err := b.store.FindMessageActivity(ctx, spaceID)
+	if err != nil {
+		return nil, fmt.Errorf("error finding messages: %w", err)
+	}
+
+	reactions, err := b.store.FindReactionActivity(ctx, spaceID)
+	if err != nil {
+		return nil, fmt.Errorf("error finding reactions: %w", err)
+	}
+
+	result := &BacktestResult{
+		SpaceID:      spaceID,
+		TemplateType: s.TemplateType,
+		Samples:      len(samples),
+		Recorded:     recordedOutcome(samples),
+	}
+
+	thresholds := b.thresholdsFor(s.TemplateType)
+	for _, model := range models {
+		result.Replays = append(result.Replays, b.replay(s, samples, messages, reactions, model, thresholds))
+	}
+
+	return result, nil
+}
+
+// replay scores the space at each sample time and steps it through the
+// lifecycle the way the space manager's monitoring would have
+func (b *Backtester) replay(
+	s *space.Space,
+	samples []space.EngagementPoint,
+	messages []space.MessageActivity,
+	reactions []space.ReactionActivity,
+	model space.EngagementModel,
+	thresholds space.LifecycleThresholds,
+) BacktestOutcome {
+	outcome := BacktestOutcome{Model: model.Name()}
+	stage := space.StageCreating
+	var total float64
+	var scored int
+
+	for _, sample := range samples {
+		recentMessages := recentMessagesBefore(messages, sample.Time, b.config.ActivityLimit)
+		recentReactions := recentReactionsBefore(reactions, sample.Time, b.config.ActivityLimit)
+
+		activity := space.EngagementActivity{
+			At:          sample.Time,
+			Messages:    recentMessages,
+			Reactions:   recentReactions,
+			ActiveUsers: sample.ActiveUsers,
+			TotalUsers:  sample.UserCount,
+		}
+
+		metrics := model.Score(activity)
+		addEngagementSignals(metrics, activity, b.config.Signals)
+
+		score := metrics["engagement_score"]
+		total += score
+		scored++
+		if score > outcome.PeakScore {
+			outcome.PeakScore = score
+		}
+
+		// Spaces were last active when their latest message was sent
+		lastActive := s.CreatedAt
+		if len(recentMessages) > 0 {
+			lastActive = recentMessages[0].CreatedAt
+		}
+
+		next := nextLifecycleStage(stage, metrics, thresholds)
+		if next != space.StageDevolving && shouldDissolve(metrics, sample.Time.Sub(lastActive), s.IsGeoLocal) {
+			next = space.StageDevolving
+		}
+
+		if next != stage {
+			stage = next
+			outcome.Transitions = append(outcome.Transitions, StageTransition{Time: sample.Time, Stage: stage})
+		}
+
+		if stage == space.StageDevolving {
+			outcome.DissolvedAt = sample.Time
+			break
+		}
+	}
+
+	if scored > 0 {
+		outcome.MeanScore = total / float64(scored)
+	}
+
+	return outcome
+}
+
+// thresholdsFor returns the lifecycle thresholds of a template's spaces
+func (b *Backtester) thresholdsFor(templateType space.TemplateType) space.LifecycleThresholds {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if template, ok := b.templates[templateType]; ok {
+		return template.GetLifecycleThresholds()
+	}
+	return DefaultLifecycleThresholds
+}
+
+// recordedOutcome summarizes the lifecycle stages recorded with a space's samples
+func recordedOutcome(samples []space.EngagementPoint) BacktestOutcome {
+	outcome := BacktestOutcome{Model: "recorded"}
+	var stage space.LifecycleStage
+	var total float64
+
+	for _, sample := range samples {
+		total += sample.EngagementScore
+		if sample.EngagementScore > outcome.PeakScore {
+			outcome.PeakScore = sample.EngagementScore
+		}
+
+		if sample.LifecycleStage != stage {
+			stage = sample.LifecycleStage
+			outcome.Transitions = append(outcome.Transitions, StageTransition{Time: sample.Time, Stage: stage})
+		}
+
+		if outcome.DissolvedAt.IsZero() &&
+			(stage == space.StageDevolving || stage == space.StageDissolved) {
+			outcome.DissolvedAt = sample.Time
+		}
+	}
+
+	if len(samples) > 0 {
+		outcome.MeanScore = total / float64(len(samples))
+	}
+
+	return outcome
+}
+
+// recentMessagesBefore returns up to limit of the messages sent at or before
+// a time, newest first. Messages must be sorted oldest first.
+func recentMessagesBefore(messages []space.MessageActivity, at time.Time, limit int) []space.MessageActivity {
+	end := sort.Search(len(messages), func(i int) bool {
+		return messages[i].CreatedAt.After(at)
+	})
+
+	var recent []space.MessageActivity
+	for i := end - 1; i >= 0 && len(recent) < limit; i-- {
+		recent = append(recent, messages[i])
+	}
+
+	return recent
+}
+
+// recentReactionsBefore returns up to limit of the reactions made at or before
+// a time, newest first. Reactions must be sorted oldest first.
+func recentReactionsBefore(reactions []space.ReactionActivity, at time.Time, limit int) []space.ReactionActivity {
+	end := sort.Search(len(reactions), func(i int) bool {
+		return reactions[i].CreatedAt.After(at)
+	})
+
+	var recent []space.ReactionActivity
+	for i := end - 1; i >= 0 && len(recent) < limit; i-- {
+		recent = append(recent, reactions[i])
+	}
+
+	return recent
+}
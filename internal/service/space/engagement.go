@@ -6,7 +6,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"math"
+	"sort"
 	"sync"
 	"time"
 
@@ -14,24 +14,36 @@ import (
 	"github.com/nats-io/nats.go"
 
 	"essg/internal/domain/geo"
+	"essg/internal/domain/identity"
 	spaceDomain "essg/internal/domain/space"
 	"essg/internal/domain/trend"
 )
 
+// recentActivityLimit is how many of a space's latest messages and reactions are scored
+const recentActivityLimit = 100
+
 // EngagementAnalyzerConfig contains configuration for the engagement analyzer
 type EngagementAnalyzerConfig struct {
-	MonitoringInterval time.Duration
+	Models           []spaceDomain.EngagementModel // Models templates can choose from, besides the default
+	Signals          SignalConfig
+	LocalRatioSample float64 // Members sharing a location at which a local ratio is trusted halfway
 }
 
-// EngagementAnalyzer implements the space.EngagementAnalyzer interface
+// EngagementAnalyzer implements the space.EngagementAnalyzer interface. It
+// measures many spaces at once with a few set-based queries, so the space
+// manager can analyze every active space in a single pass each interval.
 type EngagementAnalyzer struct {
 	db         *pgxpool.Pool
 	eventBus   *nats.Conn
 	geoService geo.Service
+	presence   spaceDomain.PresenceTracker
+	privacy    identity.LocationPrivacyManager
 	config     EngagementAnalyzerConfig
-	monitoring sync.Map
-	ctx        context.Context
-	cancel     context.CancelFunc
+
+	// Engagement models and the one each template's spaces are scored with
+	models         map[string]spaceDomain.EngagementModel
+	templateModels map[spaceDomain.TemplateType]spaceDomain.EngagementModel
+	modelsMu       sync.RWMutex
 }
 
 // NewEngagementAnalyzer creates a new engagement analyzer
@@ -39,18 +51,73 @@ func NewEngagementAnalyzer(
 	db *pgxpool.Pool,
 	eventBus *nats.Conn,
 	geoService geo.Service,
+	presence spaceDomain.PresenceTracker,
+	privacy identity.LocationPrivacyManager,
 	config EngagementAnalyzerConfig,
 ) *EngagementAnalyzer {
-	ctx, cancel := context.WithCancel(context.Background())
+	models := map[string]spaceDomain.EngagementModel{
+		DefaultEngagementModelName: DefaultEngagementModel{},
+	}
+	for _, model := range config.Models {
+		models[model.Name()] = model
+	}
 
 	return &EngagementAnalyzer{
-		db:         db,
-		eventBus:   eventBus,
-		geoService: geoService,
-		config:     config,
-		ctx:        ctx,
-		cancel:     cancel,
+		db:             db,
+		eventBus:       eventBus,
+		geoService:     geoService,
+		presence:       presence,
+		privacy:        privacy,
+		config:         config,
+		models:         models,
+		templateModels: make(map[spaceDomain.TemplateType]spaceDomain.EngagementModel),
+	}
+}
+
+// ModelNames returns the names of the engagement models templates may select
+func (e *EngagementAnalyzer) ModelNames() []string {
+	e.modelsMu.RLock()
+	defer e.modelsMu.RUnlock()
+
+	names := make([]string, 0, len(e.models))
+	for name := range e.models {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// RegisterTemplate selects the engagement model for a template's spaces. The
+// template loader rejects unknown models, so templates registered some other
+// way that name one are scored with the default.
+func (e *EngagementAnalyzer) RegisterTemplate(template spaceDomain.Template) {
+	e.modelsMu.Lock()
+	defer e.modelsMu.Unlock()
+
+	name := template.GetEngagementModel()
+	if name == "" {
+		name = DefaultEngagementModelName
+	}
+
+	model, ok := e.models[name]
+	if !ok {
+		fmt.Printf("Unknown engagement model %q for template %s, using the default\n", name, template.GetType())
+		model = e.models[DefaultEngagementModelName]
+	}
+
+	e.templateModels[template.GetType()] = model
+}
+
+// modelFor returns the engagement model for a template's spaces
+func (e *EngagementAnalyzer) modelFor(templateType spaceDomain.TemplateType) spaceDomain.EngagementModel {
+	e.modelsMu.RLock()
+	defer e.modelsMu.RUnlock()
+
+	if model, ok := e.templateModels[templateType]; ok {
+		return model
 	}
+	return e.models[DefaultEngagementModelName]
 }
 
 // AnalyzeEngagement calculates engagement metrics for a space
@@ -61,240 +128,234 @@ func (e *EngagementAnalyzer) AnalyzeEngagement(ctx context.Context, spaceID stri
 		return nil, fmt.Errorf("error fetching space: %w", err)
 	}
 
-	// Fetch recent messages
-	recentMessages, err := e.getRecentMessages(ctx, spaceID)
+	engagement, err := e.AnalyzeSpaces(ctx, []*spaceDomain.Space{s})
+	if err != nil {
+		return nil, err
+	}
+
+	return engagement[0].Metrics, nil
+}
+
+// AnalyzeSpaces calculates engagement metrics for many spaces at once. Activity
+// and presence are fetched for all of them together rather than space by space.
+func (e *EngagementAnalyzer) AnalyzeSpaces(ctx context.Context, spaces []*spaceDomain.Space) ([]spaceDomain.SpaceEngagement, error) {
+	if len(spaces) == 0 {
+		return nil, nil
+	}
+
+	spaceIDs := make([]string, len(spaces))
+	var geoLocalIDs []string
+	for i, s := range spaces {
+		spaceIDs[i] = s.ID
+		if s.IsGeoLocal && s.Location != nil {
+			geoLocalIDs = append(geoLocalIDs, s.ID)
+		}
+	}
+
+	// Fetch recent messages and reactions
+	recentMessages, err := e.getRecentMessages(ctx, spaceIDs)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching messages: %w", err)
 	}
 
-	// Fetch active users
-	activeUsers, err := e.getActiveUsers(ctx, spaceID)
+	recentReactions, err := e.getRecentReactions(ctx, spaceIDs)
 	if err != nil {
-		return nil, fmt.Errorf("error fetching active users: %w", err)
+		return nil, fmt.Errorf("error fetching reactions: %w", err)
 	}
 
-	// Calculate activity metrics
-	messageVelocity := e.calculateMessageVelocity(recentMessages)
-	userRetention := e.calculateUserRetention(activeUsers, s.UserCount)
-	messageDepth := e.calculateMessageDepth(recentMessages)
+	// Count the identities connected right now
+	activeUsers, err := e.presence.CountPresentBySpace(ctx, spaceIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error counting present users: %w", err)
+	}
 
-	// Create base metrics
-	metrics := map[string]float64{
-		"message_velocity": messageVelocity,
-		"user_retention":   userRetention,
-		"message_depth":    messageDepth,
+	// Fetch member locations for the spaces geo factors apply to
+	var memberLocations map[string][]memberLocation
+	if len(geoLocalIDs) > 0 {
+		memberLocations, err = e.getMemberLocations(ctx, geoLocalIDs)
+		if err != nil {
+			// Log the error but continue without geo factors
+			fmt.Printf("Error fetching member locations: %v\n", err)
+		}
 	}
 
-	// Calculate overall engagement score
-	metrics["engagement_score"] = e.calculateEngagementScore(metrics)
+	now := time.Now()
+	engagement := make([]spaceDomain.SpaceEngagement, 0, len(spaces))
+	for _, s := range spaces {
+		activity := spaceDomain.EngagementActivity{
+			At:          now,
+			Messages:    recentMessages[s.ID],
+			Reactions:   recentReactions[s.ID],
+			ActiveUsers: activeUsers[s.ID],
+			TotalUsers:  s.UserCount,
+		}
 
-	// Apply geo factors for local spaces
-	if s.IsGeoLocal && s.Location != nil {
-		geoFactors, err := e.calculateGeoFactors(ctx, s)
-		if err != nil {
-			// Log the error but continue with base metrics
-			fmt.Printf("Error calculating geo factors: %v\n", err)
-		} else {
-			// Add geo-specific metrics
-			for k, v := range geoFactors {
-				metrics[k] = v
+		// Score the activity with the model the space's template uses, then
+		// add the reaction and tone signals every space is measured on
+		metrics := e.modelFor(s.TemplateType).Score(activity)
+		addEngagementSignals(metrics, activity, e.config.Signals)
+
+		// Apply geo factors for local spaces
+		if s.IsGeoLocal && s.Location != nil && memberLocations != nil {
+			geoFactors, err := e.calculateGeoFactors(ctx, s, memberLocations[s.ID])
+			if err != nil {
+				// Log the error but continue with base metrics
+				fmt.Printf("Error calculating geo factors: %v\n", err)
+			} else {
+				// Add geo-specific metrics
+				for k, v := range geoFactors {
+					metrics[k] = v
+				}
+
+				// Adjust engagement score for geo-local spaces
+				metrics["engagement_score"] = metrics["engagement_score"] * metrics["geo_multiplier"]
 			}
+		}
 
-			// Adjust engagement score for geo-local spaces
-			metrics["engagement_score"] = metrics["engagement_score"] * metrics["geo_multiplier"]
+		engagement = append(engagement, spaceDomain.SpaceEngagement{
+			Space:       s,
+			MeasuredAt:  now,
+			ActiveUsers: activeUsers[s.ID],
+			Metrics:     metrics,
+		})
+	}
+
+	return engagement, nil
+}
+
+// RecordEngagement stores measured engagement as analytics in one insert and
+// publishes it to the clients of each space
+func (e *EngagementAnalyzer) RecordEngagement(ctx context.Context, engagement []spaceDomain.SpaceEngagement) error {
+	if len(engagement) == 0 {
+		return nil
+	}
+
+	for _, measured := range engagement {
+		if err := e.publishMetrics(measured.Space.ID, measured.Space.LifecycleStage, measured.Metrics); err != nil {
+			// Log error but continue
+			fmt.Printf("Error publishing metrics for space %s: %v\n", measured.Space.ID, err)
 		}
 	}
 
-	return metrics, nil
+	if err := e.storeAnalytics(ctx, engagement); err != nil {
+		return fmt.Errorf("error storing analytics: %w", err)
+	}
+
+	return nil
 }
 
 // DetermineLifecycleStage determines the appropriate lifecycle stage
-func (e *EngagementAnalyzer) DetermineLifecycleStage(ctx context.Context, s *spaceDomain.Space) (spaceDomain.LifecycleStage, error) {
-	// If space is already in terminal states, don't change
-	if s.LifecycleStage == spaceDomain.StageDissolved ||
-		s.LifecycleStage == spaceDomain.StageDevolving {
-		return s.LifecycleStage, nil
-	}
+func (e *EngagementAnalyzer) DetermineLifecycleStage(engagement spaceDomain.SpaceEngagement) spaceDomain.LifecycleStage {
+	s := engagement.Space
 
-	// Get engagement metrics
-	metrics, err := e.AnalyzeEngagement(ctx, s.ID)
-	if err != nil {
-		return s.LifecycleStage, fmt.Errorf("error analyzing engagement: %w", err)
+	// Use the template's thresholds when the space carries them
+	thresholds := DefaultLifecycleThresholds
+	if s.Lifecycle != nil {
+		thresholds = *s.Lifecycle
 	}
 
-	// Get engagement score
+	return nextLifecycleStage(s.LifecycleStage, engagement.Metrics, thresholds)
+}
+
+// nextLifecycleStage moves a space between stages based on its engagement
+// score. Spaces flagged as toxic are held back rather than promoted to peak.
+func nextLifecycleStage(
+	current spaceDomain.LifecycleStage,
+	metrics map[string]float64,
+	thresholds spaceDomain.LifecycleThresholds,
+) spaceDomain.LifecycleStage {
 	score := metrics["engagement_score"]
+	flagged := isToxicityFlagged(metrics)
 
 	// Determine stage based on score and current stage
-	switch s.LifecycleStage {
+	switch current {
 	case spaceDomain.StageCreating:
 		// Always move from Creating to Growing
-		return spaceDomain.StageGrowing, nil
+		return spaceDomain.StageGrowing
 
 	case spaceDomain.StageGrowing:
-		if score > 70 {
-			return spaceDomain.StagePeak, nil
+		if score > thresholds.PeakScore && !flagged {
+			return spaceDomain.StagePeak
 		}
-		return spaceDomain.StageGrowing, nil
+		return spaceDomain.StageGrowing
 
 	case spaceDomain.StagePeak:
-		if score < 40 {
-			return spaceDomain.StageWaning, nil
+		if score < thresholds.WaningScore {
+			return spaceDomain.StageWaning
 		}
-		return spaceDomain.StagePeak, nil
+		return spaceDomain.StagePeak
 
 	case spaceDomain.StageWaning:
-		if score > 60 {
-			return spaceDomain.StagePeak, nil
+		if score > thresholds.RecoverScore && !flagged {
+			return spaceDomain.StagePeak
 		}
-		if score < 20 {
-			return spaceDomain.StageDevolving, nil
+		if score < thresholds.DevolveScore {
+			return spaceDomain.StageDevolving
 		}
-		return spaceDomain.StageWaning, nil
+		return spaceDomain.StageWaning
+
+	case spaceDomain.StageDevolving, spaceDomain.StageDissolved:
+		return current
 
 	default:
-		return spaceDomain.StageGrowing, nil
+		return spaceDomain.StageGrowing
 	}
 }
 
 // ShouldDissolve determines if a space should begin dissolution
-func (e *EngagementAnalyzer) ShouldDissolve(ctx context.Context, s *spaceDomain.Space) (bool, error) {
+func (e *EngagementAnalyzer) ShouldDissolve(engagement spaceDomain.SpaceEngagement) bool {
+	s := engagement.Space
+
 	// If space is already dissolving or dissolved, no need to check
 	if s.LifecycleStage == spaceDomain.StageDevolving ||
 		s.LifecycleStage == spaceDomain.StageDissolved {
-		return false, nil
+		return false
 	}
 
-	// Get engagement metrics
-	metrics, err := e.AnalyzeEngagement(ctx, s.ID)
-	if err != nil {
-		return false, fmt.Errorf("error analyzing engagement: %w", err)
-	}
+	return shouldDissolve(engagement.Metrics, engagement.MeasuredAt.Sub(s.LastActive), s.IsGeoLocal)
+}
 
-	// Get engagement score
+// shouldDissolve applies the dissolution criteria to a space's engagement
+// metrics and how long it has been since it was last active
+func shouldDissolve(metrics map[string]float64, timeSinceLastActive time.Duration, isGeoLocal bool) bool {
 	score := metrics["engagement_score"]
-
-	// Check time since last activity
-	timeSinceLastActive := time.Since(s.LastActive)
-
-	// Check message velocity
 	messageVelocity := metrics["message_velocity"]
-
-	// Check user retention
 	userRetention := metrics["user_retention"]
 
 	// Dissolution criteria
 
 	// Very low engagement score for a significant period
 	if score < 10 && timeSinceLastActive > 2*time.Hour {
-		return true, nil
+		return true
 	}
 
 	// No messages for a long time
 	if messageVelocity < 0.01 && timeSinceLastActive > 6*time.Hour {
-		return true, nil
+		return true
 	}
 
 	// Very low user retention and low message velocity
 	if userRetention < 0.1 && messageVelocity < 0.5 && timeSinceLastActive > 4*time.Hour {
-		return true, nil
+		return true
 	}
 
 	// For geo-local spaces, use different criteria
-	if s.IsGeoLocal {
+	if isGeoLocal {
 		geoMultiplier, ok := metrics["geo_multiplier"]
 		if ok && geoMultiplier < 0.2 && timeSinceLastActive > 4*time.Hour {
-			return true, nil
+			return true
 		}
 	}
 
-	return false, nil
-}
-
-// StartMonitoring begins monitoring a space's engagement
-func (e *EngagementAnalyzer) StartMonitoring(ctx context.Context, spaceID string) error {
-	// Check if already monitoring
-	if _, exists := e.monitoring.Load(spaceID); exists {
-		return nil
-	}
-
-	// Start monitoring goroutine
-	monitorCtx, cancel := context.WithCancel(e.ctx)
-	e.monitoring.Store(spaceID, cancel)
-
-	go e.monitorSpace(monitorCtx, spaceID)
-
-	return nil
-}
-
-// StopMonitoring stops monitoring a space's engagement
-func (e *EngagementAnalyzer) StopMonitoring(ctx context.Context, spaceID string) error {
-	// Get cancel function
-	cancelI, exists := e.monitoring.Load(spaceID)
-	if !exists {
-		return nil
-	}
-
-	// Call cancel function
-	if cancel, ok := cancelI.(context.CancelFunc); ok {
-		cancel()
-	}
-
-	// Remove from monitoring map
-	e.monitoring.Delete(spaceID)
-
-	return nil
-}
-
-// monitorSpace continuously monitors a space's engagement
-func (e *EngagementAnalyzer) monitorSpace(ctx context.Context, spaceID string) {
-	ticker := time.NewTicker(e.config.MonitoringInterval)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			// Get space
-			s, err := e.getSpace(ctx, spaceID)
-			if err != nil {
-				fmt.Printf("Error getting space %s: %v\n", spaceID, err)
-				continue
-			}
-
-			// Skip spaces in terminal states
-			if s.LifecycleStage == spaceDomain.StageDissolved {
-				e.StopMonitoring(context.Background(), spaceID)
-				return
-			}
-
-			// Analyze engagement
-			metrics, err := e.AnalyzeEngagement(ctx, spaceID)
-			if err != nil {
-				fmt.Printf("Error analyzing engagement for space %s: %v\n", spaceID, err)
-				continue
-			}
-
-			// Store analytics
-			if err := e.storeAnalytics(ctx, spaceID, s.LifecycleStage, metrics); err != nil {
-				fmt.Printf("Error storing analytics for space %s: %v\n", spaceID, err)
-			}
-
-			// Publish metrics
-			if err := e.publishMetrics(spaceID, metrics); err != nil {
-				fmt.Printf("Error publishing metrics for space %s: %v\n", spaceID, err)
-			}
-		}
-	}
+	return false
 }
 
 // getSpace fetches a space by ID
 func (e *EngagementAnalyzer) getSpace(ctx context.Context, spaceID string) (*spaceDomain.Space, error) {
 	query := `
-		SELECT id, title, description, trend_id, template_type::text, lifecycle_stage::text,
+		SELECT id, title, description, COALESCE(trend_id, ''), template_type, lifecycle_stage::text,
 			created_at, last_active, user_count, message_count, 
-			ST_X(location::geometry) as lat, ST_Y(location::geometry) as lng,
+			ST_X(location::geometry) as lng, ST_Y(location::geometry) as lat,
 			location_radius, is_geo_local, topic_tags
 		FROM spaces
 		WHERE id = $1
@@ -308,7 +369,7 @@ func (e *EngagementAnalyzer) getSpace(ctx context.Context, spaceID string) (*spa
 	err := e.db.QueryRow(ctx, query, spaceID).Scan(
 		&s.ID, &s.Title, &s.Description, &s.TrendID, &templateType, &lifecycleStage,
 		&s.CreatedAt, &s.LastActive, &s.UserCount, &s.MessageCount,
-		&lat, &lng, &s.LocationRadius, &s.IsGeoLocal, &topicTags,
+		&lng, &lat, &s.LocationRadius, &s.IsGeoLocal, &topicTags,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("error querying space: %w", err)
@@ -333,36 +394,36 @@ func (e *EngagementAnalyzer) getSpace(ctx context.Context, spaceID string) (*spa
 	return &s, nil
 }
 
-// getRecentMessages fetches recent messages for a space
-func (e *EngagementAnalyzer) getRecentMessages(ctx context.Context, spaceID string) ([]Message, error) {
+// getRecentMessages fetches the recent messages of several spaces, newest first
+func (e *EngagementAnalyzer) getRecentMessages(ctx context.Context, spaceIDs []string) (map[string][]spaceDomain.MessageActivity, error) {
 	query := `
-		SELECT id, user_id, created_at, reply_to_id
-		FROM messages
-		WHERE space_id = $1
-		ORDER BY created_at DESC
-		LIMIT 100
+		SELECT s.id, m.created_at, m.reply_to_id IS NOT NULL, COALESCE(m.content, '')
+		FROM unnest($1::text[]) AS s(id)
+		CROSS JOIN LATERAL (
+			SELECT created_at, reply_to_id, content
+			FROM messages
+			WHERE space_id = s.id
+			ORDER BY created_at DESC
+			LIMIT $2
+		) m
+		ORDER BY s.id, m.created_at DESC
 	`
 
-	rows, err := e.db.Query(ctx, query, spaceID)
+	rows, err := e.db.Query(ctx, query, spaceIDs, recentActivityLimit)
 	if err != nil {
 		return nil, fmt.Errorf("error querying messages: %w", err)
 	}
 	defer rows.Close()
 
-	var messages []Message
+	messages := make(map[string][]spaceDomain.MessageActivity, len(spaceIDs))
 	for rows.Next() {
-		var msg Message
-		var replyToID *string
-
-		if err := rows.Scan(&msg.ID, &msg.UserID, &msg.CreatedAt, &replyToID); err != nil {
+		var spaceID string
+		var msg spaceDomain.MessageActivity
+		if err := rows.Scan(&spaceID, &msg.CreatedAt, &msg.IsReply, &msg.Content); err != nil {
 			return nil, fmt.Errorf("error scanning message: %w", err)
 		}
 
-		if replyToID != nil {
-			msg.ReplyToID = *replyToID
-		}
-
-		messages = append(messages, msg)
+		messages[spaceID] = append(messages[spaceID], msg)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -372,262 +433,137 @@ func (e *EngagementAnalyzer) getRecentMessages(ctx context.Context, spaceID stri
 	return messages, nil
 }
 
-// Message represents a simplified message for engagement analysis
-type Message struct {
-	ID        string
-	UserID    string
-	CreatedAt time.Time
-	ReplyToID string
-}
-
-// getActiveUsers fetches active users for a space
-func (e *EngagementAnalyzer) getActiveUsers(ctx context.Context, spaceID string) ([]string, error) {
+// getRecentReactions fetches the recent reactions in several spaces, newest first
+func (e *EngagementAnalyzer) getRecentReactions(ctx context.Context, spaceIDs []string) (map[string][]spaceDomain.ReactionActivity, error) {
 	query := `
-		SELECT DISTINCT user_id
-		FROM messages
-		WHERE space_id = $1
-		AND created_at > NOW() - INTERVAL '1 hour'
+		SELECT s.id, r.created_at, r.user_id
+		FROM unnest($1::text[]) AS s(id)
+		CROSS JOIN LATERAL (
+			SELECT r.created_at, r.user_id
+			FROM reactions r
+			JOIN messages m ON m.id = r.message_id
+			WHERE m.space_id = s.id
+			ORDER BY r.created_at DESC
+			LIMIT $2
+		) r
+		ORDER BY s.id, r.created_at DESC
 	`
 
-	rows, err := e.db.Query(ctx, query, spaceID)
+	rows, err := e.db.Query(ctx, query, spaceIDs, recentActivityLimit)
 	if err != nil {
-		return nil, fmt.Errorf("error querying active users: %w", err)
+		return nil, fmt.Errorf("error querying reactions: %w", err)
 	}
 	defer rows.Close()
 
-	var users []string
+	reactions := make(map[string][]spaceDomain.ReactionActivity, len(spaceIDs))
 	for rows.Next() {
-		var userID string
-		if err := rows.Scan(&userID); err != nil {
-			return nil, fmt.Errorf("error scanning user ID: %w", err)
-		}
-		users = append(users, userID)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating users: %w", err)
-	}
-
-	return users, nil
-}
-
-// calculateMessageVelocity calculates message velocity (messages per minute)
-func (e *EngagementAnalyzer) calculateMessageVelocity(messages []Message) float64 {
-	if len(messages) == 0 {
-		return 0
-	}
-
-	// If only one message, use time since it was created
-	if len(messages) == 1 {
-		duration := time.Since(messages[0].CreatedAt)
-		if duration.Minutes() < 1 {
-			return 1.0 // At least one message per minute
-		}
-		return 1.0 / duration.Minutes()
-	}
-
-	// Get newest and oldest message times
-	newest := messages[0].CreatedAt
-	oldest := messages[len(messages)-1].CreatedAt
-
-	// Calculate duration
-	duration := newest.Sub(oldest)
-
-	// Calculate messages per minute
-	if duration.Minutes() < 1 {
-		return float64(len(messages))
-	}
-
-	return float64(len(messages)) / duration.Minutes()
-}
-
-// calculateUserRetention calculates user retention
-func (e *EngagementAnalyzer) calculateUserRetention(activeUsers []string, totalUsers int) float64 {
-	if totalUsers == 0 {
-		return 0
-	}
-
-	return float64(len(activeUsers)) / float64(totalUsers)
-}
-
-// calculateMessageDepth calculates message depth (replies / total messages)
-func (e *EngagementAnalyzer) calculateMessageDepth(messages []Message) float64 {
-	if len(messages) == 0 {
-		return 0
-	}
-
-	// Count replies
-	replies := 0
-	for _, msg := range messages {
-		if msg.ReplyToID != "" {
-			replies++
+		var spaceID string
+		var reaction spaceDomain.ReactionActivity
+		if err := rows.Scan(&spaceID, &reaction.CreatedAt, &reaction.UserID); err != nil {
+			return nil, fmt.Errorf("error scanning reaction: %w", err)
 		}
-	}
-
-	return float64(replies) / float64(len(messages))
-}
-
-// calculateEngagementScore calculates overall engagement score
-func (e *EngagementAnalyzer) calculateEngagementScore(metrics map[string]float64) float64 {
-	// Weights for different metrics
-	weights := map[string]float64{
-		"message_velocity": 0.5,
-		"user_retention":   0.3,
-		"message_depth":    0.2,
-	}
-
-	// Calculate weighted score
-	var score float64
-	for metric, weight := range weights {
-		if value, ok := metrics[metric]; ok {
-			score += value * weight
-		}
-	}
-
-	// Normalize to 0-100 scale
-	normalizedScore := math.Min(100, score*100)
-
-	return normalizedScore
-}
-
-// calculateGeoFactors calculates geo-specific engagement factors
-func (e *EngagementAnalyzer) calculateGeoFactors(ctx context.Context, s *spaceDomain.Space) (map[string]float64, error) {
-	if s.Location == nil {
-		return nil, fmt.Errorf("space has no location")
-	}
-
-	// Get population density
-	density, err := e.geoService.GetPopulationDensity(ctx, *s.Location, s.LocationRadius)
-	if err != nil {
-		return nil, fmt.Errorf("error getting population density: %w", err)
-	}
-
-	// Count local users
-	localUsers, err := e.countLocalUsers(ctx, s)
-	if err != nil {
-		return nil, fmt.Errorf("error counting local users: %w", err)
-	}
-
-	// Calculate local user ratio
-	localUserRatio := 0.0
-	if s.UserCount > 0 {
-		localUserRatio = float64(localUsers) / float64(s.UserCount)
-	}
 
-	// Calculate geo multiplier
-	// Higher for spaces with more local users relative to population density
-	geoMultiplier := 1.0
-	if density.DensityPerKm2 > 0 {
-		// Adjust for population density
-		// More credit for engagement in less dense areas
-		densityFactor := 1.0 / math.Log10(math.Max(10, density.DensityPerKm2))
-
-		// Combine with local user ratio
-		geoMultiplier = 1.0 + (localUserRatio * densityFactor * 2.0)
-	}
-
-	return map[string]float64{
-		"geo_multiplier":     geoMultiplier,
-		"local_user_ratio":   localUserRatio,
-		"population_density": density.DensityPerKm2,
-	}, nil
-}
-
-// countLocalUsers counts users within the space's location radius
-func (e *EngagementAnalyzer) countLocalUsers(ctx context.Context, s *spaceDomain.Space) (int, error) {
-	if s.Location == nil {
-		return 0, fmt.Errorf("space has no location")
+		reactions[spaceID] = append(reactions[spaceID], reaction)
 	}
 
-	query := `
-		SELECT COUNT(DISTINCT ei.user_id)
-		FROM ephemeral_identities ei
-		WHERE ei.space_id = $1
-		AND ei.location IS NOT NULL
-		AND ST_DWithin(
-			geography(ei.location),
-			geography(ST_MakePoint($2, $3)),
-			$4 * 1000
-		)
-	`
-
-	var count int
-	err := e.db.QueryRow(
-		ctx,
-		query,
-		s.ID,
-		s.Location.Longitude,
-		s.Location.Latitude,
-		s.LocationRadius,
-	).Scan(&count)
-
-	if err != nil {
-		return 0, fmt.Errorf("error querying local users: %w", err)
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating reactions: %w", err)
 	}
 
-	return count, nil
+	return reactions, nil
 }
 
-// storeAnalytics stores space analytics
-func (e *EngagementAnalyzer) storeAnalytics(
-	ctx context.Context,
-	spaceID string,
-	lifecycleStage spaceDomain.LifecycleStage,
-	metrics map[string]float64,
-) error {
+// storeAnalytics stores the analytics of many spaces in a single insert, and
+// keeps each space's latest metrics in its engagement metrics
+func (e *EngagementAnalyzer) storeAnalytics(ctx context.Context, engagement []spaceDomain.SpaceEngagement) error {
 	query := `
 		INSERT INTO space_analytics (
 			space_id, timestamp, user_count, message_count,
 			active_users, engagement_score, lifecycle_stage, metrics
-		) VALUES ($1, $2, $3, $4, $5, $6, $7::lifecycle_stage, $8)
+		)
+		SELECT
+			a.space_id, a.timestamp, a.user_count, a.message_count,
+			a.active_users, a.engagement_score, a.lifecycle_stage::lifecycle_stage, a.metrics::jsonb
+		FROM unnest(
+			$1::text[], $2::timestamptz[], $3::int[], $4::int[],
+			$5::int[], $6::float8[], $7::text[], $8::text[]
+		) AS a(
+			space_id, timestamp, user_count, message_count,
+			active_users, engagement_score, lifecycle_stage, metrics
+		)
 	`
 
-	// Get space data
-	s, err := e.getSpace(ctx, spaceID)
-	if err != nil {
-		return fmt.Errorf("error getting space: %w", err)
-	}
+	n := len(engagement)
+	spaceIDs := make([]string, n)
+	timestamps := make([]time.Time, n)
+	userCounts := make([]int32, n)
+	messageCounts := make([]int32, n)
+	activeUsers := make([]int32, n)
+	scores := make([]float64, n)
+	stages := make([]string, n)
+	metrics := make([]string, n)
+
+	for i, measured := range engagement {
+		// Convert metrics to JSONB
+		metricsJSON, err := json.Marshal(measured.Metrics)
+		if err != nil {
+			return fmt.Errorf("error marshaling metrics: %w", err)
+		}
 
-	// Get active users
-	activeUsers, err := e.getActiveUsers(ctx, spaceID)
-	if err != nil {
-		return fmt.Errorf("error getting active users: %w", err)
+		spaceIDs[i] = measured.Space.ID
+		timestamps[i] = measured.MeasuredAt
+		userCounts[i] = int32(measured.Space.UserCount)
+		messageCounts[i] = int32(measured.Space.MessageCount)
+		activeUsers[i] = int32(measured.ActiveUsers)
+		scores[i] = measured.Metrics["engagement_score"]
+		stages[i] = string(measured.Space.LifecycleStage)
+		metrics[i] = string(metricsJSON)
 	}
 
-	// Convert metrics to JSONB
-	metricsJSON, err := json.Marshal(metrics)
+	_, err := e.db.Exec(
+		ctx,
+		query,
+		spaceIDs,
+		timestamps,
+		userCounts,
+		messageCounts,
+		activeUsers,
+		scores,
+		stages,
+		metrics,
+	)
 	if err != nil {
-		return fmt.Errorf("error marshaling metrics: %w", err)
+		return fmt.Errorf("error inserting analytics: %w", err)
 	}
 
-	// Execute query
 	_, err = e.db.Exec(
 		ctx,
-		query,
-		spaceID,
-		time.Now(),
-		s.UserCount,
-		s.MessageCount,
-		len(activeUsers),
-		metrics["engagement_score"],
-		string(lifecycleStage),
-		metricsJSON,
+		`UPDATE spaces SET engagement_metrics = m.metrics::jsonb
+		FROM unnest($1::text[], $2::text[]) AS m(space_id, metrics)
+		WHERE spaces.id = m.space_id`,
+		spaceIDs,
+		metrics,
 	)
-
 	if err != nil {
-		return fmt.Errorf("error inserting analytics: %w", err)
+		return fmt.Errorf("error updating engagement metrics: %w", err)
 	}
 
 	return nil
 }
 
-// publishMetrics publishes engagement metrics to NATS
-func (e *EngagementAnalyzer) publishMetrics(spaceID string, metrics map[string]float64) error {
+// publishMetrics publishes engagement metrics to NATS, for clients that opt in to them
+func (e *EngagementAnalyzer) publishMetrics(
+	spaceID string,
+	lifecycleStage spaceDomain.LifecycleStage,
+	metrics map[string]float64,
+) error {
 	// Convert metrics to JSON
 	metricsJSON, err := json.Marshal(map[string]interface{}{
-		"space_id": spaceID,
-		"time":     time.Now(),
-		"metrics":  metrics,
+		"type":            "metrics",
+		"space_id":        spaceID,
+		"lifecycle_stage": lifecycleStage,
+		"time":            time.Now(),
+		"metrics":         metrics,
 	})
 
 	if err != nil {
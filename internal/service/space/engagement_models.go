@@ -0,0 +1,185 @@
+// internal/service/space/engagement_models.go
+
+package space
+
+import (
+	"math"
+	"time"
+
+	spaceDomain "essg/internal/domain/space"
+)
+
+// DefaultEngagementModelName names the model used when a template does not pick one
+const DefaultEngagementModelName = "default"
+
+// engagementWeights are how much each metric contributes to the engagement score
+var engagementWeights = map[string]float64{
+	"message_velocity": 0.5,
+	"user_retention":   0.3,
+	"message_depth":    0.2,
+}
+
+// DefaultEngagementModel scores spaces on the message rate across their most
+// recent messages, the share of users still present and how often they reply
+type DefaultEngagementModel struct{}
+
+// Name identifies the model in template definitions
+func (DefaultEngagementModel) Name() string {
+	return DefaultEngagementModelName
+}
+
+// Score returns engagement metrics for the activity
+func (DefaultEngagementModel) Score(activity spaceDomain.EngagementActivity) map[string]float64 {
+	metrics := map[string]float64{
+		"message_velocity": messageVelocity(activity.Messages, activity.At),
+		"user_retention":   userRetention(activity.ActiveUsers, activity.TotalUsers),
+		"message_depth":    messageDepth(activity.Messages),
+	}
+	metrics["engagement_score"] = engagementScore(metrics)
+
+	return metrics
+}
+
+// EWMAEngagementModel scores spaces on exponentially weighted message and
+// reaction rates, so recent activity counts for more than a burst long ago
+// and a space cools down steadily once it goes quiet
+type EWMAEngagementModel struct {
+	HalfLife       time.Duration // How long until activity counts for half as much
+	ReactionWeight float64       // How much a reaction counts towards velocity relative to a message
+}
+
+// Name identifies the model in template definitions
+func (EWMAEngagementModel) Name() string {
+	return "ewma"
+}
+
+// Score returns engagement metrics for the activity
+func (m EWMAEngagementModel) Score(activity spaceDomain.EngagementActivity) map[string]float64 {
+	var messageWeight, replyWeight float64
+	for _, msg := range activity.Messages {
+		w := m.decay(activity.At.Sub(msg.CreatedAt))
+		messageWeight += w
+		if msg.IsReply {
+			replyWeight += w
+		}
+	}
+
+	var reactionWeight float64
+	for _, reaction := range activity.Reactions {
+		reactionWeight += m.decay(activity.At.Sub(reaction.CreatedAt))
+	}
+
+	// Decayed counts over the mean lifetime of a weight give rates per minute
+	lifetime := m.HalfLife.Minutes() / math.Ln2
+
+	depth := 0.0
+	if messageWeight > 0 {
+		depth = replyWeight / messageWeight
+	}
+
+	metrics := map[string]float64{
+		"message_velocity":  messageWeight / lifetime,
+		"reaction_velocity": reactionWeight / lifetime,
+		"user_retention":    userRetention(activity.ActiveUsers, activity.TotalUsers),
+		"message_depth":     depth,
+	}
+	metrics["engagement_score"] = engagementScore(map[string]float64{
+		"message_velocity": metrics["message_velocity"] + m.ReactionWeight*metrics["reaction_velocity"],
+		"user_retention":   metrics["user_retention"],
+		"message_depth":    metrics["message_depth"],
+	})
+
+	return metrics
+}
+
+// decay returns the weight of activity of a given age
+func (m EWMAEngagementModel) decay(age time.Duration) float64 {
+	if age < 0 {
+		age = 0
+	}
+
+	return math.Exp(-math.Ln2 * age.Minutes() / m.HalfLife.Minutes())
+}
+
+// messageVelocity calculates message velocity (messages per minute)
+func messageVelocity(messages []spaceDomain.MessageActivity, at time.Time) float64 {
+	times := make([]time.Time, len(messages))
+	for i, msg := range messages {
+		times[i] = msg.CreatedAt
+	}
+
+	return activityVelocity(times, at)
+}
+
+// activityVelocity calculates how many times something happened per minute,
+// given the times newest first
+func activityVelocity(times []time.Time, at time.Time) float64 {
+	if len(times) == 0 {
+		return 0
+	}
+
+	// If only one event, use time since it happened
+	if len(times) == 1 {
+		duration := at.Sub(times[0])
+		if duration.Minutes() < 1 {
+			return 1.0 // At least one per minute
+		}
+		return 1.0 / duration.Minutes()
+	}
+
+	// Get newest and oldest times
+	newest := times[0]
+	oldest := times[len(times)-1]
+
+	// Calculate duration
+	duration := newest.Sub(oldest)
+
+	// Calculate events per minute
+	if duration.Minutes() < 1 {
+		return float64(len(times))
+	}
+
+	return float64(len(times)) / duration.Minutes()
+}
+
+// userRetention calculates the share of users still present.
+// Active users come from live presence, which can briefly count someone who
+// has already left, so the ratio is capped at 1.
+func userRetention(activeUsers int, totalUsers int) float64 {
+	if totalUsers == 0 {
+		return 0
+	}
+
+	return math.Min(float64(activeUsers)/float64(totalUsers), 1)
+}
+
+// messageDepth calculates message depth (replies / total messages)
+func messageDepth(messages []spaceDomain.MessageActivity) float64 {
+	if len(messages) == 0 {
+		return 0
+	}
+
+	// Count replies
+	replies := 0
+	for _, msg := range messages {
+		if msg.IsReply {
+			replies++
+		}
+	}
+
+	return float64(replies) / float64(len(messages))
+}
+
+// engagementScore combines metrics into an overall score from 0 to 100
+func engagementScore(metrics map[string]float64) float64 {
+	// Calculate weighted score
+	var score float64
+	for metric, weight := range engagementWeights {
+		if value, ok := metrics[metric]; ok {
+			score += value * weight
+		}
+	}
+
+	// Normalize to 0-100 scale
+	return math.Min(100, score*100)
+}
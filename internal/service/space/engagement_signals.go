@@ -0,0 +1,79 @@
+// internal/service/space/engagement_signals.go
+
+package space
+
+import (
+	"time"
+	"unicode/utf8"
+
+	spaceDomain "essg/internal/domain/space"
+)
+
+// SignalConfig contains configuration for the reaction and tone signals
+// added to every space's engagement metrics
+type SignalConfig struct {
+	Lexicon             *Lexicon // Scores message tone; sentiment and toxicity are left out when nil
+	ToxicityThreshold   float64  // Share of toxic messages at which a space is flagged
+	ToxicityMinMessages int      // Messages needed in the window before a space can be flagged
+}
+
+// addEngagementSignals adds reaction, message length, sentiment and toxicity
+// signals to the metrics a model scored. Models that report their own
+// reaction velocity keep it.
+func addEngagementSignals(metrics map[string]float64, activity spaceDomain.EngagementActivity, config SignalConfig) {
+	// Reactions
+	reactionTimes := make([]time.Time, len(activity.Reactions))
+	reactors := make(map[string]bool)
+	for i, reaction := range activity.Reactions {
+		reactionTimes[i] = reaction.CreatedAt
+		reactors[reaction.UserID] = true
+	}
+
+	if _, ok := metrics["reaction_velocity"]; !ok {
+		metrics["reaction_velocity"] = activityVelocity(reactionTimes, activity.At)
+	}
+	metrics["unique_reactors"] = float64(len(reactors))
+
+	if len(activity.Messages) == 0 {
+		return
+	}
+
+	// Message length and tone across the window
+	var length, sentiment float64
+	var toxic int
+	for _, msg := range activity.Messages {
+		length += float64(utf8.RuneCountInString(msg.Content))
+
+		if config.Lexicon != nil {
+			s, isToxic := config.Lexicon.Score(msg.Content)
+			sentiment += s
+			if isToxic {
+				toxic++
+			}
+		}
+	}
+
+	count := float64(len(activity.Messages))
+	metrics["message_length"] = length / count
+
+	if config.Lexicon == nil {
+		return
+	}
+
+	metrics["sentiment"] = sentiment / count
+	metrics["toxicity"] = float64(toxic) / count
+
+	// Flag heated but toxic spaces so they are held back rather than promoted
+	flagged := 0.0
+	if config.ToxicityThreshold > 0 &&
+		len(activity.Messages) >= config.ToxicityMinMessages &&
+		metrics["toxicity"] >= config.ToxicityThreshold {
+		flagged = 1
+	}
+	metrics["toxicity_flagged"] = flagged
+}
+
+// isToxicityFlagged reports whether metrics flag a space as toxic
+func isToxicityFlagged(metrics map[string]float64) bool {
+	return metrics["toxicity_flagged"] > 0
+}
@@ -0,0 +1,108 @@
+// internal/service/space/engagement_signals_test.go
+
+package space
+
+import (
+	"testing"
+	"time"
+
+	spaceDomain "essg/internal/domain/space"
+)
+
+func TestAddEngagementSignalsFlagsToxicity(t *testing.T) {
+	lexicon, err := ParseLexicon([]byte(`{
+		"valence": {"great": 3, "awful": -2},
+		"negators": ["not"],
+		"toxic": ["idiot", "shut up"]
+	}`))
+	if err != nil {
+		t.Fatalf("ParseLexicon: %v", err)
+	}
+
+	config := SignalConfig{
+		Lexicon:             lexicon,
+		ToxicityThreshold:   0.3,
+		ToxicityMinMessages: 4,
+	}
+
+	tests := []struct {
+		name     string
+		messages []string
+		config   SignalConfig
+		flagged  float64
+		toxicity float64
+	}{
+		{
+			name:     "toxic share over threshold",
+			messages: []string{"you idiot", "shut up already", "great point", "not awful"},
+			config:   config,
+			flagged:  1,
+			toxicity: 0.5,
+		},
+		{
+			name:     "toxic share under threshold",
+			messages: []string{"you idiot", "great point", "not awful", "great", "fine"},
+			config:   config,
+			flagged:  0,
+			toxicity: 0.2,
+		},
+		{
+			name:     "too few messages to flag",
+			messages: []string{"you idiot", "shut up"},
+			config:   config,
+			flagged:  0,
+			toxicity: 1,
+		},
+		{
+			name:     "flagging disabled",
+			messages: []string{"you idiot", "shut up", "idiot", "idiot"},
+			config:   SignalConfig{Lexicon: lexicon, ToxicityMinMessages: 4},
+			flagged:  0,
+			toxicity: 1,
+		},
+	}
+
+	now := time.Now()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			activity := spaceDomain.EngagementActivity{At: now}
+			for i, content := range tt.messages {
+				activity.Messages = append(activity.Messages, spaceDomain.MessageActivity{
+					CreatedAt: now.Add(-time.Duration(i) * time.Minute),
+					Content:   content,
+				})
+			}
+
+			metrics := map[string]float64{}
+			addEngagementSignals(metrics, activity, tt.config)
+
+			if metrics["toxicity"] != tt.toxicity {
+				t.Errorf("toxicity = %v, want %v", metrics["toxicity"], tt.toxicity)
+			}
+			if metrics["toxicity_flagged"] != tt.flagged {
+				t.Errorf("toxicity_flagged = %v, want %v", metrics["toxicity_flagged"], tt.flagged)
+			}
+			if got := isToxicityFlagged(metrics); got != (tt.flagged > 0) {
+				t.Errorf("isToxicityFlagged = %v, want %v", got, tt.flagged > 0)
+			}
+		})
+	}
+}
+
+func TestAddEngagementSignalsWithoutLexicon(t *testing.T) {
+	now := time.Now()
+	activity := spaceDomain.EngagementActivity{
+		At:       now,
+		Messages: []spaceDomain.MessageActivity{{CreatedAt: now, Content: "you idiot"}},
+	}
+
+	metrics := map[string]float64{}
+	addEngagementSignals(metrics, activity, SignalConfig{ToxicityThreshold: 0.1})
+
+	if _, ok := metrics["toxicity_flagged"]; ok {
+		t.Errorf("toxicity_flagged set without a lexicon")
+	}
+	if metrics["message_length"] != 9 {
+		t.Errorf("message_length = %v, want 9", metrics["message_length"])
+	}
+}
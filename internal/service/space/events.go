@@ -0,0 +1,149 @@
+// internal/service/space/events.go
+
+package space
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	"essg/internal/domain/space"
+)
+
+// EventStore defines the storage interface for recent space events
+type EventStore interface {
+	// SaveEvent records an event published to a space, returning its position in the log
+	SaveEvent(ctx context.Context, event space.SpaceEvent) (int64, error)
+
+	// FindEventsSince returns a space's events after a position in the log, oldest first
+	FindEventsSince(ctx context.Context, spaceID string, afterSeq int64, limit int) ([]space.SpaceEvent, error)
+}
+
+// EventLogConfig contains configuration for the space event log
+type EventLogConfig struct {
+	Topics       []string // Topics worth replaying; transient ones like typing are left out
+	QueueGroup   string
+	StoreTimeout time.Duration
+}
+
+// EventLogService implements the space.EventLog interface. It records the
+// events published to every space, sharing the work with other replicas
+// through a queue group, so clients can catch up on what they missed. Each
+// logged event is republished on the space's log topic with its position, so
+// streams can follow events in the order they are replayed.
+type EventLogService struct {
+	store    EventStore
+	eventBus *nats.Conn
+	config   EventLogConfig
+	topics   map[string]bool
+	sub      *nats.Subscription
+}
+
+// NewEventLogService creates a new event log service
+func NewEventLogService(store EventStore, eventBus *nats.Conn, config EventLogConfig) *EventLogService {
+	topics := make(map[string]bool, len(config.Topics))
+	for _, topic := range config.Topics {
+		topics[topic] = true
+	}
+
+	return &EventLogService{
+		store:    store,
+		eventBus: eventBus,
+		config:   config,
+		topics:   topics,
+	}
+}
+
+// Start begins recording space events
+func (l *EventLogService) Start() error {
+	sub, err := l.eventBus.QueueSubscribe("space.*.*", l.config.QueueGroup, l.handleEvent)
+	if err != nil {
+		return fmt.Errorf("error subscribing to space events: %w", err)
+	}
+	l.sub = sub
+
+	return nil
+}
+
+// Stop stops recording events, letting in-flight ones finish
+func (l *EventLogService) Stop(ctx context.Context) error {
+	if l.sub == nil {
+		return nil
+	}
+
+	if err := l.sub.Drain(); err != nil {
+		return fmt.Errorf("error draining subscription: %w", err)
+	}
+
+	return nil
+}
+
+// EventsSince returns a space's events after a position in the log, oldest first
+func (l *EventLogService) EventsSince(
+	ctx context.Context,
+	spaceID string,
+	afterSeq int64,
+	limit int,
+) ([]space.SpaceEvent, error) {
+	events, err := l.store.FindEventsSince(ctx, spaceID, afterSeq, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error finding events: %w", err)
+	}
+
+	return events, nil
+}
+
+// handleEvent records an event on one of the replayed topics
+func (l *EventLogService) handleEvent(msg *nats.Msg) {
+	parts := strings.Split(msg.Subject, ".")
+	if len(parts) != 3 || !l.topics[parts[2]] {
+		return
+	}
+
+	// Events are stamped with the time they happened; fall back to when it arrived
+	var stamp struct {
+		Time time.Time `json:"time"`
+	}
+	if err := json.Unmarshal(msg.Data, &stamp); err != nil {
+		// Log error but continue
+		fmt.Printf("Error parsing space event: %v\n", err)
+		return
+	}
+	if stamp.Time.IsZero() {
+		stamp.Time = time.Now()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), l.config.StoreTimeout)
+	defer cancel()
+
+	event := space.SpaceEvent{
+		SpaceID: parts[1],
+		Topic:   parts[2],
+		Data:    msg.Data,
+		Time:    stamp.Time,
+	}
+	seq, err := l.store.SaveEvent(ctx, event)
+	if err != nil {
+		// Log error but continue, passing the event on without a position so
+		// streams still deliver it live
+		fmt.Printf("Error saving space event: %v\n", err)
+	}
+
+	data, err := json.Marshal(map[string]interface{}{
+		"seq":   seq,
+		"topic": event.Topic,
+		"event": json.RawMessage(msg.Data),
+	})
+	if err != nil {
+		fmt.Printf("Error marshaling logged event: %v\n", err)
+		return
+	}
+
+	if err := l.eventBus.Publish(fmt.Sprintf("space.%s.%s", event.SpaceID, space.EventLogTopic), data); err != nil {
+		fmt.Printf("Error publishing logged event: %v\n", err)
+	}
+}
@@ -0,0 +1,167 @@
+// internal/service/space/geo_engagement.go
+
+package space
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"essg/internal/domain/geo"
+	"essg/internal/domain/identity"
+	spaceDomain "essg/internal/domain/space"
+	"essg/internal/domain/trend"
+)
+
+// memberLocation is where a space member last was, and how precisely they
+// agreed to share it
+type memberLocation struct {
+	UserID     string
+	Location   *trend.Location // Nil when the member has no location
+	ShareLevel identity.LocationSharingLevel
+}
+
+// localRatio is the share of a space's members who are local, and how far it
+// can be trusted
+type localRatio struct {
+	Ratio      float64
+	Confidence float64
+	Sharing    int // Members whose consented location counted
+	Members    int
+}
+
+// estimateLocalRatio estimates how many of a space's members are within its
+// radius, using only the locations they consented to share and only at the
+// precision they consented to. A member whose coarsened location could be on
+// either side of the edge counts as half local, and lowers the confidence.
+func (e *EngagementAnalyzer) estimateLocalRatio(s *spaceDomain.Space, members []memberLocation) localRatio {
+	result := localRatio{Members: len(members)}
+
+	var local, ambiguous float64
+	for _, member := range members {
+		if member.Location == nil {
+			continue
+		}
+
+		shared := e.privacy.ApplyPrivacySettings(*member.Location, member.ShareLevel, identity.LocationScope{
+			UserID:  member.UserID,
+			SpaceID: s.ID,
+		})
+		if shared == nil {
+			continue // Sharing disabled
+		}
+		result.Sharing++
+
+		distance := e.geoService.CalculateDistance(*shared, *s.Location)
+		uncertainty := shared.Accuracy / 1000
+
+		switch {
+		case distance+uncertainty <= s.LocationRadius:
+			local++
+		case distance-uncertainty <= s.LocationRadius:
+			ambiguous++
+		}
+	}
+
+	if result.Sharing == 0 {
+		return result
+	}
+
+	sharing := float64(result.Sharing)
+	result.Ratio = (local + ambiguous/2) / sharing
+
+	// Trust the ratio less when few members share a location, when those who
+	// do are a small part of the space, and when their precision leaves them
+	// straddling the edge
+	coverage := sharing / float64(result.Members)
+	certainty := 1 - ambiguous/sharing
+	sampleWeight := sharing / (sharing + math.Max(e.config.LocalRatioSample, 0))
+	result.Confidence = coverage * certainty * sampleWeight
+
+	return result
+}
+
+// calculateGeoFactors calculates geo-specific engagement factors
+func (e *EngagementAnalyzer) calculateGeoFactors(ctx context.Context, s *spaceDomain.Space, members []memberLocation) (map[string]float64, error) {
+	if s.Location == nil {
+		return nil, fmt.Errorf("space has no location")
+	}
+
+	ratio := e.estimateLocalRatio(s, members)
+	factors := map[string]float64{
+		"local_ratio":            ratio.Ratio,
+		"local_ratio_confidence": ratio.Confidence,
+		"location_sharing_users": float64(ratio.Sharing),
+		"geo_multiplier":         1.0,
+	}
+
+	// Get population density
+	density, err := e.geoService.GetPopulationDensity(ctx, *s.Location, s.LocationRadius)
+	if errors.Is(err, geo.ErrNoPopulationData) {
+		// Without population the local ratio is reported but not weighted
+		return factors, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting population density: %w", err)
+	}
+	factors["population_density"] = density.DensityPerKm2
+
+	// Calculate geo multiplier
+	// Higher for spaces with more local users relative to population density,
+	// discounted by how far the local ratio can be trusted
+	if density.DensityPerKm2 > 0 {
+		// Adjust for population density
+		// More credit for engagement in less dense areas
+		densityFactor := 1.0 / math.Log10(math.Max(10, density.DensityPerKm2))
+
+		// Combine with local user ratio
+		factors["geo_multiplier"] = 1.0 + (ratio.Ratio * ratio.Confidence * densityFactor * 2.0)
+	}
+
+	return factors, nil
+}
+
+// getMemberLocations fetches the current members of many spaces, with their
+// stored locations and sharing levels
+func (e *EngagementAnalyzer) getMemberLocations(ctx context.Context, spaceIDs []string) (map[string][]memberLocation, error) {
+	query := `
+		SELECT space_id, user_id, location_share_level::text,
+			ST_X(location::geometry) as lng, ST_Y(location::geometry) as lat
+		FROM ephemeral_identities
+		WHERE space_id = ANY($1)
+		AND left_at IS NULL
+	`
+
+	rows, err := e.db.Query(ctx, query, spaceIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error querying member locations: %w", err)
+	}
+	defer rows.Close()
+
+	members := make(map[string][]memberLocation, len(spaceIDs))
+	for rows.Next() {
+		var spaceID, shareLevel string
+		var member memberLocation
+		var lng, lat *float64
+		if err := rows.Scan(&spaceID, &member.UserID, &shareLevel, &lng, &lat); err != nil {
+			return nil, fmt.Errorf("error scanning member location: %w", err)
+		}
+
+		member.ShareLevel = identity.LocationSharingLevel(shareLevel)
+		if lng != nil && lat != nil {
+			member.Location = &trend.Location{
+				Latitude:  *lat,
+				Longitude: *lng,
+			}
+		}
+
+		members[spaceID] = append(members[spaceID], member)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating member locations: %w", err)
+	}
+
+	return members, nil
+}
@@ -0,0 +1,142 @@
+// internal/service/space/lexicon.go
+
+package space
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// defaultLexicon holds the built-in sentiment and toxicity word lists
+//
+//go:embed lexicon/default.json
+var defaultLexicon []byte
+
+// negationScalar dampens and flips the valence of words after a negator
+const negationScalar = -0.74
+
+// negationWindow is how many words back a negator reaches
+const negationWindow = 3
+
+// sentimentAlpha controls how quickly summed valence approaches -1 or 1
+const sentimentAlpha = 15
+
+// LexiconFile is the declarative form of a lexicon
+type LexiconFile struct {
+	Valence  map[string]float64 `json:"valence"`  // Word to valence, from -4 (negative) to 4 (positive)
+	Negators []string           `json:"negators"` // Words that flip the valence of those after them
+	Toxic    []string           `json:"toxic"`    // Words and phrases that make a message toxic
+}
+
+// Lexicon scores the sentiment and toxicity of messages from word lists,
+// without calling out to any external service
+type Lexicon struct {
+	valence      map[string]float64
+	negators     map[string]bool
+	toxic        map[string]bool
+	maxToxicSize int // Words in the longest toxic phrase
+}
+
+// ParseLexicon decodes and validates a lexicon
+func ParseLexicon(data []byte) (*Lexicon, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var file LexiconFile
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("error decoding lexicon: %w", err)
+	}
+
+	lexicon := &Lexicon{
+		valence:  make(map[string]float64, len(file.Valence)),
+		negators: make(map[string]bool, len(file.Negators)),
+		toxic:    make(map[string]bool, len(file.Toxic)),
+	}
+
+	for word, valence := range file.Valence {
+		if valence < -4 || valence > 4 {
+			return nil, fmt.Errorf("valence of %q must be between -4 and 4", word)
+		}
+		lexicon.valence[strings.ToLower(word)] = valence
+	}
+
+	for _, word := range file.Negators {
+		lexicon.negators[strings.ToLower(word)] = true
+	}
+
+	for _, phrase := range file.Toxic {
+		words := tokenize(phrase)
+		if len(words) == 0 {
+			return nil, fmt.Errorf("empty toxic phrase")
+		}
+		lexicon.toxic[strings.Join(words, " ")] = true
+		if len(words) > lexicon.maxToxicSize {
+			lexicon.maxToxicSize = len(words)
+		}
+	}
+
+	return lexicon, nil
+}
+
+// LoadDefaultLexicon returns the built-in lexicon
+func LoadDefaultLexicon() (*Lexicon, error) {
+	lexicon, err := ParseLexicon(defaultLexicon)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing default lexicon: %w", err)
+	}
+
+	return lexicon, nil
+}
+
+// Score returns a message's sentiment from -1 to 1 and whether it is toxic
+func (l *Lexicon) Score(text string) (float64, bool) {
+	words := tokenize(text)
+
+	var sum float64
+	for i, word := range words {
+		valence, ok := l.valence[word]
+		if !ok {
+			continue
+		}
+
+		for j := max(0, i-negationWindow); j < i; j++ {
+			if l.negators[words[j]] {
+				valence *= negationScalar
+				break
+			}
+		}
+
+		sum += valence
+	}
+
+	sentiment := sum / math.Sqrt(sum*sum+sentimentAlpha)
+
+	return sentiment, l.isToxic(words)
+}
+
+// isToxic reports whether any toxic word or phrase appears in the words
+func (l *Lexicon) isToxic(words []string) bool {
+	for i := range words {
+		for n := 1; n <= l.maxToxicSize && i+n <= len(words); n++ {
+			if l.toxic[strings.Join(words[i:i+n], " ")] {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// tokenize splits text into lowercase words, keeping apostrophes in contractions
+func tokenize(text string) []string {
+	text = strings.ToLower(strings.ReplaceAll(text, "’", "'"))
+
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+}
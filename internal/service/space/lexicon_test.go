@@ -0,0 +1,75 @@
+// internal/service/space/lexicon_test.go
+
+package space
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLexiconScore(t *testing.T) {
+	lexicon, err := ParseLexicon([]byte(`{
+		"valence": {"great": 3, "Awful": -2},
+		"negators": ["not", "don't"],
+		"toxic": ["idiot", "shut up"]
+	}`))
+	if err != nil {
+		t.Fatalf("ParseLexicon: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		text      string
+		sentiment float64
+		toxic     bool
+	}{
+		{name: "empty", text: "", sentiment: 0},
+		{name: "unknown words", text: "the weather today", sentiment: 0},
+		{name: "positive", text: "great", sentiment: 0.6124},
+		{name: "mixed", text: "great but awful", sentiment: 0.25},
+		{name: "case insensitive", text: "NOT AWFUL", sentiment: 0.3570},
+		{name: "negated", text: "not great", sentiment: -0.4973},
+		{name: "negated within window", text: "not really that great", sentiment: -0.4973},
+		{name: "negator out of reach", text: "not one bit of it great", sentiment: 0.6124},
+		{name: "curly apostrophe", text: "I don’t feel great", sentiment: -0.4973},
+		{name: "toxic word", text: "Idiot.", sentiment: 0, toxic: true},
+		{name: "toxic phrase", text: "Shut   up!", sentiment: 0, toxic: true},
+		{name: "split toxic phrase", text: "shut the door, look up", sentiment: 0},
+		{name: "toxic word inside another", text: "idiotic", sentiment: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sentiment, toxic := lexicon.Score(tt.text)
+			if math.Abs(sentiment-tt.sentiment) > 1e-4 {
+				t.Errorf("sentiment = %v, want %v", sentiment, tt.sentiment)
+			}
+			if toxic != tt.toxic {
+				t.Errorf("toxic = %v, want %v", toxic, tt.toxic)
+			}
+		})
+	}
+}
+
+func TestParseLexiconRejectsInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "valence out of range", data: `{"valence": {"great": 5}}`},
+		{name: "empty toxic phrase", data: `{"toxic": ["  "]}`},
+		{name: "unknown field", data: `{"words": {}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseLexicon([]byte(tt.data)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	if _, err := LoadDefaultLexicon(); err != nil {
+		t.Errorf("LoadDefaultLexicon: %v", err)
+	}
+}
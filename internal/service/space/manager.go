@@ -4,6 +4,7 @@ package space
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"sync"
 	"time"
@@ -23,6 +24,9 @@ type SpaceStore interface {
 	// GetSpace retrieves a space by ID
 	GetSpace(ctx context.Context, id string) (*space.Space, error)
 
+	// GetSpaces retrieves several spaces by ID, leaving out those that do not exist
+	GetSpaces(ctx context.Context, ids []string) ([]space.Space, error)
+
 	// FindSpaces finds spaces matching the filter
 	FindSpaces(ctx context.Context, filter space.SpaceFilter) ([]space.Space, error)
 
@@ -35,18 +39,28 @@ type SpaceManagerConfig struct {
 	EventsTopic         string
 	DefaultGracePeriod  time.Duration
 	MonitoringInterval  time.Duration
+	CountdownInterval   time.Duration // How often connected clients are reminded of a pending dissolution
 	MaxConcurrentSpaces int
+	Admission           AdmissionConfig
+	Analytics           AnalyticsConfig
 }
 
 // SpaceManager implements the space.Manager interface
 type SpaceManager struct {
 	spaceStore         SpaceStore
+	admissionStore     AdmissionStore
+	analyticsStore     AnalyticsStore
 	spaceTemplates     map[space.TemplateType]space.Template
 	engagementAnalyzer space.EngagementAnalyzer
 	eventBus           *nats.Conn
 	config             SpaceManagerConfig
 	lifecycleHandlers  []func(space.Space, space.LifecycleStage) error
 	activeSpaces       sync.Map
+	dissolutions       map[string]*pendingDissolution // Spaces with a dissolution scheduled
+	dissolutionsMu     sync.Mutex
+	admissionQueue     []queuedTrend
+	evictions          map[string]bool // Spaces being dissolved early to make room
+	admissionMu        sync.Mutex
 	ctx                context.Context
 	cancel             context.CancelFunc
 	mu                 sync.RWMutex
@@ -56,6 +70,8 @@ type SpaceManager struct {
 // NewSpaceManager creates a new space manager
 func NewSpaceManager(
 	spaceStore SpaceStore,
+	admissionStore AdmissionStore,
+	analyticsStore AnalyticsStore,
 	engagementAnalyzer space.EngagementAnalyzer,
 	eventBus *nats.Conn,
 	config SpaceManagerConfig,
@@ -64,11 +80,15 @@ func NewSpaceManager(
 
 	sm := &SpaceManager{
 		spaceStore:         spaceStore,
+		admissionStore:     admissionStore,
+		analyticsStore:     analyticsStore,
 		spaceTemplates:     make(map[space.TemplateType]space.Template),
 		engagementAnalyzer: engagementAnalyzer,
 		eventBus:           eventBus,
 		config:             config,
 		lifecycleHandlers:  []func(space.Space, space.LifecycleStage) error{},
+		evictions:          make(map[string]bool),
+		dissolutions:       make(map[string]*pendingDissolution),
 		ctx:                ctx,
 		cancel:             cancel,
 	}
@@ -87,8 +107,22 @@ func (sm *SpaceManager) RegisterTemplate(template space.Template) {
 	sm.spaceTemplates[template.GetType()] = template
 }
 
-// CreateSpace creates a new ephemeral space from a detected trend
+// CreateSpace creates a new ephemeral space from a detected trend, subject to admission control
 func (sm *SpaceManager) CreateSpace(ctx context.Context, trend trend.Trend) (*space.Space, error) {
+	sm.admissionMu.Lock()
+	s, eviction, err := sm.admitTrend(ctx, trend)
+	sm.admissionMu.Unlock()
+
+	// Dissolve outside admission so other trends aren't held up by its I/O
+	if eviction != nil {
+		sm.evictSpace(ctx, *eviction)
+	}
+
+	return s, err
+}
+
+// createFromTrend instantiates and launches a space for a trend
+func (sm *SpaceManager) createFromTrend(ctx context.Context, trend trend.Trend) (*space.Space, error) {
 	// Select the best template for this trend
 	template := sm.selectBestTemplate(trend)
 	if template == nil {
@@ -117,19 +151,19 @@ func (sm *SpaceManager) CreateSpace(ctx context.Context, trend trend.Trend) (*sp
 		s.IsGeoLocal = trend.IsGeoLocal
 	}
 
+	return sm.launchSpace(ctx, s)
+}
+
+// launchSpace saves a newly instantiated space and starts its lifecycle
+func (sm *SpaceManager) launchSpace(ctx context.Context, s *space.Space) (*space.Space, error) {
 	// Save to storage
 	if err := sm.spaceStore.SaveSpace(ctx, *s); err != nil {
 		return nil, fmt.Errorf("error saving space: %w", err)
 	}
 
-	// Track in active spaces
+	// Track in active spaces, whose engagement is monitored
 	sm.activeSpaces.Store(s.ID, s)
 
-	// Start engagement monitoring
-	if err := sm.engagementAnalyzer.StartMonitoring(ctx, s.ID); err != nil {
-		return nil, fmt.Errorf("error starting engagement monitoring: %w", err)
-	}
-
 	// Publish space created event
 	if err := sm.publishSpaceEvent(*s, "created"); err != nil {
 		// Log error but continue
@@ -145,6 +179,210 @@ func (sm *SpaceManager) CreateSpace(ctx context.Context, trend trend.Trend) (*sp
 	return s, nil
 }
 
+// CreateCustomSpace creates a user-initiated space from an explicit template
+func (sm *SpaceManager) CreateCustomSpace(ctx context.Context, req space.CustomSpaceRequest) (*space.Space, error) {
+	if req.TemplateType == "" {
+		req.TemplateType = space.TemplateGeneral
+	}
+
+	// Look up the requested template
+	sm.mu.RLock()
+	template := sm.spaceTemplates[req.TemplateType]
+	sm.mu.RUnlock()
+
+	if template == nil {
+		return nil, fmt.Errorf("%w: %s", space.ErrInvalidTemplate, req.TemplateType)
+	}
+
+	// Hold admission while counting and saving, like admitted trends, so
+	// concurrent requests can't both take the last slot
+	sm.admissionMu.Lock()
+	defer sm.admissionMu.Unlock()
+
+	// User-initiated spaces are never queued, so refuse them at capacity
+	live, err := sm.admissionStore.CountLiveSpaces(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error counting live spaces: %w", err)
+	}
+	if sm.atCapacity(live) {
+		return nil, space.ErrAtCapacity
+	}
+
+	// Templates instantiate from trends, so describe the request as one
+	seed := trend.Trend{
+		Topic:          req.Title,
+		Description:    req.Description,
+		Keywords:       req.TopicTags,
+		Location:       req.Location,
+		LocationRadius: req.LocationRadius,
+		IsGeoLocal:     req.Location != nil && template.IsGeoAware(),
+	}
+
+	s := template.Instantiate(seed)
+	s.ID = uuid.New().String()
+	s.TrendID = ""
+	s.OwnerID = req.OwnerID
+	s.CreatedAt = time.Now()
+	s.LastActive = time.Now()
+	s.LifecycleStage = space.StageCreating
+
+	// Keep the location even for templates that aren't geo-aware
+	if req.Location != nil {
+		s.Location = req.Location
+		s.LocationRadius = req.LocationRadius
+	}
+
+	return sm.launchSpace(ctx, s)
+}
+
+// UpdateSpace updates a space's curated details on behalf of its owner
+func (sm *SpaceManager) UpdateSpace(
+	ctx context.Context,
+	spaceID string,
+	userID string,
+	update space.SpaceUpdate,
+) (*space.Space, error) {
+	s, err := sm.spaceStore.GetSpace(ctx, spaceID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting space: %w", err)
+	}
+
+	// Only the creator of a user-initiated space may curate it
+	if s.OwnerID == "" || s.OwnerID != userID {
+		return nil, space.ErrNotOwner
+	}
+
+	if update.Title != nil {
+		s.Title = *update.Title
+	}
+	if update.Description != nil {
+		s.Description = *update.Description
+	}
+	if update.TopicTags != nil {
+		s.TopicTags = update.TopicTags
+	}
+
+	if err := sm.spaceStore.SaveSpace(ctx, *s); err != nil {
+		return nil, fmt.Errorf("error saving updated space: %w", err)
+	}
+
+	if err := sm.publishSpaceEvent(*s, "updated"); err != nil {
+		// Log error but continue
+		fmt.Printf("Error publishing space updated event: %v\n", err)
+	}
+
+	return s, nil
+}
+
+// PinSpace pins or unpins a space
+func (sm *SpaceManager) PinSpace(ctx context.Context, spaceID string, pinned bool) error {
+	s, err := sm.spaceStore.GetSpace(ctx, spaceID)
+	if err != nil {
+		return fmt.Errorf("error getting space: %w", err)
+	}
+
+	if s.IsPinned == pinned {
+		return nil
+	}
+
+	s.IsPinned = pinned
+	if err := sm.spaceStore.SaveSpace(ctx, *s); err != nil {
+		return fmt.Errorf("error saving pinned space: %w", err)
+	}
+
+	eventType := "pinned"
+	if !pinned {
+		eventType = "unpinned"
+	}
+
+	if err := sm.publishSpaceEvent(*s, eventType); err != nil {
+		// Log error but continue
+		fmt.Printf("Error publishing space %s event: %v\n", eventType, err)
+	}
+
+	return nil
+}
+
+// SetFeatureEnabled turns one of a space's declared features on or off
+func (sm *SpaceManager) SetFeatureEnabled(
+	ctx context.Context,
+	spaceID, featureID string,
+	enabled bool,
+) (*space.Space, error) {
+	s, err := sm.spaceStore.GetSpace(ctx, spaceID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting space: %w", err)
+	}
+
+	// Only features the template declared can be toggled
+	index := -1
+	for i, f := range s.Features {
+		if f.ID == featureID {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, fmt.Errorf("%w: %s", space.ErrUnknownFeature, featureID)
+	}
+
+	if s.Features[index].IsEnabled == enabled {
+		return s, nil
+	}
+
+	s.Features[index].IsEnabled = enabled
+	if err := sm.spaceStore.SaveSpace(ctx, *s); err != nil {
+		return nil, fmt.Errorf("error saving space features: %w", err)
+	}
+
+	// Let connected clients pick up the change immediately
+	if err := sm.publishFeaturesEvent(*s); err != nil {
+		// Log error but continue
+		fmt.Printf("Error publishing features event: %v\n", err)
+	}
+
+	return s, nil
+}
+
+// OverrideExpiry sets the time at which a space dissolves, ignoring engagement
+func (sm *SpaceManager) OverrideExpiry(ctx context.Context, spaceID string, expiresAt time.Time) error {
+	if !expiresAt.After(time.Now()) {
+		return fmt.Errorf("%w: must be in the future", space.ErrInvalidExpiry)
+	}
+
+	s, err := sm.spaceStore.GetSpace(ctx, spaceID)
+	if err != nil {
+		return fmt.Errorf("error getting space: %w", err)
+	}
+
+	if s.LifecycleStage == space.StageDissolved {
+		return fmt.Errorf("%w: space already dissolved", space.ErrInvalidExpiry)
+	}
+
+	s.ExpiresAt = &expiresAt
+	s.ExpiryOverride = true
+
+	if err := sm.spaceStore.SaveSpace(ctx, *s); err != nil {
+		return fmt.Errorf("error saving space expiry: %w", err)
+	}
+
+	// Move the final dissolution to the new time
+	sm.scheduleDissolution(spaceID, expiresAt)
+
+	if err := sm.publishSpaceEvent(*s, "expiry_changed"); err != nil {
+		// Log error but continue
+		fmt.Printf("Error publishing expiry changed event: %v\n", err)
+	}
+
+	// Let connected clients restart their countdown
+	if err := sm.publishCountdownEvent(spaceID, expiresAt); err != nil {
+		// Log error but continue
+		fmt.Printf("Error publishing countdown event: %v\n", err)
+	}
+
+	return nil
+}
+
 // GetSpace returns a space by ID
 func (sm *SpaceManager) GetSpace(ctx context.Context, id string) (*space.Space, error) {
 	return sm.spaceStore.GetSpace(ctx, id)
@@ -170,7 +408,7 @@ func (sm *SpaceManager) UpdateLifecycle(ctx context.Context, spaceID string, sta
 
 	// Special handling for dissolution
 	if stage == space.StageDevolving {
-		return sm.InitiateDissolution(ctx, spaceID, sm.config.DefaultGracePeriod)
+		return sm.InitiateDissolution(ctx, spaceID, sm.gracePeriodFor(s))
 	}
 
 	// Update stage
@@ -202,8 +440,11 @@ func (sm *SpaceManager) InitiateDissolution(ctx context.Context, spaceID string,
 		return fmt.Errorf("error getting space: %w", err)
 	}
 
-	// Set dissolution time
+	// Set dissolution time, keeping any later deadline set by an admin
 	expiresAt := time.Now().Add(gracePeriod)
+	if s.ExpiryOverride && s.ExpiresAt != nil && s.ExpiresAt.After(expiresAt) {
+		expiresAt = *s.ExpiresAt
+	}
 	s.ExpiresAt = &expiresAt
 
 	// Update lifecycle stage
@@ -216,47 +457,7 @@ func (sm *SpaceManager) InitiateDissolution(ctx context.Context, spaceID string,
 	}
 
 	// Schedule final dissolution
-	go func() {
-		select {
-		case <-sm.ctx.Done():
-			return
-		case <-time.After(gracePeriod):
-			// Final dissolution logic
-			dissolutionCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-			defer cancel()
-
-			// Update to dissolved state
-			finalSpace, err := sm.spaceStore.GetSpace(dissolutionCtx, spaceID)
-			if err != nil {
-				fmt.Printf("Error getting space during final dissolution: %v\n", err)
-				return
-			}
-
-			finalSpace.LifecycleStage = space.StageDissolved
-
-			// Save final state
-			if err := sm.spaceStore.SaveSpace(dissolutionCtx, *finalSpace); err != nil {
-				fmt.Printf("Error saving final dissolution state: %v\n", err)
-				return
-			}
-
-			// Stop monitoring
-			if err := sm.engagementAnalyzer.StopMonitoring(dissolutionCtx, spaceID); err != nil {
-				fmt.Printf("Error stopping engagement monitoring: %v\n", err)
-			}
-
-			// Remove from active spaces
-			sm.activeSpaces.Delete(spaceID)
-
-			// Publish dissolved event
-			if err := sm.publishLifecycleEvent(*finalSpace, space.StageDevolving, space.StageDissolved); err != nil {
-				fmt.Printf("Error publishing final dissolution event: %v\n", err)
-			}
-
-			// Call lifecycle handlers
-			sm.callLifecycleHandlers(*finalSpace, space.StageDissolved)
-		}
-	}()
+	sm.scheduleDissolution(spaceID, expiresAt)
 
 	// Publish dissolution initiated event
 	if err := sm.publishLifecycleEvent(*s, prevStage, space.StageDevolving); err != nil {
@@ -307,59 +508,226 @@ func (sm *SpaceManager) Stop(ctx context.Context) error {
 	return nil
 }
 
-// selectBestTemplate selects the most appropriate template for a trend
-func (sm *SpaceManager) selectBestTemplate(t trend.Trend) space.Template {
-	sm.mu.RLock()
-	defer sm.mu.RUnlock()
+// pendingDissolution is a scheduled dissolution. Its time is guarded by the
+// manager's dissolutionsMu; moved is signalled when it changes.
+type pendingDissolution struct {
+	at    time.Time
+	moved chan struct{}
+}
+
+// scheduleDissolution arranges for a space to be dissolved at the given time,
+// moving any dissolution already scheduled for it. Until then, connected
+// clients are sent a countdown every CountdownInterval.
+func (sm *SpaceManager) scheduleDissolution(spaceID string, at time.Time) {
+	sm.armDissolution(spaceID, at, true)
+}
 
-	// If this is a geo-local trend, prioritize local template
-	if t.IsGeoLocal && sm.spaceTemplates[space.TemplateLocal] != nil {
-		return sm.spaceTemplates[space.TemplateLocal]
+// resumeDissolution schedules a stored dissolution deadline unless one is
+// already scheduled, for deadlines whose timers were lost in a restart
+func (sm *SpaceManager) resumeDissolution(spaceID string, at time.Time) {
+	sm.armDissolution(spaceID, at, false)
+}
+
+// armDissolution schedules a space's dissolution, moving a scheduled one to
+// the new time only when asked to
+func (sm *SpaceManager) armDissolution(spaceID string, at time.Time, move bool) {
+	sm.dissolutionsMu.Lock()
+	if pending, ok := sm.dissolutions[spaceID]; ok {
+		// Move it, waking its goroutine unless a wake-up is already waiting
+		if move {
+			pending.at = at
+			select {
+			case pending.moved <- struct{}{}:
+			default:
+			}
+		}
+		sm.dissolutionsMu.Unlock()
+		return
 	}
 
-	// Check for breaking news
-	if isBreakingNews(t) && sm.spaceTemplates[space.TemplateBreakingNews] != nil {
-		return sm.spaceTemplates[space.TemplateBreakingNews]
+	pending := &pendingDissolution{at: at, moved: make(chan struct{}, 1)}
+	sm.dissolutions[spaceID] = pending
+	sm.dissolutionsMu.Unlock()
+
+	go func() {
+		timer := time.NewTimer(time.Until(at))
+		defer timer.Stop()
+
+		var countdown <-chan time.Time
+		if sm.config.CountdownInterval > 0 {
+			ticker := time.NewTicker(sm.config.CountdownInterval)
+			defer ticker.Stop()
+			countdown = ticker.C
+		}
+
+		for {
+			select {
+			case <-sm.ctx.Done():
+				return
+			case <-pending.moved:
+				sm.dissolutionsMu.Lock()
+				at = pending.at
+				sm.dissolutionsMu.Unlock()
+
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(time.Until(at))
+			case <-countdown:
+				if err := sm.publishCountdownEvent(spaceID, at); err != nil {
+					// Log error but continue
+					fmt.Printf("Error publishing countdown event: %v\n", err)
+				}
+			case <-timer.C:
+				// Stop taking reschedules, unless one moved it just now
+				sm.dissolutionsMu.Lock()
+				if pending.at.After(at) {
+					at = pending.at
+					sm.dissolutionsMu.Unlock()
+					timer.Reset(time.Until(at))
+					continue
+				}
+				delete(sm.dissolutions, spaceID)
+				sm.dissolutionsMu.Unlock()
+
+				sm.completeDissolution(spaceID)
+				return
+			}
+		}
+	}()
+}
+
+// completeDissolution moves a space to its final dissolved state
+func (sm *SpaceManager) completeDissolution(spaceID string) {
+	dissolutionCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Update to dissolved state
+	finalSpace, err := sm.spaceStore.GetSpace(dissolutionCtx, spaceID)
+	if err != nil {
+		fmt.Printf("Error getting space during final dissolution: %v\n", err)
+		return
 	}
 
-	// Check for event-based trends
-	if isEventBased(t) && sm.spaceTemplates[space.TemplateEvent] != nil {
-		return sm.spaceTemplates[space.TemplateEvent]
+	if finalSpace.LifecycleStage == space.StageDissolved {
+		return
 	}
 
-	// Check for discussion-based trends
-	if isDiscussionBased(t) && sm.spaceTemplates[space.TemplateDiscussion] != nil {
-		return sm.spaceTemplates[space.TemplateDiscussion]
+	prevStage := finalSpace.LifecycleStage
+	finalSpace.LifecycleStage = space.StageDissolved
+
+	// Save final state
+	if err := sm.spaceStore.SaveSpace(dissolutionCtx, *finalSpace); err != nil {
+		fmt.Printf("Error saving final dissolution state: %v\n", err)
+		return
 	}
 
-	// Fall back to general template
-	return sm.spaceTemplates[space.TemplateGeneral]
+	// Remove from active spaces, which stops monitoring
+	sm.activeSpaces.Delete(spaceID)
+
+	// Publish dissolved event
+	if err := sm.publishLifecycleEvent(*finalSpace, prevStage, space.StageDissolved); err != nil {
+		fmt.Printf("Error publishing final dissolution event: %v\n", err)
+	}
+
+	// Call lifecycle handlers
+	sm.callLifecycleHandlers(*finalSpace, space.StageDissolved)
+
+	// Hand the freed slot to the best queued trend
+	sm.releaseEviction(spaceID)
+	sm.drainAdmissionQueue(dissolutionCtx)
 }
 
-// Helper functions for trend categorization
-func isBreakingNews(t trend.Trend) bool {
-	// Simplified logic - in a real implementation, this would be more sophisticated
-	if entityTypes, ok := t.EntityTypes["news"]; ok && entityTypes > 0.7 {
-		return true
+// selectableTemplate is a template that declares rules for choosing it
+type selectableTemplate interface {
+	space.Template
+
+	// GetSelection returns the rules for choosing this template for a trend, if any
+	GetSelection() *SelectionDefinition
+}
+
+// selectBestTemplate selects the highest scoring template for a trend
+func (sm *SpaceManager) selectBestTemplate(t trend.Trend) space.Template {
+	explanation := sm.scoreTemplates(t)
+
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	return sm.spaceTemplates[explanation.Selected]
+}
+
+// ExplainTemplateSelection scores every template against a trend and reports the choice
+func (sm *SpaceManager) ExplainTemplateSelection(ctx context.Context, t trend.Trend) (*space.TemplateExplanation, error) {
+	return sm.scoreTemplates(t), nil
+}
+
+// scoreTemplates evaluates each template's selection rules against a trend,
+// falling back to the general template when none is eligible
+func (sm *SpaceManager) scoreTemplates(t trend.Trend) *space.TemplateExplanation {
+	sm.mu.RLock()
+	candidates := make([]space.TemplateCandidate, 0, len(sm.spaceTemplates))
+	for templateType, template := range sm.spaceTemplates {
+		selectable, ok := template.(selectableTemplate)
+		if !ok || selectable.GetSelection() == nil {
+			candidates = append(candidates, space.TemplateCandidate{
+				TemplateType: templateType,
+				Reason:       "no selection rules",
+			})
+			continue
+		}
+
+		candidates = append(candidates, selectable.GetSelection().Evaluate(templateType, t))
 	}
-	return t.Velocity > 5.0 // High velocity often indicates breaking news
+	sm.mu.RUnlock()
+
+	rankCandidates(candidates)
+
+	explanation := &space.TemplateExplanation{
+		TrendID:    t.ID,
+		Selected:   space.TemplateGeneral,
+		Fallback:   true,
+		Candidates: candidates,
+	}
+
+	if len(candidates) > 0 && candidates[0].Eligible {
+		explanation.Selected = candidates[0].TemplateType
+		explanation.Fallback = false
+	}
+
+	return explanation
 }
 
-func isEventBased(t trend.Trend) bool {
-	// Check for event entity types
-	if entityTypes, ok := t.EntityTypes["event"]; ok && entityTypes > 0.6 {
-		return true
+// gracePeriodFor returns how long a space lingers once it starts dissolving
+func (sm *SpaceManager) gracePeriodFor(s *space.Space) time.Duration {
+	sm.mu.RLock()
+	template := sm.spaceTemplates[s.TemplateType]
+	sm.mu.RUnlock()
+
+	if template != nil && template.GetGracePeriod() > 0 {
+		return template.GetGracePeriod()
 	}
-	return false
+	return sm.config.DefaultGracePeriod
 }
 
-func isDiscussionBased(t trend.Trend) bool {
-	// Discussion trends often have more varied sources and lower velocity
-	return len(t.Sources) > 2 && t.Velocity < 3.0
+// applyTemplateSettings attaches the current template's lifecycle thresholds to a space
+func (sm *SpaceManager) applyTemplateSettings(s *space.Space) {
+	sm.mu.RLock()
+	template := sm.spaceTemplates[s.TemplateType]
+	sm.mu.RUnlock()
+
+	if template != nil {
+		thresholds := template.GetLifecycleThresholds()
+		s.Lifecycle = &thresholds
+	}
 }
 
 // monitorActiveSpaces regularly checks all active spaces for lifecycle updates
 func (sm *SpaceManager) monitorActiveSpaces() {
+	sm.restoreActiveSpaces()
+
 	ticker := time.NewTicker(sm.config.MonitoringInterval)
 	defer ticker.Stop()
 
@@ -373,65 +741,153 @@ func (sm *SpaceManager) monitorActiveSpaces() {
 	}
 }
 
-// checkActiveSpaces checks all active spaces for potential lifecycle updates
+// restoreActiveSpaces resumes monitoring the spaces a previous run left live
+func (sm *SpaceManager) restoreActiveSpaces() {
+	ctx, cancel := context.WithTimeout(sm.ctx, 30*time.Second)
+	defer cancel()
+
+	const pageSize = 100
+	for offset := 0; ; offset += pageSize {
+		spaces, err := sm.spaceStore.FindSpaces(ctx, space.SpaceFilter{
+			LifecycleStages: []space.LifecycleStage{
+				space.StageCreating,
+				space.StageGrowing,
+				space.StagePeak,
+				space.StageWaning,
+				space.StageDevolving,
+			},
+			Limit:  pageSize,
+			Offset: offset,
+		})
+		if err != nil {
+			fmt.Printf("Error restoring active spaces: %v\n", err)
+			return
+		}
+
+		for i := range spaces {
+			sm.activeSpaces.LoadOrStore(spaces[i].ID, &spaces[i])
+		}
+
+		if len(spaces) < pageSize {
+			return
+		}
+	}
+}
+
+// checkActiveSpaces analyzes the engagement of all active spaces in one pass,
+// recording it and acting on it for lifecycle updates and dissolution
 func (sm *SpaceManager) checkActiveSpaces() {
 	// Create context with timeout for this check
 	ctx, cancel := context.WithTimeout(sm.ctx, 30*time.Second)
 	defer cancel()
 
-	// Process each active space
+	var spaceIDs []string
 	sm.activeSpaces.Range(func(key, value interface{}) bool {
-		spaceID, ok := key.(string)
-		if !ok {
-			return true // Continue to next item
+		if spaceID, ok := key.(string); ok {
+			spaceIDs = append(spaceIDs, spaceID)
 		}
+		return true
+	})
 
-		// Get latest space data
-		s, err := sm.spaceStore.GetSpace(ctx, spaceID)
-		if err != nil {
-			fmt.Printf("Error getting space during monitoring: %v\n", err)
-			return true
-		}
+	if len(spaceIDs) > 0 {
+		sm.evaluateSpaces(ctx, spaceIDs)
+	}
+
+	// Admit queued trends if spaces dissolved elsewhere, and expire stale ones
+	sm.drainAdmissionQueue(ctx)
+}
 
-		// Skip spaces in terminal states
+// evaluateSpaces measures the engagement of spaces once and shares it between
+// their analytics, lifecycle updates and dissolution checks
+func (sm *SpaceManager) evaluateSpaces(ctx context.Context, spaceIDs []string) {
+	// Get latest space data
+	stored, err := sm.spaceStore.GetSpaces(ctx, spaceIDs)
+	if err != nil {
+		fmt.Printf("Error getting spaces during monitoring: %v\n", err)
+		return
+	}
+
+	spaces := make([]*space.Space, 0, len(stored))
+	for i := range stored {
+		s := &stored[i]
+
+		// Stop tracking spaces in terminal states
 		if s.LifecycleStage == space.StageDissolved {
-			sm.activeSpaces.Delete(spaceID)
-			return true
+			sm.activeSpaces.Delete(s.ID)
+			continue
 		}
 
-		// Skip spaces already dissolving
-		if s.LifecycleStage == space.StageDevolving {
-			return true
-		}
+		// Evaluate the space against its template's thresholds
+		sm.applyTemplateSettings(s)
+		spaces = append(spaces, s)
+	}
 
-		// Analyze engagement to determine lifecycle stage
-		stage, err := sm.engagementAnalyzer.DetermineLifecycleStage(ctx, s)
-		if err != nil {
-			fmt.Printf("Error determining lifecycle stage: %v\n", err)
-			return true
+	engagement, err := sm.engagementAnalyzer.AnalyzeSpaces(ctx, spaces)
+	if err != nil {
+		fmt.Printf("Error analyzing engagement: %v\n", err)
+		return
+	}
+
+	if err := sm.engagementAnalyzer.RecordEngagement(ctx, engagement); err != nil {
+		// Log error but continue
+		fmt.Printf("Error recording engagement: %v\n", err)
+	}
+
+	for _, measured := range engagement {
+		sm.applyEngagement(ctx, measured)
+	}
+}
+
+// applyEngagement updates a space's lifecycle stage from its measured
+// engagement and begins dissolution when it has run its course
+func (sm *SpaceManager) applyEngagement(ctx context.Context, measured space.SpaceEngagement) {
+	s := measured.Space
+
+	// Dissolution timers don't survive a restart, so make sure spaces with a
+	// deadline have one; a deadline already passed dissolves the space now
+	if s.ExpiresAt != nil && (s.LifecycleStage == space.StageDevolving || s.ExpiryOverride) {
+		sm.resumeDissolution(s.ID, *s.ExpiresAt)
+	}
+
+	// Skip spaces already dissolving
+	if s.LifecycleStage == space.StageDevolving {
+		return
+	}
+
+	// Let moderators know when a space is first flagged as toxic; it is held
+	// back from its peak while flagged
+	if isToxicityFlagged(measured.Metrics) && !isToxicityFlagged(s.EngagementMetrics) {
+		if err := sm.publishSpaceEvent(*s, "flagged"); err != nil {
+			// Log error but continue
+			fmt.Printf("Error publishing space flagged event: %v\n", err)
 		}
+	}
+
+	stage := sm.engagementAnalyzer.DetermineLifecycleStage(measured)
 
-		// Check if stage is different and update if needed
-		if stage != s.LifecycleStage {
-			if err := sm.UpdateLifecycle(ctx, spaceID, stage); err != nil {
+	// Spaces with an admin-set expiry only dissolve when it is reached
+	if s.ExpiryOverride {
+		if stage != s.LifecycleStage && stage != space.StageDevolving {
+			if err := sm.UpdateLifecycle(ctx, s.ID, stage); err != nil {
 				fmt.Printf("Error updating lifecycle: %v\n", err)
 			}
 		}
+		return
+	}
 
-		// Check if space should be dissolved
-		if s.LifecycleStage != space.StageDevolving {
-			shouldDissolve, err := sm.engagementAnalyzer.ShouldDissolve(ctx, s)
-			if err != nil {
-				fmt.Printf("Error checking dissolution: %v\n", err)
-			} else if shouldDissolve {
-				if err := sm.InitiateDissolution(ctx, spaceID, sm.config.DefaultGracePeriod); err != nil {
-					fmt.Printf("Error initiating dissolution: %v\n", err)
-				}
-			}
+	// Check if stage is different and update if needed
+	if stage != s.LifecycleStage {
+		if err := sm.UpdateLifecycle(ctx, s.ID, stage); err != nil {
+			fmt.Printf("Error updating lifecycle: %v\n", err)
 		}
+	}
 
-		return true
-	})
+	// Check if space should be dissolved
+	if sm.engagementAnalyzer.ShouldDissolve(measured) {
+		if err := sm.InitiateDissolution(ctx, s.ID, sm.gracePeriodFor(s)); err != nil {
+			fmt.Printf("Error initiating dissolution: %v\n", err)
+		}
+	}
 }
 
 // publishSpaceEvent publishes a space event to the event bus
@@ -443,16 +899,85 @@ func (sm *SpaceManager) publishSpaceEvent(s space.Space, eventType string) error
 	return sm.eventBus.Publish(topic, data)
 }
 
-// publishLifecycleEvent publishes a lifecycle change event
+// publishFeaturesEvent publishes a space's enabled features to its connected clients
+func (sm *SpaceManager) publishFeaturesEvent(s space.Space) error {
+	data, err := json.Marshal(map[string]interface{}{
+		"type":     "features",
+		"space_id": s.ID,
+		"features": s.EnabledFeatures(),
+		"time":     time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("error marshaling features event: %w", err)
+	}
+
+	return sm.eventBus.Publish(fmt.Sprintf("space.%s.features", s.ID), data)
+}
+
+// publishLifecycleEvent publishes a lifecycle change to server-side listeners
+// and to the space's connected clients, who are sent the final dissolved event
+// when the space dissolves
 func (sm *SpaceManager) publishLifecycleEvent(s space.Space, prevStage, newStage space.LifecycleStage) error {
-	// In a real implementation, we'd properly serialize the event to JSON
-	data := []byte(fmt.Sprintf(
-		`{"id":"%s","title":"%s","prevStage":"%s","newStage":"%s"}`,
-		s.ID, s.Title, prevStage, newStage,
-	))
+	data, err := json.Marshal(map[string]interface{}{
+		"id":         s.ID,
+		"title":      s.Title,
+		"prevStage":  prevStage,
+		"newStage":   newStage,
+		"expires_at": s.ExpiresAt,
+	})
+	if err != nil {
+		return fmt.Errorf("error marshaling lifecycle event: %w", err)
+	}
 
 	topic := fmt.Sprintf("%s.lifecycle.changed", sm.config.EventsTopic)
-	return sm.eventBus.Publish(topic, data)
+	if err := sm.eventBus.Publish(topic, data); err != nil {
+		return fmt.Errorf("error publishing lifecycle event: %w", err)
+	}
+
+	event := map[string]interface{}{
+		"type":           space.LifecycleChanged,
+		"space_id":       s.ID,
+		"stage":          newStage,
+		"previous_stage": prevStage,
+		"expires_at":     s.ExpiresAt,
+		"time":           time.Now(),
+	}
+	if newStage == space.StageDissolved {
+		event = map[string]interface{}{
+			"type":           space.LifecycleDissolved,
+			"space_id":       s.ID,
+			"previous_stage": prevStage,
+			"time":           time.Now(),
+		}
+	}
+
+	return sm.publishToSpace(s.ID, event)
+}
+
+// publishCountdownEvent reminds a space's connected clients when it dissolves
+func (sm *SpaceManager) publishCountdownEvent(spaceID string, expiresAt time.Time) error {
+	remaining := time.Until(expiresAt)
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return sm.publishToSpace(spaceID, map[string]interface{}{
+		"type":              space.LifecycleCountdown,
+		"space_id":          spaceID,
+		"expires_at":        expiresAt,
+		"remaining_seconds": int(remaining.Seconds()),
+		"time":              time.Now(),
+	})
+}
+
+// publishToSpace publishes an event on a space's lifecycle topic
+func (sm *SpaceManager) publishToSpace(spaceID string, event map[string]interface{}) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("error marshaling %s event: %w", event["type"], err)
+	}
+
+	return sm.eventBus.Publish(fmt.Sprintf("space.%s.lifecycle", spaceID), data)
 }
 
 // callLifecycleHandlers calls all registered lifecycle handlers
@@ -0,0 +1,177 @@
+// internal/service/space/membership.go
+
+package space
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+
+	"essg/internal/domain/identity"
+	"essg/internal/domain/space"
+)
+
+// MembershipStore defines the storage interface for space membership
+type MembershipStore interface {
+	// JoinSpace records a user as a member, reporting whether they newly joined and the new user count
+	JoinSpace(ctx context.Context, ident identity.EphemeralIdentity) (*identity.EphemeralIdentity, bool, int, error)
+
+	// LeaveSpace marks a user as having left, returning their identity and the new user count
+	LeaveSpace(ctx context.Context, spaceID, userID string) (*identity.EphemeralIdentity, int, error)
+
+	// GetMember returns the identity a current member uses in a space
+	GetMember(ctx context.Context, spaceID, userID string) (*identity.EphemeralIdentity, error)
+
+	// TouchMember records a member as active now
+	TouchMember(ctx context.Context, spaceID, userID string) error
+
+	// GetOccupancy counts a space's members and those active within the window
+	GetOccupancy(ctx context.Context, spaceID string, window time.Duration) (int, int, error)
+}
+
+// MembershipConfig contains configuration for space membership
+type MembershipConfig struct {
+	ActiveWindow           time.Duration
+	DefaultAnonymity       bool
+	DefaultLocationSharing identity.LocationSharingLevel
+}
+
+// maxPersonaAttempts is how many personas are tried before a join fails
+const maxPersonaAttempts = 8
+
+// MembershipService implements the space.MembershipManager interface
+type MembershipService struct {
+	store    MembershipStore
+	personas identity.PersonaGenerator
+	eventBus *nats.Conn
+	config   MembershipConfig
+}
+
+// NewMembershipService creates a new membership service
+func NewMembershipService(
+	store MembershipStore,
+	personas identity.PersonaGenerator,
+	eventBus *nats.Conn,
+	config MembershipConfig,
+) *MembershipService {
+	return &MembershipService{
+		store:    store,
+		personas: personas,
+		eventBus: eventBus,
+		config:   config,
+	}
+}
+
+// JoinSpace adds a user to a space, creating their ephemeral identity on first
+// join. Nicknames are unique within a space, so a persona whose nickname is
+// taken is re-derived a few times before giving up.
+func (m *MembershipService) JoinSpace(ctx context.Context, spaceID, userID string) (*identity.EphemeralIdentity, error) {
+	now := time.Now()
+
+	var joined *identity.EphemeralIdentity
+	var isNew bool
+	var userCount int
+	var err error
+	for attempt := 0; attempt < maxPersonaAttempts; attempt++ {
+		persona := m.personas.GeneratePersona(userID, spaceID, attempt)
+
+		// The configured defaults only apply to users without preferences of their own
+		joined, isNew, userCount, err = m.store.JoinSpace(ctx, identity.EphemeralIdentity{
+			ID:                 uuid.New().String(),
+			UserID:             userID,
+			SpaceID:            spaceID,
+			Nickname:           persona.Nickname,
+			Avatar:             persona.Avatar,
+			IsAnonymous:        m.config.DefaultAnonymity,
+			LocationShareLevel: m.config.DefaultLocationSharing,
+			CreatedAt:          now,
+			LastActive:         now,
+		})
+		if !errors.Is(err, identity.ErrNicknameTaken) {
+			break
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error joining space: %w", err)
+	}
+
+	// Rejoining while already a member is not a presence change
+	if isNew {
+		if err := m.publishPresence(spaceID, "member_joined", *joined, userCount); err != nil {
+			// Log error but continue
+			fmt.Printf("Error publishing join event: %v\n", err)
+		}
+	}
+
+	return joined, nil
+}
+
+// LeaveSpace removes a user from a space, keeping their identity for past messages
+func (m *MembershipService) LeaveSpace(ctx context.Context, spaceID, userID string) error {
+	left, userCount, err := m.store.LeaveSpace(ctx, spaceID, userID)
+	if err != nil {
+		return fmt.Errorf("error leaving space: %w", err)
+	}
+
+	if err := m.publishPresence(spaceID, "member_left", *left, userCount); err != nil {
+		// Log error but continue
+		fmt.Printf("Error publishing leave event: %v\n", err)
+	}
+
+	return nil
+}
+
+// GetMember returns the identity a current member uses in a space
+func (m *MembershipService) GetMember(ctx context.Context, spaceID, userID string) (*identity.EphemeralIdentity, error) {
+	return m.store.GetMember(ctx, spaceID, userID)
+}
+
+// TouchPresence records that a member is currently present in a space
+func (m *MembershipService) TouchPresence(ctx context.Context, spaceID, userID string) error {
+	return m.store.TouchMember(ctx, spaceID, userID)
+}
+
+// GetOccupancy returns member and active counts for a space
+func (m *MembershipService) GetOccupancy(ctx context.Context, spaceID string) (*space.Occupancy, error) {
+	members, active, err := m.store.GetOccupancy(ctx, spaceID, m.config.ActiveWindow)
+	if err != nil {
+		return nil, fmt.Errorf("error getting occupancy: %w", err)
+	}
+
+	return &space.Occupancy{
+		SpaceID:      spaceID,
+		Members:      members,
+		Active:       active,
+		ActiveWindow: m.config.ActiveWindow,
+	}, nil
+}
+
+// publishPresence publishes a membership change to the space's presence topic.
+// These are distinct from the join and leave events the presence tracker sends
+// as connections come and go. Only the ephemeral identity is shared, never the
+// underlying user.
+func (m *MembershipService) publishPresence(
+	spaceID string,
+	eventType string,
+	ident identity.EphemeralIdentity,
+	userCount int,
+) error {
+	data, err := json.Marshal(map[string]interface{}{
+		"type":        eventType,
+		"space_id":    spaceID,
+		"identity_id": ident.ID,
+		"nickname":    ident.Nickname,
+		"user_count":  userCount,
+		"time":        time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("error marshaling presence event: %w", err)
+	}
+
+	return m.eventBus.Publish(fmt.Sprintf("space.%s.presence", spaceID), data)
+}
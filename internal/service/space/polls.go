@@ -0,0 +1,376 @@
+// internal/service/space/polls.go
+
+package space
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+
+	"essg/internal/domain/space"
+)
+
+// PollStore defines the storage interface for polls
+type PollStore interface {
+	// FindMemberIdentity returns the ephemeral identity a current member uses in a space
+	FindMemberIdentity(ctx context.Context, spaceID, userID string) (string, error)
+
+	// SavePoll stores a new poll and its options
+	SavePoll(ctx context.Context, p space.Poll) error
+
+	// GetPoll retrieves a poll in a space with its current tallies
+	GetPoll(ctx context.Context, spaceID, pollID string) (*space.Poll, error)
+
+	// FindPolls retrieves a space's polls with their tallies, newest first
+	FindPolls(ctx context.Context, spaceID string) ([]space.Poll, error)
+
+	// SaveVote records an identity's vote if the poll is open and it has not voted yet
+	SaveVote(ctx context.Context, pollID, identityID, optionID string) error
+
+	// ClosePoll closes an open poll, reporting whether it was open
+	ClosePoll(ctx context.Context, pollID string) (bool, error)
+
+	// CloseSpacePolls closes every open poll in a space, returning the polls closed
+	CloseSpacePolls(ctx context.Context, spaceID string) ([]space.Poll, error)
+
+	// CloseExpiredPolls closes open polls whose deadline has passed, returning the polls closed
+	CloseExpiredPolls(ctx context.Context) ([]space.Poll, error)
+}
+
+// PollConfig contains configuration for space polls
+type PollConfig struct {
+	MinOptions        int
+	MaxOptions        int
+	MaxQuestionLength int
+	MaxOptionLength   int
+	SweepInterval     time.Duration
+}
+
+// PollService implements the space.PollManager interface
+type PollService struct {
+	store      PollStore
+	spaceStore SpaceStore
+	eventBus   *nats.Conn
+	config     PollConfig
+	ctx        context.Context
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+// NewPollService creates a new poll service
+func NewPollService(store PollStore, spaceStore SpaceStore, eventBus *nats.Conn, config PollConfig) *PollService {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &PollService{
+		store:      store,
+		spaceStore: spaceStore,
+		eventBus:   eventBus,
+		config:     config,
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// Start begins periodically closing polls whose deadline has passed
+func (p *PollService) Start() {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+
+		ticker := time.NewTicker(p.config.SweepInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-p.ctx.Done():
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(p.ctx, p.config.SweepInterval)
+				p.closeExpiredPolls(ctx)
+				cancel()
+			}
+		}
+	}()
+}
+
+// Stop stops the deadline sweep
+func (p *PollService) Stop(ctx context.Context) error {
+	p.cancel()
+
+	c := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(c)
+	}()
+
+	select {
+	case <-c:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return nil
+}
+
+// CreatePoll starts a poll on behalf of a space member
+func (p *PollService) CreatePoll(ctx context.Context, spaceID string, req space.PollRequest) (*space.Poll, error) {
+	if err := p.validateRequest(&req); err != nil {
+		return nil, err
+	}
+
+	if err := p.checkPollsEnabled(ctx, spaceID); err != nil {
+		return nil, err
+	}
+
+	identityID, err := p.store.FindMemberIdentity(ctx, spaceID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	poll := space.Poll{
+		ID:                uuid.New().String(),
+		SpaceID:           spaceID,
+		CreatorIdentityID: identityID,
+		Question:          req.Question,
+		Options:           make([]space.PollOption, 0, len(req.Options)),
+		Deadline:          req.Deadline,
+		CreatedAt:         time.Now(),
+	}
+
+	for _, text := range req.Options {
+		poll.Options = append(poll.Options, space.PollOption{
+			ID:   uuid.New().String(),
+			Text: text,
+		})
+	}
+
+	if err := p.store.SavePoll(ctx, poll); err != nil {
+		return nil, fmt.Errorf("error saving poll: %w", err)
+	}
+
+	if err := p.publishPollEvent("poll_created", poll); err != nil {
+		// Log error but continue
+		fmt.Printf("Error publishing poll event: %v\n", err)
+	}
+
+	return &poll, nil
+}
+
+// GetPoll returns a poll with its current tallies
+func (p *PollService) GetPoll(ctx context.Context, spaceID, pollID string) (*space.Poll, error) {
+	return p.store.GetPoll(ctx, spaceID, pollID)
+}
+
+// ListPolls returns a space's polls, newest first
+func (p *PollService) ListPolls(ctx context.Context, spaceID string) ([]space.Poll, error) {
+	return p.store.FindPolls(ctx, spaceID)
+}
+
+// Vote records a member's vote, allowing one vote per ephemeral identity
+func (p *PollService) Vote(ctx context.Context, spaceID, pollID, userID, optionID string) (*space.Poll, error) {
+	if err := p.checkPollsEnabled(ctx, spaceID); err != nil {
+		return nil, err
+	}
+
+	// Make sure the poll belongs to this space before voting in it
+	if _, err := p.store.GetPoll(ctx, spaceID, pollID); err != nil {
+		return nil, err
+	}
+
+	identityID, err := p.store.FindMemberIdentity(ctx, spaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.store.SaveVote(ctx, pollID, identityID, optionID); err != nil {
+		return nil, err
+	}
+
+	poll, err := p.store.GetPoll(ctx, spaceID, pollID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Stream the new tallies to everyone in the space
+	if err := p.publishPollEvent("poll_tally", *poll); err != nil {
+		// Log error but continue
+		fmt.Printf("Error publishing poll event: %v\n", err)
+	}
+
+	return poll, nil
+}
+
+// ClosePoll closes a poll early on behalf of its creator
+func (p *PollService) ClosePoll(ctx context.Context, spaceID, pollID, userID string) (*space.Poll, error) {
+	poll, err := p.store.GetPoll(ctx, spaceID, pollID)
+	if err != nil {
+		return nil, err
+	}
+
+	identityID, err := p.store.FindMemberIdentity(ctx, spaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if identityID != poll.CreatorIdentityID {
+		return nil, space.ErrNotOwner
+	}
+
+	closed, err := p.store.ClosePoll(ctx, pollID)
+	if err != nil {
+		return nil, err
+	}
+	if !closed {
+		return nil, space.ErrPollClosed
+	}
+
+	return p.finishPoll(ctx, spaceID, pollID)
+}
+
+// HandleLifecycleChange closes a space's polls when it dissolves
+func (p *PollService) HandleLifecycleChange(s space.Space, stage space.LifecycleStage) error {
+	if stage != space.StageDissolved {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(p.ctx, 30*time.Second)
+	defer cancel()
+
+	closed, err := p.store.CloseSpacePolls(ctx, s.ID)
+	if err != nil {
+		return fmt.Errorf("error closing polls for space %s: %w", s.ID, err)
+	}
+
+	for _, poll := range closed {
+		if _, err := p.finishPoll(ctx, poll.SpaceID, poll.ID); err != nil {
+			// Log error but continue
+			fmt.Printf("Error finishing poll %s: %v\n", poll.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// closeExpiredPolls closes polls past their deadline and announces their results
+func (p *PollService) closeExpiredPolls(ctx context.Context) {
+	closed, err := p.store.CloseExpiredPolls(ctx)
+	if err != nil {
+		fmt.Printf("Error closing expired polls: %v\n", err)
+		return
+	}
+
+	for _, poll := range closed {
+		if _, err := p.finishPoll(ctx, poll.SpaceID, poll.ID); err != nil {
+			// Log error but continue
+			fmt.Printf("Error finishing poll %s: %v\n", poll.ID, err)
+		}
+	}
+}
+
+// finishPoll loads a closed poll's final tallies and announces them
+func (p *PollService) finishPoll(ctx context.Context, spaceID, pollID string) (*space.Poll, error) {
+	poll, err := p.store.GetPoll(ctx, spaceID, pollID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.publishPollEvent("poll_closed", *poll); err != nil {
+		// Log error but continue
+		fmt.Printf("Error publishing poll event: %v\n", err)
+	}
+
+	return poll, nil
+}
+
+// checkPollsEnabled ensures a space is open and has the polls feature turned on
+func (p *PollService) checkPollsEnabled(ctx context.Context, spaceID string) error {
+	s, err := p.spaceStore.GetSpace(ctx, spaceID)
+	if err != nil {
+		return fmt.Errorf("error getting space: %w", err)
+	}
+
+	if s.LifecycleStage == space.StageDissolved {
+		return space.ErrSpaceClosed
+	}
+
+	if !s.HasFeature(space.FeaturePolls) {
+		return fmt.Errorf("%w: %s", space.ErrFeatureDisabled, space.FeaturePolls)
+	}
+
+	return nil
+}
+
+// validateRequest tidies a poll request and checks it against the configured limits
+func (p *PollService) validateRequest(req *space.PollRequest) error {
+	req.Question = strings.TrimSpace(req.Question)
+	if req.Question == "" {
+		return fmt.Errorf("%w: question is required", space.ErrInvalidPoll)
+	}
+	if len(req.Question) > p.config.MaxQuestionLength {
+		return fmt.Errorf("%w: question longer than %d characters", space.ErrInvalidPoll, p.config.MaxQuestionLength)
+	}
+
+	if len(req.Options) < p.config.MinOptions || len(req.Options) > p.config.MaxOptions {
+		return fmt.Errorf(
+			"%w: polls need between %d and %d options",
+			space.ErrInvalidPoll, p.config.MinOptions, p.config.MaxOptions,
+		)
+	}
+
+	seen := make(map[string]bool, len(req.Options))
+	for i, option := range req.Options {
+		option = strings.TrimSpace(option)
+		if option == "" {
+			return fmt.Errorf("%w: option %d is empty", space.ErrInvalidPoll, i+1)
+		}
+		if len(option) > p.config.MaxOptionLength {
+			return fmt.Errorf("%w: option %d longer than %d characters", space.ErrInvalidPoll, i+1, p.config.MaxOptionLength)
+		}
+
+		key := strings.ToLower(option)
+		if seen[key] {
+			return fmt.Errorf("%w: option %q listed twice", space.ErrInvalidPoll, option)
+		}
+		seen[key] = true
+		req.Options[i] = option
+	}
+
+	if req.Deadline != nil && !req.Deadline.After(time.Now()) {
+		return fmt.Errorf("%w: deadline must be in the future", space.ErrInvalidPoll)
+	}
+
+	return nil
+}
+
+// publishPollEvent publishes a poll and its tallies to the space's connected clients
+func (p *PollService) publishPollEvent(eventType string, poll space.Poll) error {
+	options := make([]map[string]interface{}, 0, len(poll.Options))
+	for _, option := range poll.Options {
+		options = append(options, map[string]interface{}{
+			"id":    option.ID,
+			"text":  option.Text,
+			"votes": option.Votes,
+		})
+	}
+
+	data, err := json.Marshal(map[string]interface{}{
+		"type":        eventType,
+		"poll_id":     poll.ID,
+		"question":    poll.Question,
+		"options":     options,
+		"total_votes": poll.TotalVotes,
+		"deadline":    poll.Deadline,
+		"closed":      poll.ClosedAt != nil,
+		"time":        time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("error marshaling poll event: %w", err)
+	}
+
+	return p.eventBus.Publish(fmt.Sprintf("space.%s.polls", poll.SpaceID), data)
+}
@@ -0,0 +1,482 @@
+// internal/service/space/presence.go
+
+package space
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+
+	"essg/internal/domain/identity"
+	"essg/internal/domain/space"
+)
+
+// PresenceStore defines the storage interface for presence shared between replicas
+type PresenceStore interface {
+	// AddConnection records that a replica has connections from an identity, reporting whether it was newly present
+	AddConnection(ctx context.Context, spaceID, identityID, replicaID string, at, staleBefore time.Time) (bool, error)
+
+	// RemoveConnection removes a replica's record of an identity, reporting whether it is no longer present anywhere
+	RemoveConnection(ctx context.Context, spaceID, identityID, replicaID string, staleBefore time.Time) (bool, error)
+
+	// UpdatePresence updates a replica's status and typing state for an identity
+	UpdatePresence(ctx context.Context, spaceID, identityID, replicaID string, status space.PresenceStatus, typingUntil time.Time) error
+
+	// Heartbeat refreshes a replica's records for the identities it has connections from
+	Heartbeat(ctx context.Context, replicaID string, present []identity.EphemeralIdentity, at time.Time) error
+
+	// ExpirePresence removes records not refreshed since staleBefore, returning identities no longer present anywhere
+	ExpirePresence(ctx context.Context, staleBefore time.Time) ([]identity.EphemeralIdentity, error)
+
+	// RemoveReplica removes every record a replica holds, returning identities no longer present anywhere
+	RemoveReplica(ctx context.Context, replicaID string, staleBefore time.Time) ([]identity.EphemeralIdentity, error)
+
+	// FindPresence returns the identities with fresh records in a space
+	FindPresence(ctx context.Context, spaceID string, staleBefore time.Time) ([]space.PresentIdentity, error)
+
+	// CountPresence counts the identities with fresh records in a space
+	CountPresence(ctx context.Context, spaceID string, staleBefore time.Time) (int, error)
+
+	// CountPresenceBySpace counts the identities with fresh records in each of several spaces
+	CountPresenceBySpace(ctx context.Context, spaceIDs []string, staleBefore time.Time) (map[string]int, error)
+}
+
+// PresenceConfig contains configuration for presence tracking
+type PresenceConfig struct {
+	ReplicaID         string        // Identifies this process's records; generated when empty
+	IdleAfter         time.Duration // Time without interaction before a connected identity is idle
+	HeartbeatInterval time.Duration // How often this replica refreshes its records
+	StaleAfter        time.Duration // Age at which another replica's records are considered abandoned
+	TypingTimeout     time.Duration // How long a typing indicator lasts without being renewed
+	TypingDebounce    time.Duration // Minimum time between typing broadcasts for an identity
+	SweepInterval     time.Duration // How often typing and idle state is checked
+	StoreTimeout      time.Duration
+}
+
+// presenceKey identifies an identity's presence in a space
+type presenceKey struct {
+	spaceID    string
+	identityID string
+}
+
+// localPresence is this replica's view of an identity's connections. The
+// mutex serializes syncing its record to the store; the other fields are
+// guarded by the service's mutex.
+type localPresence struct {
+	mu           sync.Mutex
+	stored       bool // Whether the store holds this replica's record, guarded by mu
+	ident        identity.EphemeralIdentity
+	connections  int
+	status       space.PresenceStatus
+	lastActive   time.Time
+	typingUntil  time.Time
+	typingSentAt time.Time
+}
+
+// PresenceService implements the space.PresenceTracker interface. Each
+// replica counts its own connections and keeps one shared record per
+// identity, so join and leave are only announced when an identity's first
+// connection anywhere opens and its last one closes. Typing is broadcast at
+// most once per debounce interval while it continues, and stopped explicitly
+// when it lapses.
+type PresenceService struct {
+	store    PresenceStore
+	eventBus *nats.Conn
+	config   PresenceConfig
+	mu       sync.Mutex
+	local    map[presenceKey]*localPresence
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// NewPresenceService creates a new presence service
+func NewPresenceService(store PresenceStore, eventBus *nats.Conn, config PresenceConfig) *PresenceService {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if config.ReplicaID == "" {
+		config.ReplicaID = uuid.New().String()
+	}
+
+	return &PresenceService{
+		store:    store,
+		eventBus: eventBus,
+		config:   config,
+		local:    make(map[presenceKey]*localPresence),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Start begins expiring typing and idle state and refreshing this replica's records
+func (p *PresenceService) Start() {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+
+		sweep := time.NewTicker(p.config.SweepInterval)
+		defer sweep.Stop()
+		heartbeat := time.NewTicker(p.config.HeartbeatInterval)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-p.ctx.Done():
+				return
+			case <-sweep.C:
+				p.sweep()
+			case <-heartbeat.C:
+				p.heartbeat()
+			}
+		}
+	}()
+}
+
+// Stop stops tracking and removes this replica's records, announcing departures
+func (p *PresenceService) Stop(ctx context.Context) error {
+	p.cancel()
+
+	c := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(c)
+	}()
+
+	select {
+	case <-c:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	departed, err := p.store.RemoveReplica(ctx, p.config.ReplicaID, p.staleBefore())
+	if err != nil {
+		return fmt.Errorf("error removing presence: %w", err)
+	}
+
+	for _, ident := range departed {
+		p.publishPresence(space.PresenceLeave, ident)
+	}
+
+	return nil
+}
+
+// Connect records a connection from an identity
+func (p *PresenceService) Connect(ctx context.Context, ident identity.EphemeralIdentity) error {
+	key := presenceKey{spaceID: ident.SpaceID, identityID: ident.ID}
+
+	p.mu.Lock()
+	entry, ok := p.local[key]
+	if !ok {
+		entry = &localPresence{ident: ident, status: space.PresenceActive}
+		p.local[key] = entry
+	}
+	entry.connections++
+	entry.lastActive = time.Now()
+	p.mu.Unlock()
+
+	return p.sync(ctx, entry)
+}
+
+// Disconnect ends a connection from an identity
+func (p *PresenceService) Disconnect(ctx context.Context, ident identity.EphemeralIdentity) error {
+	key := presenceKey{spaceID: ident.SpaceID, identityID: ident.ID}
+
+	p.mu.Lock()
+	entry, ok := p.local[key]
+	if !ok || entry.connections == 0 {
+		p.mu.Unlock()
+		return nil
+	}
+	entry.connections--
+	stopTyping := entry.connections == 0 && !entry.typingUntil.IsZero()
+	if entry.connections == 0 {
+		entry.typingUntil = time.Time{}
+	}
+	p.mu.Unlock()
+
+	if stopTyping {
+		p.publishTyping(ident, false)
+	}
+
+	return p.sync(ctx, entry)
+}
+
+// Activity records that a connected identity is interacting, bringing it back from idle
+func (p *PresenceService) Activity(ctx context.Context, ident identity.EphemeralIdentity) error {
+	entry, returned, typingUntil := p.touch(ident)
+	if entry == nil || !returned {
+		return nil
+	}
+
+	p.publishPresence(space.PresenceReturn, ident)
+
+	return p.store.UpdatePresence(ctx, ident.SpaceID, ident.ID, p.config.ReplicaID, space.PresenceActive, typingUntil)
+}
+
+// SetTyping records whether a connected identity is typing. Broadcasts are
+// debounced; clients should treat typing as lapsed after the typing timeout
+// unless it is renewed.
+func (p *PresenceService) SetTyping(ctx context.Context, ident identity.EphemeralIdentity, typing bool) error {
+	entry, returned, _ := p.touch(ident)
+	if entry == nil {
+		return nil
+	}
+
+	now := time.Now()
+
+	p.mu.Lock()
+	wasTyping := entry.typingUntil.After(now)
+	broadcast := false
+	if typing {
+		entry.typingUntil = now.Add(p.config.TypingTimeout)
+		if !wasTyping || now.Sub(entry.typingSentAt) >= p.config.TypingDebounce {
+			entry.typingSentAt = now
+			broadcast = true
+		}
+	} else if wasTyping {
+		entry.typingUntil = time.Time{}
+		broadcast = true
+	}
+	typingUntil := entry.typingUntil
+	p.mu.Unlock()
+
+	if returned {
+		p.publishPresence(space.PresenceReturn, ident)
+	}
+	if !broadcast && !returned {
+		return nil
+	}
+	if broadcast {
+		p.publishTyping(ident, typing)
+	}
+
+	return p.store.UpdatePresence(ctx, ident.SpaceID, ident.ID, p.config.ReplicaID, space.PresenceActive, typingUntil)
+}
+
+// GetPresence returns the identities connected to a space
+func (p *PresenceService) GetPresence(ctx context.Context, spaceID string) (*space.Presence, error) {
+	present, err := p.store.FindPresence(ctx, spaceID, p.staleBefore())
+	if err != nil {
+		return nil, fmt.Errorf("error finding presence: %w", err)
+	}
+
+	presence := &space.Presence{
+		SpaceID:    spaceID,
+		Identities: present,
+	}
+	for _, ident := range present {
+		if ident.Status == space.PresenceActive {
+			presence.Active++
+		} else {
+			presence.Idle++
+		}
+	}
+
+	return presence, nil
+}
+
+// CountPresent counts the identities connected to a space
+func (p *PresenceService) CountPresent(ctx context.Context, spaceID string) (int, error) {
+	return p.store.CountPresence(ctx, spaceID, p.staleBefore())
+}
+
+// CountPresentBySpace counts the identities connected to each of several spaces
+func (p *PresenceService) CountPresentBySpace(ctx context.Context, spaceIDs []string) (map[string]int, error) {
+	return p.store.CountPresenceBySpace(ctx, spaceIDs, p.staleBefore())
+}
+
+// touch marks a connected identity as active now, reporting whether it was idle
+func (p *PresenceService) touch(ident identity.EphemeralIdentity) (*localPresence, bool, time.Time) {
+	key := presenceKey{spaceID: ident.SpaceID, identityID: ident.ID}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entry, ok := p.local[key]
+	if !ok || entry.connections == 0 {
+		return nil, false, time.Time{}
+	}
+
+	entry.lastActive = time.Now()
+	returned := entry.status == space.PresenceIdle
+	entry.status = space.PresenceActive
+
+	return entry, returned, entry.typingUntil
+}
+
+// sync brings this replica's stored record for an identity in line with its
+// connections, announcing joins and leaves the store reports
+func (p *PresenceService) sync(ctx context.Context, entry *localPresence) error {
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	p.mu.Lock()
+	connected := entry.connections > 0
+	ident := entry.ident
+	p.mu.Unlock()
+
+	switch {
+	case connected && !entry.stored:
+		joined, err := p.store.AddConnection(ctx, ident.SpaceID, ident.ID, p.config.ReplicaID, time.Now(), p.staleBefore())
+		if err != nil {
+			return fmt.Errorf("error adding presence: %w", err)
+		}
+		entry.stored = true
+
+		if joined {
+			p.publishPresence(space.PresenceJoin, ident)
+		}
+
+	case !connected && entry.stored:
+		left, err := p.store.RemoveConnection(ctx, ident.SpaceID, ident.ID, p.config.ReplicaID, p.staleBefore())
+		if err != nil {
+			return fmt.Errorf("error removing presence: %w", err)
+		}
+		entry.stored = false
+
+		if left {
+			p.publishPresence(space.PresenceLeave, ident)
+		}
+	}
+
+	return nil
+}
+
+// sweep stops lapsed typing indicators, marks quiet identities idle and
+// forgets identities with no connections left
+func (p *PresenceService) sweep() {
+	now := time.Now()
+	var stoppedTyping, idled []*localPresence
+
+	p.mu.Lock()
+	for key, entry := range p.local {
+		if entry.connections == 0 {
+			// Only forget entries whose record is gone and that aren't mid-sync
+			if entry.mu.TryLock() {
+				if !entry.stored {
+					delete(p.local, key)
+				}
+				entry.mu.Unlock()
+			}
+			continue
+		}
+
+		if !entry.typingUntil.IsZero() && !entry.typingUntil.After(now) {
+			entry.typingUntil = time.Time{}
+			stoppedTyping = append(stoppedTyping, entry)
+		}
+
+		if entry.status == space.PresenceActive && now.Sub(entry.lastActive) >= p.config.IdleAfter {
+			entry.status = space.PresenceIdle
+			idled = append(idled, entry)
+		}
+	}
+	p.mu.Unlock()
+
+	for _, entry := range stoppedTyping {
+		p.publishTyping(entry.ident, false)
+	}
+
+	if len(idled) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(p.ctx, p.config.StoreTimeout)
+	defer cancel()
+
+	for _, entry := range idled {
+		p.publishPresence(space.PresenceIdled, entry.ident)
+
+		if err := p.store.UpdatePresence(ctx, entry.ident.SpaceID, entry.ident.ID, p.config.ReplicaID, space.PresenceIdle, time.Time{}); err != nil {
+			// Log error but continue
+			fmt.Printf("Error updating presence: %v\n", err)
+		}
+	}
+}
+
+// heartbeat refreshes this replica's records and expires those abandoned by
+// other replicas, announcing identities that left with them
+func (p *PresenceService) heartbeat() {
+	p.mu.Lock()
+	present := make([]identity.EphemeralIdentity, 0, len(p.local))
+	for _, entry := range p.local {
+		if entry.connections > 0 {
+			present = append(present, entry.ident)
+		}
+	}
+	p.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(p.ctx, p.config.StoreTimeout)
+	defer cancel()
+
+	if err := p.store.Heartbeat(ctx, p.config.ReplicaID, present, time.Now()); err != nil {
+		// Log error but continue
+		fmt.Printf("Error refreshing presence: %v\n", err)
+	}
+
+	departed, err := p.store.ExpirePresence(ctx, p.staleBefore())
+	if err != nil {
+		// Log error but continue
+		fmt.Printf("Error expiring presence: %v\n", err)
+		return
+	}
+
+	for _, ident := range departed {
+		p.publishPresence(space.PresenceLeave, ident)
+	}
+}
+
+// staleBefore returns the time before which records are considered abandoned
+func (p *PresenceService) staleBefore() time.Time {
+	return time.Now().Add(-p.config.StaleAfter)
+}
+
+// publishPresence publishes a presence event to the space's presence topic.
+// Only the ephemeral identity is shared, never the underlying user.
+func (p *PresenceService) publishPresence(eventType string, ident identity.EphemeralIdentity) {
+	data, err := json.Marshal(map[string]interface{}{
+		"type":        eventType,
+		"space_id":    ident.SpaceID,
+		"identity_id": ident.ID,
+		"nickname":    ident.Nickname,
+		"time":        time.Now(),
+	})
+	if err != nil {
+		fmt.Printf("Error marshaling presence event: %v\n", err)
+		return
+	}
+
+	if err := p.eventBus.Publish(fmt.Sprintf("space.%s.presence", ident.SpaceID), data); err != nil {
+		// Log error but continue
+		fmt.Printf("Error publishing presence event: %v\n", err)
+	}
+}
+
+// publishTyping publishes a typing indicator to the space's typing topic
+func (p *PresenceService) publishTyping(ident identity.EphemeralIdentity, typing bool) {
+	data, err := json.Marshal(map[string]interface{}{
+		"type": "typing",
+		"identity": map[string]interface{}{
+			"id":           ident.ID,
+			"nickname":     ident.Nickname,
+			"avatar":       ident.Avatar,
+			"is_anonymous": ident.IsAnonymous,
+		},
+		"is_typing": typing,
+		"time":      time.Now(),
+	})
+	if err != nil {
+		fmt.Printf("Error marshaling typing event: %v\n", err)
+		return
+	}
+
+	if err := p.eventBus.Publish(fmt.Sprintf("space.%s.typing", ident.SpaceID), data); err != nil {
+		// Log error but continue
+		fmt.Printf("Error publishing typing event: %v\n", err)
+	}
+}
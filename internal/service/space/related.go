@@ -0,0 +1,545 @@
+// internal/service/space/related.go
+
+package space
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"essg/internal/domain/geo"
+	"essg/internal/domain/space"
+	"essg/internal/domain/trend"
+)
+
+// RelationStore defines the storage interface for space relations
+type RelationStore interface {
+	// SaveRelations replaces the stored relations for a space
+	SaveRelations(ctx context.Context, spaceID string, relations []space.Relation) error
+
+	// GetRelations retrieves the stored relations for a space, best first
+	GetRelations(ctx context.Context, spaceID string, limit int) ([]space.Relation, error)
+
+	// DeleteRelations removes every relation that involves a space
+	DeleteRelations(ctx context.Context, spaceID string) error
+
+	// GetCoParticipation counts users shared between each pair of the given spaces
+	GetCoParticipation(ctx context.Context, spaceIDs []string) (map[string]map[string]int, error)
+
+	// GetCoParticipationWith counts users one space shares with each of the given spaces
+	GetCoParticipationWith(ctx context.Context, spaceID string, spaceIDs []string) (map[string]int, error)
+
+	// GetTrendSources retrieves the sources of each of the given trends
+	GetTrendSources(ctx context.Context, trendIDs []string) (map[string][]trend.Source, error)
+
+	// SaveRelatedTrends updates the related trends recorded for a trend
+	SaveRelatedTrends(ctx context.Context, trendID string, related []string) error
+}
+
+// RelatedSpaceConfig contains configuration for related space discovery
+type RelatedSpaceConfig struct {
+	RefreshInterval time.Duration
+	MaxRelated      int
+	MinScore        float64
+	ProximityKm     float64
+	MaxCandidates   int
+}
+
+// Weights for the individual relatedness signals
+var relationWeights = map[string]float64{
+	"topic_overlap":    0.35,
+	"shared_sources":   0.25,
+	"geo_proximity":    0.15,
+	"co_participation": 0.25,
+}
+
+// liveStages are the lifecycle stages in which a space can be related to others
+var liveStages = []space.LifecycleStage{
+	space.StageGrowing,
+	space.StagePeak,
+	space.StageWaning,
+}
+
+// RelatedSpaceService implements the space.RelationFinder interface
+type RelatedSpaceService struct {
+	relationStore RelationStore
+	spaceStore    SpaceStore
+	geoService    geo.Service
+	config        RelatedSpaceConfig
+	snapshot      *relationSnapshot // Signals from the last full refresh, for refreshing single spaces
+	snapshotMu    sync.Mutex
+	ctx           context.Context
+	cancel        context.CancelFunc
+	wg            sync.WaitGroup
+}
+
+// relationSnapshot holds the data shared by all relatedness calculations in a
+// pass. Snapshots are not modified once built, so they can be shared.
+type relationSnapshot struct {
+	spaces          []space.Space
+	sources         map[string][]trend.Source
+	coParticipation map[string]map[string]int
+}
+
+// NewRelatedSpaceService creates a new related space service
+func NewRelatedSpaceService(
+	relationStore RelationStore,
+	spaceStore SpaceStore,
+	geoService geo.Service,
+	config RelatedSpaceConfig,
+) *RelatedSpaceService {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &RelatedSpaceService{
+		relationStore: relationStore,
+		spaceStore:    spaceStore,
+		geoService:    geoService,
+		config:        config,
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+}
+
+// Start begins periodically refreshing relations between live spaces
+func (r *RelatedSpaceService) Start() {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		ticker := time.NewTicker(r.config.RefreshInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.ctx.Done():
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(r.ctx, r.config.RefreshInterval)
+				if err := r.RefreshAll(ctx); err != nil {
+					fmt.Printf("Error refreshing space relations: %v\n", err)
+				}
+				cancel()
+			}
+		}
+	}()
+}
+
+// Stop stops the refresh loop
+func (r *RelatedSpaceService) Stop(ctx context.Context) error {
+	r.cancel()
+
+	c := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(c)
+	}()
+
+	select {
+	case <-c:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return nil
+}
+
+// GetRelatedSpaces returns the spaces most closely related to a space
+func (r *RelatedSpaceService) GetRelatedSpaces(ctx context.Context, spaceID string, limit int) ([]space.RelatedSpace, error) {
+	if limit <= 0 || limit > r.config.MaxRelated {
+		limit = r.config.MaxRelated
+	}
+
+	relations, err := r.relationStore.GetRelations(ctx, spaceID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error getting relations: %w", err)
+	}
+
+	ids := make([]string, len(relations))
+	for i, rel := range relations {
+		ids[i] = rel.RelatedSpaceID
+	}
+
+	stored, err := r.spaceStore.GetSpaces(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("error getting related spaces: %w", err)
+	}
+
+	spaces := make(map[string]space.Space, len(stored))
+	for _, s := range stored {
+		spaces[s.ID] = s
+	}
+
+	related := make([]space.RelatedSpace, 0, len(relations))
+	for _, rel := range relations {
+		s, ok := spaces[rel.RelatedSpaceID]
+		if !ok {
+			continue // Removed since the last refresh
+		}
+
+		related = append(related, space.RelatedSpace{
+			Space:   s,
+			Score:   rel.Score,
+			Factors: rel.Factors,
+		})
+	}
+
+	return related, nil
+}
+
+// RefreshRelations recomputes the relations for a single space against the
+// signals cached by the last full refresh, loading only the space's own
+func (r *RelatedSpaceService) RefreshRelations(ctx context.Context, spaceID string) error {
+	s, err := r.spaceStore.GetSpace(ctx, spaceID)
+	if err != nil {
+		return fmt.Errorf("error getting space: %w", err)
+	}
+
+	if !isLiveStage(s.LifecycleStage) {
+		// The space is no longer live, so nothing should link to it
+		return r.relationStore.DeleteRelations(ctx, spaceID)
+	}
+
+	r.snapshotMu.Lock()
+	cached := r.snapshot
+	r.snapshotMu.Unlock()
+
+	if cached == nil {
+		if cached, err = r.loadSnapshot(ctx); err != nil {
+			return err
+		}
+	}
+
+	snapshot, err := r.withSpace(ctx, cached, *s)
+	if err != nil {
+		return err
+	}
+
+	r.snapshotMu.Lock()
+	r.snapshot = snapshot
+	r.snapshotMu.Unlock()
+
+	return r.saveRelations(ctx, *s, r.computeRelations(*s, snapshot), snapshot)
+}
+
+// RefreshAll recomputes the relations for every live space
+func (r *RelatedSpaceService) RefreshAll(ctx context.Context) error {
+	snapshot, err := r.loadSnapshot(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.snapshotMu.Lock()
+	r.snapshot = snapshot
+	r.snapshotMu.Unlock()
+
+	for _, s := range snapshot.spaces {
+		if err := r.saveRelations(ctx, s, r.computeRelations(s, snapshot), snapshot); err != nil {
+			fmt.Printf("Error saving relations for space %s: %v\n", s.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// HandleLifecycleChange keeps relations current as spaces move through their lifecycle
+func (r *RelatedSpaceService) HandleLifecycleChange(s space.Space, stage space.LifecycleStage) error {
+	ctx, cancel := context.WithTimeout(r.ctx, 30*time.Second)
+	defer cancel()
+
+	switch stage {
+	case space.StageDevolving, space.StageDissolved:
+		return r.relationStore.DeleteRelations(ctx, s.ID)
+	default:
+		return r.RefreshRelations(ctx, s.ID)
+	}
+}
+
+// loadSnapshot gathers the live spaces and the signals needed to relate them
+func (r *RelatedSpaceService) loadSnapshot(ctx context.Context) (*relationSnapshot, error) {
+	spaces, err := r.spaceStore.FindSpaces(ctx, space.SpaceFilter{
+		LifecycleStages: liveStages,
+		Limit:           r.config.MaxCandidates,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error finding live spaces: %w", err)
+	}
+
+	spaceIDs := make([]string, 0, len(spaces))
+	trendIDs := make([]string, 0, len(spaces))
+	for _, s := range spaces {
+		spaceIDs = append(spaceIDs, s.ID)
+		if s.TrendID != "" {
+			trendIDs = append(trendIDs, s.TrendID)
+		}
+	}
+
+	sources, err := r.relationStore.GetTrendSources(ctx, trendIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error getting trend sources: %w", err)
+	}
+
+	coParticipation, err := r.relationStore.GetCoParticipation(ctx, spaceIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error getting co-participation: %w", err)
+	}
+
+	return &relationSnapshot{
+		spaces:          spaces,
+		sources:         sources,
+		coParticipation: coParticipation,
+	}, nil
+}
+
+// withSpace returns a copy of a snapshot with a space's current state and
+// signals in place of those cached for it
+func (r *RelatedSpaceService) withSpace(ctx context.Context, snapshot *relationSnapshot, s space.Space) (*relationSnapshot, error) {
+	spaces := make([]space.Space, 0, len(snapshot.spaces)+1)
+	spaceIDs := make([]string, 0, len(snapshot.spaces))
+	for _, other := range snapshot.spaces {
+		if other.ID == s.ID {
+			continue
+		}
+		spaces = append(spaces, other)
+		spaceIDs = append(spaceIDs, other.ID)
+	}
+	spaces = append(spaces, s)
+
+	sources := make(map[string][]trend.Source, len(snapshot.sources)+1)
+	for trendID, trendSources := range snapshot.sources {
+		sources[trendID] = trendSources
+	}
+	if _, ok := sources[s.TrendID]; s.TrendID != "" && !ok {
+		trendSources, err := r.relationStore.GetTrendSources(ctx, []string{s.TrendID})
+		if err != nil {
+			return nil, fmt.Errorf("error getting trend sources: %w", err)
+		}
+		sources[s.TrendID] = trendSources[s.TrendID]
+	}
+
+	shared, err := r.relationStore.GetCoParticipationWith(ctx, s.ID, spaceIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error getting co-participation: %w", err)
+	}
+
+	coParticipation := make(map[string]map[string]int, len(snapshot.coParticipation)+1)
+	for spaceID, counts := range snapshot.coParticipation {
+		coParticipation[spaceID] = counts
+	}
+	coParticipation[s.ID] = shared
+
+	return &relationSnapshot{
+		spaces:          spaces,
+		sources:         sources,
+		coParticipation: coParticipation,
+	}, nil
+}
+
+// isLiveStage reports whether a space in a stage can be related to others
+func isLiveStage(stage space.LifecycleStage) bool {
+	for _, live := range liveStages {
+		if stage == live {
+			return true
+		}
+	}
+	return false
+}
+
+// computeRelations scores a space against every other live space
+func (r *RelatedSpaceService) computeRelations(s space.Space, snapshot *relationSnapshot) []space.Relation {
+	now := time.Now()
+
+	var relations []space.Relation
+	for _, other := range snapshot.spaces {
+		if other.ID == s.ID {
+			continue
+		}
+
+		factors := map[string]float64{
+			"topic_overlap":    tagOverlap(s.TopicTags, other.TopicTags),
+			"shared_sources":   sourceOverlap(s.TrendID, other.TrendID, snapshot.sources),
+			"geo_proximity":    r.geoProximity(s, other),
+			"co_participation": coParticipationScore(s, other, snapshot.coParticipation),
+		}
+
+		var score float64
+		for factor, weight := range relationWeights {
+			score += factors[factor] * weight
+		}
+
+		if score < r.config.MinScore {
+			continue
+		}
+
+		relations = append(relations, space.Relation{
+			SpaceID:        s.ID,
+			RelatedSpaceID: other.ID,
+			Score:          score,
+			Factors:        factors,
+			UpdatedAt:      now,
+		})
+	}
+
+	// Keep only the strongest links
+	sort.Slice(relations, func(i, j int) bool {
+		return relations[i].Score > relations[j].Score
+	})
+
+	if len(relations) > r.config.MaxRelated {
+		relations = relations[:r.config.MaxRelated]
+	}
+
+	return relations
+}
+
+// saveRelations stores a space's relations and mirrors them onto its trend
+func (r *RelatedSpaceService) saveRelations(
+	ctx context.Context,
+	s space.Space,
+	relations []space.Relation,
+	snapshot *relationSnapshot,
+) error {
+	if err := r.relationStore.SaveRelations(ctx, s.ID, relations); err != nil {
+		return fmt.Errorf("error saving relations: %w", err)
+	}
+
+	if s.TrendID == "" {
+		return nil
+	}
+
+	// Derive related trends from the trends behind related spaces
+	trendIDs := make(map[string]string, len(snapshot.spaces))
+	for _, other := range snapshot.spaces {
+		trendIDs[other.ID] = other.TrendID
+	}
+
+	relatedTrends := []string{}
+	seen := map[string]bool{s.TrendID: true}
+	for _, rel := range relations {
+		trendID := trendIDs[rel.RelatedSpaceID]
+		if trendID == "" || seen[trendID] {
+			continue
+		}
+
+		seen[trendID] = true
+		relatedTrends = append(relatedTrends, trendID)
+	}
+
+	if err := r.relationStore.SaveRelatedTrends(ctx, s.TrendID, relatedTrends); err != nil {
+		return fmt.Errorf("error saving related trends: %w", err)
+	}
+
+	return nil
+}
+
+// geoProximity scores how close two spaces are, from 1 (same point) to 0
+func (r *RelatedSpaceService) geoProximity(a, b space.Space) float64 {
+	if a.Location == nil || b.Location == nil {
+		return 0
+	}
+
+	// Spaces with large radii are related over larger distances
+	maxDistance := math.Max(r.config.ProximityKm, a.LocationRadius+b.LocationRadius)
+	if maxDistance <= 0 {
+		return 0
+	}
+
+	distance := r.geoService.CalculateDistance(*a.Location, *b.Location)
+
+	return math.Max(0, 1-distance/maxDistance)
+}
+
+// tagOverlap calculates the Jaccard similarity of two tag sets
+func tagOverlap(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	setA := make(map[string]bool, len(a))
+	for _, tag := range a {
+		setA[strings.ToLower(tag)] = true
+	}
+
+	union := len(setA)
+	intersection := 0
+	seen := make(map[string]bool, len(b))
+	for _, tag := range b {
+		tag = strings.ToLower(tag)
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+
+		if setA[tag] {
+			intersection++
+		} else {
+			union++
+		}
+	}
+
+	return float64(intersection) / float64(union)
+}
+
+// sourceOverlap calculates how much two spaces' originating trends share sources
+func sourceOverlap(trendA, trendB string, sources map[string][]trend.Source) float64 {
+	if trendA == "" || trendB == "" {
+		return 0
+	}
+
+	// Spaces created from the same trend share everything
+	if trendA == trendB {
+		return 1
+	}
+
+	keysA := sourceKeys(sources[trendA])
+	keysB := sourceKeys(sources[trendB])
+	if len(keysA) == 0 || len(keysB) == 0 {
+		return 0
+	}
+
+	intersection := 0
+	for key := range keysA {
+		if keysB[key] {
+			intersection++
+		}
+	}
+
+	union := len(keysA) + len(keysB) - intersection
+
+	return float64(intersection) / float64(union)
+}
+
+// sourceKeys builds an identity set for a list of sources
+func sourceKeys(sources []trend.Source) map[string]bool {
+	keys := make(map[string]bool, len(sources))
+	for _, src := range sources {
+		switch {
+		case src.URL != "":
+			keys[src.URL] = true
+		case src.ExternalID != "":
+			keys[src.Platform+":"+src.ExternalID] = true
+		}
+	}
+	return keys
+}
+
+// coParticipationScore calculates the share of users two spaces have in common
+func coParticipationScore(a, b space.Space, shared map[string]map[string]int) float64 {
+	count := shared[a.ID][b.ID]
+	if count == 0 {
+		return 0
+	}
+
+	smaller := a.UserCount
+	if b.UserCount < smaller {
+		smaller = b.UserCount
+	}
+	if smaller < count {
+		smaller = count
+	}
+
+	return float64(count) / float64(smaller)
+}
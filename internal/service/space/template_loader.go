@@ -0,0 +1,465 @@
+// internal/service/space/template_loader.go
+
+package space
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"essg/internal/domain/space"
+)
+
+// defaultTemplates holds the built-in template definitions
+//
+//go:embed templates/defaults.json
+var defaultTemplates []byte
+
+// templateTypePattern restricts template types to identifiers safe for storage and URLs
+var templateTypePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// Duration is a time.Duration that unmarshals from strings such as "30m"
+type Duration time.Duration
+
+// UnmarshalJSON parses a duration string
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string: %w", err)
+	}
+
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(parsed)
+	return nil
+}
+
+// TemplateFile is a set of template definitions and the features they draw on
+type TemplateFile struct {
+	Features  map[string]FeatureDefinition `json:"features"`
+	Templates []TemplateDefinition         `json:"templates"`
+}
+
+// FeatureDefinition describes a feature templates can enable
+type FeatureDefinition struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Config      map[string]interface{} `json:"config"`
+}
+
+// TemplateDefinition describes a space template declaratively
+type TemplateDefinition struct {
+	Type           space.TemplateType   `json:"type"`
+	Name           string               `json:"name"`
+	Description    string               `json:"description"`
+	GeoAware       bool                 `json:"geo_aware"`
+	AlwaysGeoLocal bool                 `json:"always_geo_local"`
+	Features       []FeatureRef         `json:"features"`
+	Lifecycle      *LifecycleDefinition `json:"lifecycle"`
+	GracePeriod    Duration             `json:"grace_period"`
+	Selection      *SelectionDefinition `json:"selection"`
+	Model          string               `json:"engagement_model"`
+}
+
+// FeatureRef enables a catalog feature for a template, optionally overriding its config
+type FeatureRef struct {
+	ID      string                 `json:"id"`
+	Enabled *bool                  `json:"enabled"`
+	Config  map[string]interface{} `json:"config"`
+}
+
+// LifecycleDefinition sets the engagement scores that move spaces between stages
+type LifecycleDefinition struct {
+	PeakScore    float64 `json:"peak_score"`
+	WaningScore  float64 `json:"waning_score"`
+	RecoverScore float64 `json:"recover_score"`
+	DevolveScore float64 `json:"devolve_score"`
+}
+
+// ParseTemplateFile decodes a template file, rejecting unknown fields
+func ParseTemplateFile(data []byte) (*TemplateFile, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var file TemplateFile
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("error decoding template file: %w", err)
+	}
+
+	return &file, nil
+}
+
+// BuildTemplates validates template files and builds their templates. Later
+// files extend the feature catalog and replace templates of the same type.
+// Templates may name the default engagement model or any of the given models.
+func BuildTemplates(models []string, files ...TemplateFile) ([]*ConfiguredTemplate, error) {
+	known := map[string]bool{DefaultEngagementModelName: true}
+	for _, model := range models {
+		known[model] = true
+	}
+
+	catalog := make(map[string]FeatureDefinition)
+	definitions := make(map[space.TemplateType]TemplateDefinition)
+
+	for _, file := range files {
+		for id, feature := range file.Features {
+			catalog[id] = feature
+		}
+
+		seen := make(map[space.TemplateType]bool)
+		for _, def := range file.Templates {
+			if seen[def.Type] {
+				return nil, fmt.Errorf("template %q defined twice in one file", def.Type)
+			}
+			seen[def.Type] = true
+			definitions[def.Type] = def
+		}
+	}
+
+	// Validate everything so a single bad definition is reported with the rest
+	var errs []error
+	templates := make([]*ConfiguredTemplate, 0, len(definitions))
+	for _, def := range definitions {
+		template, err := buildTemplate(def, catalog, known)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("template %q: %w", def.Type, err))
+			continue
+		}
+		templates = append(templates, template)
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	if _, ok := definitions[space.TemplateGeneral]; !ok {
+		return nil, fmt.Errorf("template %q is required as the fallback", space.TemplateGeneral)
+	}
+
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].templateType < templates[j].templateType
+	})
+
+	return templates, nil
+}
+
+// buildTemplate validates a single definition against the feature catalog and
+// the known engagement models
+func buildTemplate(def TemplateDefinition, catalog map[string]FeatureDefinition, models map[string]bool) (*ConfiguredTemplate, error) {
+	if !templateTypePattern.MatchString(string(def.Type)) {
+		return nil, fmt.Errorf("type must be lowercase letters, digits and underscores")
+	}
+
+	if len(def.Features) == 0 {
+		return nil, fmt.Errorf("no features")
+	}
+
+	// Resolve features from the catalog
+	features := make([]space.Feature, 0, len(def.Features))
+	seen := make(map[string]bool)
+	for _, ref := range def.Features {
+		feature, ok := catalog[ref.ID]
+		if !ok {
+			return nil, fmt.Errorf("unknown feature %q", ref.ID)
+		}
+		if seen[ref.ID] {
+			return nil, fmt.Errorf("feature %q listed twice", ref.ID)
+		}
+		seen[ref.ID] = true
+
+		enabled := true
+		if ref.Enabled != nil {
+			enabled = *ref.Enabled
+		}
+
+		features = append(features, space.Feature{
+			ID:          ref.ID,
+			Name:        feature.Name,
+			Description: feature.Description,
+			Config:      mergeFeatureConfig(feature.Config, ref.Config),
+			IsEnabled:   enabled,
+		})
+	}
+
+	if def.AlwaysGeoLocal && !def.GeoAware {
+		return nil, fmt.Errorf("always_geo_local requires geo_aware")
+	}
+
+	// Check lifecycle thresholds are ordered
+	thresholds := DefaultLifecycleThresholds
+	if def.Lifecycle != nil {
+		thresholds = space.LifecycleThresholds(*def.Lifecycle)
+
+		if thresholds.DevolveScore < 0 || thresholds.PeakScore > 100 {
+			return nil, fmt.Errorf("lifecycle scores must be between 0 and 100")
+		}
+		if !(thresholds.DevolveScore < thresholds.WaningScore &&
+			thresholds.WaningScore <= thresholds.RecoverScore &&
+			thresholds.RecoverScore <= thresholds.PeakScore) {
+			return nil, fmt.Errorf("lifecycle scores must satisfy devolve < waning <= recover <= peak")
+		}
+	}
+
+	if def.GracePeriod < 0 {
+		return nil, fmt.Errorf("grace_period must not be negative")
+	}
+
+	if def.Model != "" && !models[def.Model] {
+		return nil, fmt.Errorf("unknown engagement_model %q", def.Model)
+	}
+
+	if def.Selection != nil {
+		if err := def.Selection.validate(); err != nil {
+			return nil, fmt.Errorf("selection: %w", err)
+		}
+	}
+
+	name := def.Name
+	if name == "" {
+		name = string(def.Type)
+	}
+
+	return &ConfiguredTemplate{
+		templateType:   def.Type,
+		name:           name,
+		description:    def.Description,
+		features:       features,
+		isGeoAware:     def.GeoAware,
+		alwaysGeoLocal: def.AlwaysGeoLocal,
+		thresholds:     thresholds,
+		gracePeriod:    time.Duration(def.GracePeriod),
+		selection:      def.Selection,
+		model:          def.Model,
+	}, nil
+}
+
+// mergeFeatureConfig overlays a template's feature config on the catalog default
+func mergeFeatureConfig(base, override map[string]interface{}) map[string]interface{} {
+	if len(base) == 0 && len(override) == 0 {
+		return nil
+	}
+
+	merged := make(map[string]interface{}, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range override {
+		merged[k] = v
+	}
+	return merged
+}
+
+// TemplateRegistrar accepts templates for use when creating spaces
+type TemplateRegistrar interface {
+	// RegisterTemplate registers a space template
+	RegisterTemplate(template space.Template)
+}
+
+// TemplateRegistrars passes templates on to several registrars, such as the
+// space manager and the engagement analyzer
+type TemplateRegistrars []TemplateRegistrar
+
+// RegisterTemplate registers a space template with every registrar
+func (r TemplateRegistrars) RegisterTemplate(template space.Template) {
+	for _, registrar := range r {
+		registrar.RegisterTemplate(template)
+	}
+}
+
+// TemplateLoaderConfig contains configuration for the template loader
+type TemplateLoaderConfig struct {
+	Dir              string   // Directory of *.json template files layered over the defaults
+	EngagementModels []string // Models templates may name besides the default
+	ReloadInterval   time.Duration
+}
+
+// TemplateLoader loads declarative templates and reloads them when their files change
+type TemplateLoader struct {
+	registrar   TemplateRegistrar
+	config      TemplateLoaderConfig
+	fingerprint string
+	ctx         context.Context
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+}
+
+// NewTemplateLoader creates a new template loader
+func NewTemplateLoader(registrar TemplateRegistrar, config TemplateLoaderConfig) *TemplateLoader {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &TemplateLoader{
+		registrar: registrar,
+		config:    config,
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// Load reads, validates and registers all templates. Nothing is registered
+// unless every definition is valid.
+func (l *TemplateLoader) Load() error {
+	fingerprint, err := l.dirFingerprint()
+	if err != nil {
+		return err
+	}
+
+	files, err := l.readFiles()
+	if err != nil {
+		return err
+	}
+
+	templates, err := BuildTemplates(l.config.EngagementModels, files...)
+	if err != nil {
+		return fmt.Errorf("invalid templates: %w", err)
+	}
+
+	for _, template := range templates {
+		l.registrar.RegisterTemplate(template)
+	}
+
+	l.fingerprint = fingerprint
+	return nil
+}
+
+// Start begins watching the template directory for changes
+func (l *TemplateLoader) Start() {
+	if l.config.Dir == "" || l.config.ReloadInterval <= 0 {
+		return
+	}
+
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+
+		ticker := time.NewTicker(l.config.ReloadInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-l.ctx.Done():
+				return
+			case <-ticker.C:
+				l.reloadIfChanged()
+			}
+		}
+	}()
+}
+
+// Stop stops watching for template changes
+func (l *TemplateLoader) Stop(ctx context.Context) error {
+	l.cancel()
+
+	c := make(chan struct{})
+	go func() {
+		l.wg.Wait()
+		close(c)
+	}()
+
+	select {
+	case <-c:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// reloadIfChanged reloads templates when the directory contents have changed.
+// Templates removed from disk stay registered until restart.
+func (l *TemplateLoader) reloadIfChanged() {
+	fingerprint, err := l.dirFingerprint()
+	if err != nil {
+		fmt.Printf("Error checking template directory: %v\n", err)
+		return
+	}
+
+	if fingerprint == l.fingerprint {
+		return
+	}
+
+	// Keep serving the previous templates if the new ones are invalid
+	if err := l.Load(); err != nil {
+		fmt.Printf("Error reloading templates: %v\n", err)
+		l.fingerprint = fingerprint
+		return
+	}
+
+	fmt.Printf("Reloaded space templates from %s\n", l.config.Dir)
+}
+
+// readFiles returns the built-in definitions followed by those in the directory
+func (l *TemplateLoader) readFiles() ([]TemplateFile, error) {
+	defaults, err := ParseTemplateFile(defaultTemplates)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing default templates: %w", err)
+	}
+
+	files := []TemplateFile{*defaults}
+
+	paths, err := l.templatePaths()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", path, err)
+		}
+
+		file, err := ParseTemplateFile(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		files = append(files, *file)
+	}
+
+	return files, nil
+}
+
+// templatePaths lists template files in the directory in load order
+func (l *TemplateLoader) templatePaths() ([]string, error) {
+	if l.config.Dir == "" {
+		return nil, nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(l.config.Dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing templates: %w", err)
+	}
+
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// dirFingerprint summarizes the template files so changes can be detected
+func (l *TemplateLoader) dirFingerprint() (string, error) {
+	paths, err := l.templatePaths()
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", fmt.Errorf("error reading %s: %w", path, err)
+		}
+		fmt.Fprintf(&b, "%s:%d:%d;", path, info.Size(), info.ModTime().UnixNano())
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,239 @@
+// internal/service/space/template_rules.go
+
+package space
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"essg/internal/domain/space"
+	"essg/internal/domain/trend"
+)
+
+// Rule operators
+const (
+	ruleOpGreater      = "gt"
+	ruleOpGreaterEqual = "gte"
+	ruleOpLess         = "lt"
+	ruleOpLessEqual    = "lte"
+	ruleOpIs           = "is"
+	ruleOpContainsAny  = "contains_any"
+)
+
+// entityTypePrefix selects a trend entity type confidence, e.g. "entity_types.sports"
+const entityTypePrefix = "entity_types."
+
+// SelectionDefinition describes how well a template suits a trend. A template
+// scores its base plus the weight of every matching rule, and is only eligible
+// when all required rules match and the score reaches the minimum.
+type SelectionDefinition struct {
+	Base     float64         `json:"base"`
+	MinScore float64         `json:"min_score"`
+	Rules    []SelectionRule `json:"rules"`
+}
+
+// SelectionRule compares a single trend field against a value
+type SelectionRule struct {
+	Field    string      `json:"field"`
+	Op       string      `json:"op"`
+	Value    interface{} `json:"value,omitempty"`
+	Values   []string    `json:"values,omitempty"`
+	Weight   float64     `json:"weight"`
+	Required bool        `json:"required"`
+}
+
+// Evaluate scores a trend against the selection rules
+func (d *SelectionDefinition) Evaluate(templateType space.TemplateType, t trend.Trend) space.TemplateCandidate {
+	candidate := space.TemplateCandidate{
+		TemplateType: templateType,
+		Score:        d.Base,
+		MinScore:     d.MinScore,
+		Eligible:     true,
+		Rules:        make([]space.RuleEvaluation, 0, len(d.Rules)),
+	}
+
+	var missing []string
+	for _, rule := range d.Rules {
+		evaluation := rule.Evaluate(t)
+		candidate.Rules = append(candidate.Rules, evaluation)
+
+		if evaluation.Matched {
+			candidate.Score += rule.Weight
+		} else if rule.Required {
+			missing = append(missing, rule.Field)
+		}
+	}
+
+	switch {
+	case len(missing) > 0:
+		candidate.Eligible = false
+		candidate.Reason = fmt.Sprintf("required rules not matched: %s", strings.Join(missing, ", "))
+	case candidate.Score < d.MinScore:
+		candidate.Eligible = false
+		candidate.Reason = fmt.Sprintf("score %.2f below minimum %.2f", candidate.Score, d.MinScore)
+	default:
+		candidate.Reason = fmt.Sprintf("score %.2f", candidate.Score)
+	}
+
+	return candidate
+}
+
+// validate checks that every rule can be evaluated
+func (d *SelectionDefinition) validate() error {
+	if len(d.Rules) == 0 && d.Base <= 0 {
+		return fmt.Errorf("selection needs rules or a positive base score")
+	}
+
+	for i, rule := range d.Rules {
+		if err := rule.validate(); err != nil {
+			return fmt.Errorf("rule %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// Evaluate checks a trend against the rule, recording the value it compared
+func (r SelectionRule) Evaluate(t trend.Trend) space.RuleEvaluation {
+	evaluation := space.RuleEvaluation{
+		Field:    r.Field,
+		Op:       r.Op,
+		Expected: r.Value,
+		Weight:   r.Weight,
+		Required: r.Required,
+	}
+
+	switch r.Op {
+	case ruleOpGreater, ruleOpGreaterEqual, ruleOpLess, ruleOpLessEqual:
+		actual := numericField(t, r.Field)
+		expected, _ := r.Value.(float64)
+		evaluation.Actual = actual
+
+		switch r.Op {
+		case ruleOpGreater:
+			evaluation.Matched = actual > expected
+		case ruleOpGreaterEqual:
+			evaluation.Matched = actual >= expected
+		case ruleOpLess:
+			evaluation.Matched = actual < expected
+		default:
+			evaluation.Matched = actual <= expected
+		}
+
+	case ruleOpIs:
+		expected, _ := r.Value.(bool)
+		evaluation.Actual = t.IsGeoLocal
+		evaluation.Matched = t.IsGeoLocal == expected
+
+	case ruleOpContainsAny:
+		words := textField(t, r.Field)
+		evaluation.Expected = r.Values
+		evaluation.Actual = words
+		evaluation.Matched = containsAny(words, r.Values)
+	}
+
+	return evaluation
+}
+
+// validate checks the rule's field, operator and value agree
+func (r SelectionRule) validate() error {
+	switch r.Op {
+	case ruleOpGreater, ruleOpGreaterEqual, ruleOpLess, ruleOpLessEqual:
+		if !isNumericField(r.Field) {
+			return fmt.Errorf("field %q cannot be compared numerically", r.Field)
+		}
+		if _, ok := r.Value.(float64); !ok {
+			return fmt.Errorf("operator %q needs a numeric value", r.Op)
+		}
+
+	case ruleOpIs:
+		if r.Field != "is_geo_local" {
+			return fmt.Errorf("field %q is not a boolean", r.Field)
+		}
+		if _, ok := r.Value.(bool); !ok {
+			return fmt.Errorf("operator %q needs a boolean value", r.Op)
+		}
+
+	case ruleOpContainsAny:
+		if r.Field != "keywords" && r.Field != "topic" {
+			return fmt.Errorf("field %q is not text", r.Field)
+		}
+		if len(r.Values) == 0 {
+			return fmt.Errorf("operator %q needs values", r.Op)
+		}
+
+	default:
+		return fmt.Errorf("unknown operator %q", r.Op)
+	}
+
+	if r.Weight < 0 {
+		return fmt.Errorf("weight must not be negative")
+	}
+	if r.Weight == 0 && !r.Required {
+		return fmt.Errorf("rule has no weight and is not required")
+	}
+
+	return nil
+}
+
+// rankCandidates orders candidates best first: eligible, then by score, then by type
+func rankCandidates(candidates []space.TemplateCandidate) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Eligible != b.Eligible {
+			return a.Eligible
+		}
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return a.TemplateType < b.TemplateType
+	})
+}
+
+// isNumericField reports whether a field resolves to a number
+func isNumericField(field string) bool {
+	switch field {
+	case "velocity", "score", "source_count":
+		return true
+	}
+	return strings.HasPrefix(field, entityTypePrefix) && len(field) > len(entityTypePrefix)
+}
+
+// numericField resolves a numeric trend field
+func numericField(t trend.Trend, field string) float64 {
+	switch field {
+	case "velocity":
+		return t.Velocity
+	case "score":
+		return t.Score
+	case "source_count":
+		return float64(len(t.Sources))
+	}
+
+	if strings.HasPrefix(field, entityTypePrefix) {
+		return t.EntityTypes[strings.TrimPrefix(field, entityTypePrefix)]
+	}
+
+	return 0
+}
+
+// textField resolves the words of a text trend field
+func textField(t trend.Trend, field string) []string {
+	if field == "keywords" {
+		return t.Keywords
+	}
+	return strings.Fields(t.Topic)
+}
+
+// containsAny reports whether any of the words matches any value, ignoring case
+func containsAny(words []string, values []string) bool {
+	for _, word := range words {
+		for _, value := range values {
+			if strings.EqualFold(word, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
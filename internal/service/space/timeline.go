@@ -0,0 +1,312 @@
+// internal/service/space/timeline.go
+
+package space
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+
+	"essg/internal/domain/identity"
+	"essg/internal/domain/space"
+	"essg/internal/domain/trend"
+)
+
+// TimelineStore defines the storage interface for space timelines
+type TimelineStore interface {
+	// FindMemberIdentity returns the ephemeral identity a current member uses in a space
+	FindMemberIdentity(ctx context.Context, spaceID, userID string) (string, error)
+
+	// FindLiveTrendSpaces returns the IDs of spaces created for a trend that have not dissolved
+	FindLiveTrendSpaces(ctx context.Context, trendID string) ([]string, error)
+
+	// SaveEntry stores a new timeline entry
+	SaveEntry(ctx context.Context, e space.TimelineEntry) error
+
+	// SaveSuggestion stores a suggestion unless its source was already suggested, reporting whether it was stored
+	SaveSuggestion(ctx context.Context, e space.TimelineEntry) (bool, error)
+
+	// PinSuggestion pins a suggested entry, replacing its content when given
+	PinSuggestion(ctx context.Context, spaceID, entryID, content, authorIdentityID string) (*space.TimelineEntry, error)
+
+	// DismissSuggestion marks a suggested entry as dismissed
+	DismissSuggestion(ctx context.Context, spaceID, entryID string) error
+
+	// FindPinnedEntries retrieves a space's pinned entries since a time, in the order they happened
+	FindPinnedEntries(ctx context.Context, spaceID string, since time.Time) ([]space.TimelineEntry, error)
+
+	// FindSuggestions retrieves a space's pending suggestions, newest first
+	FindSuggestions(ctx context.Context, spaceID string, limit int) ([]space.TimelineEntry, error)
+}
+
+// TimelineConfig contains configuration for space timelines
+type TimelineConfig struct {
+	MaxContentLength int
+	MaxSuggestions   int
+}
+
+// TimelineService implements the space.Timeline interface
+type TimelineService struct {
+	store      TimelineStore
+	spaceStore SpaceStore
+	trust      identity.ReputationManager
+	eventBus   *nats.Conn
+	config     TimelineConfig
+}
+
+// NewTimelineService creates a new timeline service
+func NewTimelineService(
+	store TimelineStore,
+	spaceStore SpaceStore,
+	trust identity.ReputationManager,
+	eventBus *nats.Conn,
+	config TimelineConfig,
+) *TimelineService {
+	return &TimelineService{
+		store:      store,
+		spaceStore: spaceStore,
+		trust:      trust,
+		eventBus:   eventBus,
+		config:     config,
+	}
+}
+
+// PinUpdate adds an update to a space's timeline
+func (t *TimelineService) PinUpdate(
+	ctx context.Context,
+	spaceID string,
+	curator space.Curator,
+	update space.TimelineUpdate,
+) (*space.TimelineEntry, error) {
+	update.Content = strings.TrimSpace(update.Content)
+	if update.Content == "" {
+		return nil, fmt.Errorf("%w: content is required", space.ErrInvalidEntry)
+	}
+	if len(update.Content) > t.config.MaxContentLength {
+		return nil, fmt.Errorf("%w: content longer than %d characters", space.ErrInvalidEntry, t.config.MaxContentLength)
+	}
+	if update.SourceURL != "" && !isWebURL(update.SourceURL) {
+		return nil, fmt.Errorf("%w: source_url must be an http or https URL", space.ErrInvalidEntry)
+	}
+
+	authorID, err := t.authorize(ctx, spaceID, curator)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	occurredAt := update.OccurredAt
+	if occurredAt.IsZero() {
+		occurredAt = now
+	}
+
+	entry := space.TimelineEntry{
+		ID:               uuid.New().String(),
+		SpaceID:          spaceID,
+		Status:           space.TimelinePinned,
+		Content:          update.Content,
+		SourceURL:        update.SourceURL,
+		SourcePlatform:   update.SourcePlatform,
+		AuthorIdentityID: authorID,
+		OccurredAt:       occurredAt,
+		CreatedAt:        now,
+		PinnedAt:         &now,
+	}
+
+	if err := t.store.SaveEntry(ctx, entry); err != nil {
+		return nil, fmt.Errorf("error saving timeline entry: %w", err)
+	}
+
+	if err := t.publishUpdate(entry); err != nil {
+		// Log error but continue
+		fmt.Printf("Error publishing timeline update: %v\n", err)
+	}
+
+	return &entry, nil
+}
+
+// AcceptSuggestion pins a suggested update, optionally rewording it
+func (t *TimelineService) AcceptSuggestion(
+	ctx context.Context,
+	spaceID, entryID string,
+	curator space.Curator,
+	content string,
+) (*space.TimelineEntry, error) {
+	content = strings.TrimSpace(content)
+	if len(content) > t.config.MaxContentLength {
+		return nil, fmt.Errorf("%w: content longer than %d characters", space.ErrInvalidEntry, t.config.MaxContentLength)
+	}
+
+	authorID, err := t.authorize(ctx, spaceID, curator)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := t.store.PinSuggestion(ctx, spaceID, entryID, content, authorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.publishUpdate(*entry); err != nil {
+		// Log error but continue
+		fmt.Printf("Error publishing timeline update: %v\n", err)
+	}
+
+	return entry, nil
+}
+
+// DismissSuggestion discards a suggested update
+func (t *TimelineService) DismissSuggestion(ctx context.Context, spaceID, entryID string, curator space.Curator) error {
+	if _, err := t.authorize(ctx, spaceID, curator); err != nil {
+		return err
+	}
+
+	return t.store.DismissSuggestion(ctx, spaceID, entryID)
+}
+
+// GetTimeline returns a space's pinned updates since a time, oldest first
+func (t *TimelineService) GetTimeline(ctx context.Context, spaceID string, since time.Time) ([]space.TimelineEntry, error) {
+	return t.store.FindPinnedEntries(ctx, spaceID, since)
+}
+
+// ListSuggestions returns the updates waiting for a curator, newest first
+func (t *TimelineService) ListSuggestions(ctx context.Context, spaceID string) ([]space.TimelineEntry, error) {
+	return t.store.FindSuggestions(ctx, spaceID, t.config.MaxSuggestions)
+}
+
+// HandleTrend suggests timeline entries from the sources of a trend as the detector sees them
+func (t *TimelineService) HandleTrend(tr trend.Trend) error {
+	if len(tr.Sources) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	spaceIDs, err := t.store.FindLiveTrendSpaces(ctx, tr.ID)
+	if err != nil {
+		return fmt.Errorf("error finding spaces for trend %s: %w", tr.ID, err)
+	}
+
+	for _, spaceID := range spaceIDs {
+		s, err := t.spaceStore.GetSpace(ctx, spaceID)
+		if err != nil {
+			// Log error but continue
+			fmt.Printf("Error getting space %s: %v\n", spaceID, err)
+			continue
+		}
+
+		if !s.HasFeature(space.FeatureTimeline) {
+			continue
+		}
+
+		t.suggestSources(ctx, s.ID, tr)
+	}
+
+	return nil
+}
+
+// suggestSources stores a suggestion for each trend source not yet suggested to a space
+func (t *TimelineService) suggestSources(ctx context.Context, spaceID string, tr trend.Trend) {
+	now := time.Now()
+
+	for _, source := range tr.Sources {
+		key := sourceKey(source)
+		if key == "" {
+			continue
+		}
+
+		suggestion := space.TimelineEntry{
+			ID:             uuid.New().String(),
+			SpaceID:        spaceID,
+			Status:         space.TimelineSuggested,
+			Content:        fmt.Sprintf("New %s report on %s", source.Platform, tr.Topic),
+			SourceURL:      source.URL,
+			SourcePlatform: source.Platform,
+			SourceKey:      key,
+			OccurredAt:     now,
+			CreatedAt:      now,
+		}
+
+		if _, err := t.store.SaveSuggestion(ctx, suggestion); err != nil {
+			// Log error but continue
+			fmt.Printf("Error saving timeline suggestion for space %s: %v\n", spaceID, err)
+		}
+	}
+}
+
+// authorize checks a curator may edit a space's timeline, returning the
+// ephemeral identity to credit, which is empty for moderators
+func (t *TimelineService) authorize(ctx context.Context, spaceID string, curator space.Curator) (string, error) {
+	s, err := t.spaceStore.GetSpace(ctx, spaceID)
+	if err != nil {
+		return "", fmt.Errorf("error getting space: %w", err)
+	}
+
+	if s.LifecycleStage == space.StageDissolved {
+		return "", space.ErrSpaceClosed
+	}
+
+	if !s.HasFeature(space.FeatureTimeline) {
+		return "", fmt.Errorf("%w: %s", space.ErrFeatureDisabled, space.FeatureTimeline)
+	}
+
+	if curator.Moderator {
+		return "", nil
+	}
+
+	if curator.UserID == "" {
+		return "", space.ErrNotCurator
+	}
+
+	// Owners of user-initiated spaces and identities trusted by the space may curate
+	if curator.UserID != s.OwnerID {
+		tier, err := t.trust.GetTrustTier(ctx, curator.UserID, spaceID)
+		if err != nil && !errors.Is(err, identity.ErrUserNotFound) {
+			return "", fmt.Errorf("error getting trust tier: %w", err)
+		}
+		if tier != identity.TrustTrusted {
+			return "", space.ErrNotCurator
+		}
+	}
+
+	return t.store.FindMemberIdentity(ctx, spaceID, curator.UserID)
+}
+
+// publishUpdate publishes a pinned timeline entry to the space's connected clients
+func (t *TimelineService) publishUpdate(entry space.TimelineEntry) error {
+	data, err := json.Marshal(map[string]interface{}{
+		"type":            "timeline_update",
+		"entry_id":        entry.ID,
+		"content":         entry.Content,
+		"source_url":      entry.SourceURL,
+		"source_platform": entry.SourcePlatform,
+		"occurred_at":     entry.OccurredAt,
+		"pinned_at":       entry.PinnedAt,
+		"time":            time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("error marshaling timeline update: %w", err)
+	}
+
+	return t.eventBus.Publish(fmt.Sprintf("space.%s.timeline", entry.SpaceID), data)
+}
+
+// sourceKey identifies a trend source so it is only suggested once per space
+func sourceKey(source trend.Source) string {
+	if source.ExternalID != "" {
+		return source.Platform + ":" + source.ExternalID
+	}
+	return source.URL
+}
+
+// isWebURL reports whether a link is an absolute http or https URL
+func isWebURL(link string) bool {
+	return strings.HasPrefix(link, "https://") || strings.HasPrefix(link, "http://")
+}
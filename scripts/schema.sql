@@ -16,14 +16,6 @@ CREATE TYPE lifecycle_stage AS ENUM (
     'dissolved'
 );
 
-CREATE TYPE template_type AS ENUM (
-    'general',
-    'breaking_news',
-    'event',
-    'discussion',
-    'local'
-);
-
 CREATE TYPE message_type AS ENUM (
     'text',
     'media',
@@ -77,7 +69,7 @@ CREATE TABLE spaces (
     title TEXT NOT NULL,
     description TEXT,
     trend_id TEXT REFERENCES trends(id),
-    template_type template_type NOT NULL,
+    template_type TEXT NOT NULL, -- Templates are data-driven, so any configured type
     lifecycle_stage lifecycle_stage NOT NULL,
     created_at TIMESTAMPTZ NOT NULL,
     last_active TIMESTAMPTZ NOT NULL,
@@ -90,7 +82,10 @@ CREATE TABLE spaces (
     topic_tags TEXT[],
     related_spaces TEXT[],
     engagement_metrics JSONB,
-    features JSONB
+    features JSONB,
+    owner_id TEXT,
+    is_pinned BOOLEAN NOT NULL DEFAULT FALSE,
+    expiry_override BOOLEAN NOT NULL DEFAULT FALSE
 );
 
 -- Create spatial index on spaces location
@@ -98,6 +93,20 @@ CREATE INDEX spaces_location_idx ON spaces USING GIST (location);
 CREATE INDEX spaces_lifecycle_idx ON spaces (lifecycle_stage);
 CREATE INDEX spaces_created_at_idx ON spaces (created_at);
 CREATE INDEX spaces_template_idx ON spaces (template_type);
+CREATE INDEX spaces_pinned_idx ON spaces (is_pinned, created_at DESC);
+
+-- Space relations table linking adjacent live spaces
+CREATE TABLE space_relations (
+    space_id TEXT NOT NULL REFERENCES spaces(id),
+    related_space_id TEXT NOT NULL REFERENCES spaces(id),
+    score FLOAT NOT NULL,
+    factors JSONB,
+    updated_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (space_id, related_space_id)
+);
+
+-- Create index on space_relations for reverse lookups
+CREATE INDEX space_relations_related_idx ON space_relations (related_space_id);
 
 -- Users table
 CREATE TABLE users (
@@ -108,12 +117,17 @@ CREATE TABLE users (
     location GEOGRAPHY(POINT),
     location_sharing_preference location_sharing_level NOT NULL DEFAULT 'neighborhood',
     default_anonymity BOOLEAN NOT NULL DEFAULT TRUE,
-    notification_preferences JSONB
+    notification_preferences JSONB,
+    is_guest BOOLEAN NOT NULL DEFAULT FALSE, -- Anonymous session with no linked accounts
+    carried_trust TEXT NOT NULL DEFAULT 'new' -- Coarse trust tier carried into new identities
 );
 
 -- Create spatial index on users location
 CREATE INDEX users_location_idx ON users USING GIST (location);
 
+-- Create index on users external IDs for platform lookups
+CREATE INDEX users_external_ids_idx ON users USING GIN (external_ids);
+
 -- Ephemeral identities table
 CREATE TABLE ephemeral_identities (
     id TEXT PRIMARY KEY,
@@ -126,17 +140,57 @@ CREATE TABLE ephemeral_identities (
     location_share_level location_sharing_level NOT NULL DEFAULT 'neighborhood',
     created_at TIMESTAMPTZ NOT NULL,
     last_active TIMESTAMPTZ NOT NULL,
+    left_at TIMESTAMPTZ, -- Set while the user has left the space
     reputation JSONB,
-    UNIQUE (user_id, space_id)
+    UNIQUE (user_id, space_id),
+    CONSTRAINT ephemeral_identities_nickname_key UNIQUE (space_id, nickname)
 );
 
 -- Create index on ephemeral_identities for user and space lookups
 CREATE INDEX ephemeral_identities_user_idx ON ephemeral_identities (user_id);
 CREATE INDEX ephemeral_identities_space_idx ON ephemeral_identities (space_id);
 
+-- Message authors, so reactions and replies can credit whoever wrote a message
+CREATE TABLE message_authors (
+    message_id TEXT PRIMARY KEY,
+    space_id TEXT NOT NULL REFERENCES spaces(id),
+    identity_id TEXT NOT NULL REFERENCES ephemeral_identities(id),
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+-- Create index on message_authors for space cleanup
+CREATE INDEX message_authors_space_idx ON message_authors (space_id);
+
+-- Reputation credits, one per message, giver and dimension so repeats can't inflate reputation
+CREATE TABLE reputation_credits (
+    message_id TEXT NOT NULL REFERENCES message_authors(message_id) ON DELETE CASCADE,
+    giver_identity_id TEXT NOT NULL REFERENCES ephemeral_identities(id),
+    dimension TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (message_id, giver_identity_id, dimension)
+);
+
+-- Credits given before their message's author was recorded, applied once it is
+CREATE TABLE pending_reputation_credits (
+    message_id TEXT NOT NULL,
+    giver_identity_id TEXT NOT NULL,
+    dimension TEXT NOT NULL,
+    delta FLOAT NOT NULL, -- Already weighted by the giver's standing
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+-- Create index on pending_reputation_credits for message lookup
+CREATE INDEX pending_reputation_credits_message_idx ON pending_reputation_credits (message_id, created_at DESC);
+
+-- Create a hypertable for pending_reputation_credits
+SELECT create_hypertable('pending_reputation_credits', 'created_at');
+
+-- Drop credits for messages whose author never arrived
+SELECT add_retention_policy('pending_reputation_credits', INTERVAL '1 day');
+
 -- Messages table
 CREATE TABLE messages (
-    id TEXT PRIMARY KEY,
+    id TEXT NOT NULL,
     space_id TEXT NOT NULL REFERENCES spaces(id),
     user_id TEXT NOT NULL REFERENCES users(id),
     ephemeral_identity_id TEXT REFERENCES ephemeral_identities(id),
@@ -144,14 +198,15 @@ CREATE TABLE messages (
     content TEXT,
     media_urls TEXT[],
     metadata JSONB,
-    reply_to_id TEXT REFERENCES messages(id),
+    reply_to_id TEXT, -- Messages are a hypertable, so replies can't reference them
     status message_status NOT NULL,
     created_at TIMESTAMPTZ NOT NULL,
     updated_at TIMESTAMPTZ NOT NULL,
     location GEOGRAPHY(POINT),
     distance_from_center FLOAT,
     is_anonymous BOOLEAN NOT NULL DEFAULT FALSE,
-    visible_to_roles TEXT[]
+    visible_to_roles TEXT[],
+    PRIMARY KEY (id, created_at) -- Hypertable keys must include the time column
 );
 
 -- Make messages a hypertable for time-series optimization
@@ -165,7 +220,7 @@ CREATE INDEX messages_reply_idx ON messages (reply_to_id);
 -- Reactions table
 CREATE TABLE reactions (
     id TEXT PRIMARY KEY,
-    message_id TEXT NOT NULL REFERENCES messages(id),
+    message_id TEXT NOT NULL, -- Messages are a hypertable, so reactions can't reference them
     user_id TEXT NOT NULL REFERENCES users(id),
     reaction TEXT NOT NULL,
     created_at TIMESTAMPTZ NOT NULL,
@@ -204,6 +259,23 @@ CREATE INDEX rate_limits_lookup_idx ON rate_limits (user_id, action_type, resour
 -- Create a hypertable for rate_limits for efficient time-series data
 SELECT create_hypertable('rate_limits', 'window_start');
 
+-- Drop rate limit windows once they can no longer apply
+SELECT add_retention_policy('rate_limits', INTERVAL '1 day');
+
+-- Guest sessions issued per client, so clients can't start over as new guests to escape rate limits
+CREATE TABLE guest_issuance (
+    client_key TEXT NOT NULL, -- Client IP address, or its /64 for IPv6
+    window_start TIMESTAMPTZ NOT NULL,
+    count INT NOT NULL DEFAULT 1,
+    PRIMARY KEY (client_key, window_start)
+);
+
+-- Create a hypertable for guest_issuance
+SELECT create_hypertable('guest_issuance', 'window_start');
+
+-- Drop guest issuance windows once they can no longer apply
+SELECT add_retention_policy('guest_issuance', INTERVAL '1 day');
+
 -- Local trends table for geo-specific trends
 CREATE TABLE local_trends (
     id TEXT PRIMARY KEY,
@@ -240,6 +312,143 @@ SELECT create_hypertable('space_analytics', 'timestamp');
 -- Create index on space_analytics for space lookup
 CREATE INDEX space_analytics_space_idx ON space_analytics (space_id, timestamp DESC);
 
+-- Space archives table for anonymized summaries of dissolved spaces
+CREATE TABLE space_archives (
+    space_id TEXT PRIMARY KEY,
+    title TEXT NOT NULL,
+    trend_id TEXT,
+    trend_topic TEXT,
+    template_type TEXT NOT NULL, -- Templates are data-driven, so any configured type
+    created_at TIMESTAMPTZ NOT NULL,
+    dissolved_at TIMESTAMPTZ NOT NULL,
+    duration_seconds FLOAT NOT NULL,
+    peak_users INT NOT NULL,
+    message_count INT NOT NULL,
+    top_messages JSONB,
+    engagement_curve JSONB,
+    archived_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+-- Create index on space_archives for retention cleanup
+CREATE INDEX space_archives_expiry_idx ON space_archives (expires_at);
+
+-- Admission control decisions for space creation at capacity
+CREATE TABLE admission_decisions (
+    id TEXT PRIMARY KEY,
+    trend_id TEXT,
+    trend_topic TEXT,
+    trend_score FLOAT NOT NULL,
+    outcome TEXT NOT NULL,
+    reason TEXT,
+    active_spaces INT NOT NULL,
+    capacity INT NOT NULL,
+    space_id TEXT,
+    evicted_space_id TEXT,
+    decided_at TIMESTAMPTZ NOT NULL
+);
+
+-- Create indexes on admission_decisions for admin queries
+CREATE INDEX admission_decisions_decided_at_idx ON admission_decisions (decided_at DESC);
+CREATE INDEX admission_decisions_trend_idx ON admission_decisions (trend_id);
+
+-- Polls run in spaces with the polls feature
+CREATE TABLE polls (
+    id TEXT PRIMARY KEY,
+    space_id TEXT NOT NULL REFERENCES spaces(id),
+    creator_identity_id TEXT NOT NULL REFERENCES ephemeral_identities(id),
+    question TEXT NOT NULL,
+    deadline TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL,
+    closed_at TIMESTAMPTZ
+);
+
+-- Create indexes on polls for space listings and deadline sweeps
+CREATE INDEX polls_space_idx ON polls (space_id, created_at DESC);
+CREATE INDEX polls_open_deadline_idx ON polls (deadline) WHERE closed_at IS NULL;
+
+-- Poll answers in display order
+CREATE TABLE poll_options (
+    id TEXT PRIMARY KEY,
+    poll_id TEXT NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    text TEXT NOT NULL,
+    UNIQUE (poll_id, position)
+);
+
+-- Poll votes, one per ephemeral identity
+CREATE TABLE poll_votes (
+    poll_id TEXT NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+    identity_id TEXT NOT NULL REFERENCES ephemeral_identities(id),
+    option_id TEXT NOT NULL REFERENCES poll_options(id) ON DELETE CASCADE,
+    voted_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (poll_id, identity_id)
+);
+
+-- Create index on poll_votes for tallies
+CREATE INDEX poll_votes_option_idx ON poll_votes (option_id);
+
+-- Curated timeline updates, including suggestions drawn from trend sources
+CREATE TABLE timeline_entries (
+    id TEXT PRIMARY KEY,
+    space_id TEXT NOT NULL REFERENCES spaces(id),
+    status TEXT NOT NULL,
+    content TEXT NOT NULL,
+    source_url TEXT,
+    source_platform TEXT,
+    source_key TEXT,
+    author_identity_id TEXT REFERENCES ephemeral_identities(id),
+    occurred_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    pinned_at TIMESTAMPTZ,
+    UNIQUE (space_id, source_key)
+);
+
+-- Create indexes on timeline_entries for catching up and reviewing suggestions
+CREATE INDEX timeline_entries_pinned_idx ON timeline_entries (space_id, pinned_at) WHERE status = 'pinned';
+CREATE INDEX timeline_entries_suggested_idx ON timeline_entries (space_id, created_at DESC) WHERE status = 'suggested';
+
+-- Identities connected to spaces, one row per replica holding connections
+CREATE TABLE space_presence (
+    space_id TEXT NOT NULL REFERENCES spaces(id),
+    identity_id TEXT NOT NULL REFERENCES ephemeral_identities(id),
+    replica_id TEXT NOT NULL,
+    status TEXT NOT NULL,
+    typing_until TIMESTAMPTZ,
+    connected_at TIMESTAMPTZ NOT NULL,
+    last_seen TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (space_id, identity_id, replica_id)
+);
+
+-- Create indexes on space_presence for expiring records and replica cleanup
+CREATE INDEX space_presence_last_seen_idx ON space_presence (last_seen);
+CREATE INDEX space_presence_replica_idx ON space_presence (replica_id);
+
+-- Last event position handed out in each space. Positions are taken under this
+-- row's lock, so they commit in order and a resuming stream can't skip one.
+CREATE TABLE space_event_counters (
+    space_id TEXT PRIMARY KEY,
+    last_seq BIGINT NOT NULL
+);
+
+-- Recent space events, kept so event streams can resume after a reconnect
+CREATE TABLE space_events (
+    seq BIGINT NOT NULL, -- Position in the space's log, used as the stream event id
+    space_id TEXT NOT NULL,
+    topic TEXT NOT NULL,
+    data JSONB NOT NULL,
+    event_time TIMESTAMPTZ NOT NULL
+);
+
+-- Create index on space_events for replaying a space's events in order
+CREATE INDEX space_events_space_idx ON space_events (space_id, seq);
+
+-- Create a hypertable for space_events
+SELECT create_hypertable('space_events', 'event_time');
+
+-- Drop events once clients are no longer expected to resume from them
+SELECT add_retention_policy('space_events', INTERVAL '1 day');
+
 -- Functions for space lifecycle management
 
 -- Update space last_active timestamp
@@ -312,30 +521,72 @@ $ LANGUAGE plpgsql;
 
 -- Automated cleanup of expired data
 
+-- Clean up archives past their retention period, run periodically by the archiver
+CREATE OR REPLACE FUNCTION cleanup_expired_archives()
+RETURNS void AS $$
+BEGIN
+    DELETE FROM space_archives WHERE expires_at < NOW();
+END;
+$$ LANGUAGE plpgsql;
+
 -- Function to clean up dissolved spaces and related data
 CREATE OR REPLACE FUNCTION cleanup_dissolved_spaces()
-RETURNS void AS $
+RETURNS void AS $$
 DECLARE
     retention_period INTERVAL := '30 days'; -- Adjust based on requirements
+    space_ids TEXT[];
 BEGIN
-    -- Find spaces to clean up
-    WITH spaces_to_clean AS (
-        SELECT id FROM spaces
-        WHERE lifecycle_stage = 'dissolved'
-        AND last_active < NOW() - retention_period
-    )
-    -- Delete related data
+    -- Find spaces to clean up, once, so every delete below covers the same spaces
+    SELECT array_agg(id) INTO space_ids FROM spaces
+    WHERE lifecycle_stage = 'dissolved'
+    AND last_active < NOW() - retention_period;
+
+    IF space_ids IS NULL THEN
+        RETURN;
+    END IF;
+
+    -- Delete related data, children before the rows they reference.
+    -- Poll options and votes go with their polls, and reputation credits
+    -- with their message authors.
+    DELETE FROM polls
+    WHERE space_id = ANY(space_ids);
+
+    DELETE FROM timeline_entries
+    WHERE space_id = ANY(space_ids);
+
+    DELETE FROM space_presence
+    WHERE space_id = ANY(space_ids);
+
+    DELETE FROM reactions
+    WHERE message_id IN (SELECT id FROM messages WHERE space_id = ANY(space_ids));
+
     DELETE FROM messages
-    WHERE space_id IN (SELECT id FROM spaces_to_clean);
-    
+    WHERE space_id = ANY(space_ids);
+
+    DELETE FROM message_authors
+    WHERE space_id = ANY(space_ids);
+
+    DELETE FROM pending_reputation_credits
+    WHERE giver_identity_id IN (SELECT id FROM ephemeral_identities WHERE space_id = ANY(space_ids));
+
+    DELETE FROM space_events
+    WHERE space_id = ANY(space_ids);
+
+    DELETE FROM space_event_counters
+    WHERE space_id = ANY(space_ids);
+
     DELETE FROM ephemeral_identities
-    WHERE space_id IN (SELECT id FROM spaces_to_clean);
-    
+    WHERE space_id = ANY(space_ids);
+
     DELETE FROM space_analytics
-    WHERE space_id IN (SELECT id FROM spaces_to_clean);
-    
+    WHERE space_id = ANY(space_ids);
+
+    DELETE FROM space_relations
+    WHERE space_id = ANY(space_ids)
+    OR related_space_id = ANY(space_ids);
+
     -- Finally, delete the spaces
     DELETE FROM spaces
-    WHERE id IN (SELECT id FROM spaces_to_clean);
+    WHERE id = ANY(space_ids);
 END;
-$ LANGUAGE plpgsql;
\ No newline at end of file
+$$ LANGUAGE plpgsql;
\ No newline at end of file
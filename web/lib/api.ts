@@ -67,39 +67,64 @@ export interface Message {
   replyToUserName?: string // Username of the message this is replying to
 }
 
-// Create a custom base query with anonymous user ID
-const baseQueryWithAnonymousId: BaseQueryFn<
+// Get the token for this browser, starting a server-issued guest session if there is none
+const getAuthToken = async (): Promise<string | null> => {
+  if (typeof window === 'undefined') {
+    return null
+  }
+
+  const existing = localStorage.getItem('auth_token')
+  if (existing) {
+    return existing
+  }
+
+  try {
+    const response = await fetch(`${API_BASE_URL}/v1/auth/guest`, { method: 'POST' })
+    if (!response.ok) {
+      return null
+    }
+    const session: { user_id: string; token: string; is_guest: boolean } = await response.json()
+    localStorage.setItem('auth_token', session.token)
+    return session.token
+  } catch (e) {
+    // Carry on unauthenticated; protected endpoints will reject the request
+    return null
+  }
+}
+
+// Create a custom base query that authenticates every request
+const baseQueryWithAuth: BaseQueryFn<
   string | FetchArgs,
   unknown,
   FetchBaseQueryError
 > = async (args, api, extraOptions) => {
+  const token = await getAuthToken()
+
   const baseQuery = fetchBaseQuery({ 
     baseUrl: API_BASE_URL,
     prepareHeaders: (headers) => {
-      // Add anonymous user ID to headers if available
-      if (typeof window !== 'undefined') {
-        const anonymousUser = localStorage.getItem('anonymous_user')
-        if (anonymousUser) {
-          try {
-            const user = JSON.parse(anonymousUser)
-            headers.set('x-anonymous-user-id', user.id)
-            headers.set('x-anonymous-user-name', user.displayName)
-            headers.set('x-anonymous-user-color', user.color)
-          } catch (e) {
-            // Ignore parsing errors
-          }
-        }
+      // Authenticate with the token issued for this user or guest
+      if (token) {
+        headers.set('Authorization', `Bearer ${token}`)
       }
       return headers
     },
   })
-  return baseQuery(args, api, extraOptions)
+
+  const result = await baseQuery(args, api, extraOptions)
+
+  // Guest sessions are short-lived, so start a new one once the token is rejected
+  if (result.error && result.error.status === 401 && typeof window !== 'undefined') {
+    localStorage.removeItem('auth_token')
+  }
+
+  return result
 }
 
 // Create the API service
 export const api = createApi({
   reducerPath: 'api',
-  baseQuery: baseQueryWithAnonymousId,
+  baseQuery: baseQueryWithAuth,
   tagTypes: ['Spaces', 'Trends', 'Messages', 'SocialTrends'],
   endpoints: (builder) => ({
     // Spaces endpoints
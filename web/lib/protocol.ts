@@ -0,0 +1,210 @@
+// WebSocket frame protocol, mirroring internal/server/handlers/protocol.go.
+// Keep the two in step and bump PROTOCOL_VERSION on breaking changes. Every
+// WebSocket message carries exactly one JSON frame, in both directions.
+//
+// Clients without WebSockets can follow GET /api/v1/spaces/{id}/stream as
+// Server-Sent Events carrying the same server frames, and POST client frames
+// to the same path, which answers with an ack or error frame.
+
+// Newest protocol version this client speaks, sent as the `protocol` query parameter
+export const PROTOCOL_VERSION = 1
+
+// Location as shared in frames, already reduced to the sender's precision
+export interface FrameLocation {
+  latitude: number
+  longitude: number
+  accuracy: number
+  timestamp: string
+}
+
+// Public parts of a sender's identity in a space
+export interface FrameIdentity {
+  id: string
+  nickname: string
+  avatar?: string
+  is_anonymous?: boolean
+}
+
+// Frames sent by the client
+
+export interface MessageClientFrame {
+  type: 'message'
+  client_id?: string
+  content?: string
+  media_urls?: string[]
+  location?: FrameLocation
+  reply_to_id?: string
+}
+
+export interface TypingClientFrame {
+  type: 'typing'
+  client_id?: string
+  is_typing: boolean
+}
+
+export interface ReactionClientFrame {
+  type: 'reaction'
+  client_id?: string
+  message_id: string
+  reaction: string
+}
+
+export interface PingFrame {
+  type: 'ping'
+  client_id?: string
+}
+
+export type ClientFrame = MessageClientFrame | TypingClientFrame | ReactionClientFrame | PingFrame
+
+// Frames sent by the server
+
+export interface WelcomeFrame {
+  type: 'welcome'
+  protocol_version: number
+  space_id: string
+  identity: FrameIdentity
+  features: string[]
+  time: string
+}
+
+export interface MessageFrame {
+  type: 'message'
+  id: string
+  client_id?: string
+  identity: FrameIdentity
+  content?: string
+  media_urls?: string[]
+  location?: FrameLocation
+  reply_to_id?: string
+  reactions?: Record<string, number>
+  time: string
+}
+
+export interface HistoryFrame {
+  type: 'history'
+  messages: MessageFrame[]
+}
+
+// Sent once a frame is handled; messages are stored before they are acked
+export interface AckFrame {
+  type: 'ack'
+  client_id: string
+  id?: string // ID the message was stored under, for messages
+  time: string
+}
+
+export type ErrorCode =
+  | 'invalid_frame'
+  | 'unsupported_type'
+  | 'feature_disabled'
+  | 'rate_limited'
+  | 'internal_error'
+
+export interface ErrorFrame {
+  type: 'error'
+  client_id?: string
+  code: ErrorCode
+  message: string
+  feature?: string
+  retry_at?: string
+  time: string
+}
+
+export interface PongFrame {
+  type: 'pong'
+  client_id?: string
+  time: string
+}
+
+export interface ReactionFrame {
+  type: 'reaction'
+  identity: FrameIdentity
+  message_id: string
+  reaction: string
+  time: string
+}
+
+// Typing lapses if not renewed within the server's typing timeout, even without a stop frame
+export interface TypingFrame {
+  type: 'typing'
+  identity: FrameIdentity
+  is_typing: boolean
+  time: string
+}
+
+// Presence changes as connections come and go, and membership changes
+export interface PresenceFrame {
+  type: 'join' | 'leave' | 'idle' | 'active' | 'member_joined' | 'member_left'
+  space_id: string
+  identity_id: string
+  nickname: string
+  user_count?: number
+  time: string
+}
+
+// Lifecycle stage changes; expires_at is set once the space is dissolving
+export interface LifecycleFrame {
+  type: 'lifecycle'
+  space_id: string
+  stage: string
+  previous_stage: string
+  expires_at?: string | null
+  time: string
+}
+
+// Periodic reminder while a space is due to dissolve, also sent when its expiry moves
+export interface CountdownFrame {
+  type: 'countdown'
+  space_id: string
+  expires_at: string
+  remaining_seconds: number
+  time: string
+}
+
+// Final frame for a space; the server closes the connection after it
+export interface DissolvedFrame {
+  type: 'dissolved'
+  space_id: string
+  previous_stage: string
+  time: string
+}
+
+// Engagement metrics, sent only to connections opened with metrics=true
+export interface MetricsFrame {
+  type: 'metrics'
+  space_id: string
+  lifecycle_stage: string
+  metrics: Record<string, number>
+  time: string
+}
+
+// Other space events relayed as published, such as presence, polls and lifecycle changes
+export interface SpaceEventFrame {
+  type: string
+  [key: string]: unknown
+}
+
+export type ServerFrame =
+  | WelcomeFrame
+  | HistoryFrame
+  | AckFrame
+  | ErrorFrame
+  | PongFrame
+  | MessageFrame
+  | ReactionFrame
+  | TypingFrame
+  | PresenceFrame
+  | LifecycleFrame
+  | CountdownFrame
+  | DissolvedFrame
+  | MetricsFrame
+  | SpaceEventFrame
+
+// Generate a client ID for a frame; resending a frame with the same ID is acked
+// again without sending it twice
+export function newClientId(): string {
+  if (typeof crypto !== 'undefined' && 'randomUUID' in crypto) {
+    return crypto.randomUUID()
+  }
+  return `${Date.now().toString(36)}-${Math.random().toString(36).slice(2, 10)}`
+}